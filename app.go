@@ -7,8 +7,13 @@ import (
 	"talus_helper_windows/internal/clipboard"
 	"talus_helper_windows/internal/config"
 	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/ocr"
+	"talus_helper_windows/internal/secrets"
 	"talus_helper_windows/internal/services"
 	"talus_helper_windows/internal/storage"
+	"talus_helper_windows/internal/tasks"
+	"talus_helper_windows/internal/transform"
+	"talus_helper_windows/internal/usage"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -16,17 +21,27 @@ import (
 // App struct - thin orchestration layer
 type App struct {
 	ctx              context.Context
+	args             []string
 	config           *config.Config
+	provenance       config.Provenance
 	storage          storage.Storage
+	secrets          secrets.SecretStore
 	clipboard        clipboard.Clipboard
 	todoService      *services.TodoService
 	configService    *services.ConfigService
 	clipboardService *services.ClipboardService
+	bundleService    *services.BundleService
+	usageService     *services.UsageService
+	chatService      *services.ChatService
+	taskService      *services.TaskService
+	searchService    *services.SearchService
+	historyService   *services.ClipboardHistoryService
 }
 
-// NewApp creates a new App application struct
-func NewApp() *App {
-	return &App{}
+// NewApp creates a new App application struct.
+// args are the command-line arguments used as the highest config layer.
+func NewApp(args []string) *App {
+	return &App{args: args}
 }
 
 // startup is called when the app starts. The context is saved
@@ -39,13 +54,29 @@ func (a *App) startup(ctx context.Context) {
 
 	// Initialize dependencies
 	var err error
-	a.config, err = config.Load()
+	a.config, a.provenance, err = config.Load(a.args)
 	if err != nil {
-		// Use default config if loading fails
+		// Use default config if loading fails; previous versions remain
+		// available through ListConfigHistory/RestoreConfig
+		fmt.Printf("Failed to load config: %v\n", err)
 		defaultConfig := config.GetDefault()
 		a.config = &defaultConfig
 	}
 
+	// Open the secret store and resolve API keys from it; a.secrets stays
+	// nil when the store cannot be opened, and the keys then come from the
+	// environment
+	if dataDir, err := config.GetDataDir(); err != nil {
+		fmt.Printf("Failed to get data directory: %v\n", err)
+	} else if store, err := secrets.Open(a.config.SecretBackend, dataDir); err != nil {
+		fmt.Printf("Failed to open secret store: %v\n", err)
+	} else {
+		a.secrets = store
+	}
+	if err := config.ResolveSecrets(a.config, a.secrets); err != nil {
+		fmt.Printf("Failed to resolve secrets: %v\n", err)
+	}
+
 	// Initialize SQLite storage
 	a.storage = storage.NewSQLiteStorage()
 	if err := a.storage.Connect(ctx); err != nil {
@@ -59,12 +90,28 @@ func (a *App) startup(ctx context.Context) {
 		fmt.Printf("Failed to migrate database: %v\n", err)
 	}
 
-	a.clipboard = clipboard.NewClipboard()
+	if a.clipboard, err = clipboard.Open(a.config.ClipboardBackend); err != nil {
+		fmt.Printf("Failed to open clipboard: %v\n", err)
+		a.clipboard = clipboard.NewClipboard()
+	}
 
 	// Initialize services
 	a.todoService = services.NewTodoService(ctx, a.storage)
-	a.configService = services.NewConfigService(ctx, a.config)
-	a.clipboardService = services.NewClipboardService(ctx, a.config, a.clipboard)
+	a.configService = services.NewConfigService(ctx, a.config, a.provenance, a.args, a.secrets)
+	emit := func(name string, data ...interface{}) {
+		runtime.EventsEmit(ctx, name, data...)
+	}
+	a.usageService = services.NewUsageService(ctx, a.config, a.storage, emit)
+	a.clipboardService = services.NewClipboardService(ctx, a.config, a.clipboard, a.storage, a.usageService, emit)
+	a.chatService = services.NewChatService(ctx, a.config, a.clipboard, a.storage, a.todoService, a.usageService, emit)
+	a.taskService = services.NewTaskService(ctx, a.config, a.todoService, a.usageService, emit)
+	a.searchService = services.NewSearchService(ctx, a.config, a.storage, a.usageService)
+	a.bundleService = services.NewBundleService(ctx, Version, a.storage, a.secrets, a.configService)
+	a.historyService = services.NewClipboardHistoryService(ctx, a.config, a.clipboard, a.storage, emit)
+	a.configService.OnReload(a.historyService.SetConfig)
+	if err := a.historyService.Start(); err != nil {
+		fmt.Printf("Failed to start clipboard history: %v\n", err)
+	}
 
 	// Print system info in debug mode
 	if a.config.Debug {
@@ -94,6 +141,25 @@ func (a *App) DeleteTodo(id string) error {
 	return a.todoService.DeleteTodo(id)
 }
 
+// ExtractTasks finds the action items in text, such as meeting notes or an
+// OCR result, and returns them as proposed todos for review
+func (a *App) ExtractTasks(text string) ([]tasks.Task, error) {
+	return a.taskService.ExtractTasks(text)
+}
+
+// AddTasks adds the accepted proposed todos to the todo list
+func (a *App) AddTasks(accepted []tasks.Task) ([]models.Todo, error) {
+	return a.taskService.AddTasks(accepted)
+}
+
+// Search methods - delegated to SearchService
+
+// SemanticSearch finds todos, OCR results and Workflowy nodes by meaning, best match first.
+// limit 0 uses the configured number of results.
+func (a *App) SemanticSearch(query string, limit int) ([]models.SearchResult, error) {
+	return a.searchService.SemanticSearch(query, limit)
+}
+
 // Config methods - delegated to ConfigService
 
 // GetConfig returns the current configuration
@@ -106,11 +172,184 @@ func (a *App) SaveConfig(cfg config.Config) error {
 	return a.configService.SaveConfig(cfg)
 }
 
+// ExplainConfig reports which layer supplied each effective config value
+func (a *App) ExplainConfig() []config.FieldSource {
+	return a.configService.Explain()
+}
+
+// ListConfigHistory returns the previous versions of the config file, newest first
+func (a *App) ListConfigHistory() ([]config.HistoryEntry, error) {
+	return a.configService.ListConfigHistory()
+}
+
+// RestoreConfig replaces the config file with a previous version
+func (a *App) RestoreConfig(id string) error {
+	return a.configService.RestoreConfig(id)
+}
+
+// GetSecrets returns the masked status of the configured API keys
+func (a *App) GetSecrets() ([]services.SecretStatus, error) {
+	return a.configService.GetSecrets()
+}
+
+// SetSecret stores an API key in the secret store; an empty value clears it
+func (a *App) SetSecret(name, value string) error {
+	return a.configService.SetSecret(name, value)
+}
+
+// Bundle methods - delegated to BundleService
+
+// ExportBundle writes settings and data to a bundle file, without secrets
+func (a *App) ExportBundle(path string) error {
+	return a.bundleService.ExportBundle(path, "")
+}
+
+// ExportBundleWithSecrets writes settings, data and secrets encrypted with passphrase to a bundle file
+func (a *App) ExportBundleWithSecrets(path, passphrase string) error {
+	return a.bundleService.ExportBundle(path, passphrase)
+}
+
+// ImportBundle restores settings and data from a bundle file; any secrets in it are skipped
+func (a *App) ImportBundle(path string) (services.ImportResult, error) {
+	return a.bundleService.ImportBundle(path, "")
+}
+
+// ImportBundleWithSecrets restores settings, data and secrets from a bundle file
+func (a *App) ImportBundleWithSecrets(path, passphrase string) (services.ImportResult, error) {
+	return a.bundleService.ImportBundle(path, passphrase)
+}
+
 // Clipboard methods - delegated to ClipboardService
 
-// OCRFromClipboard extracts text from clipboard image using OpenAI Vision API
-func (a *App) OCRFromClipboard() (string, error) {
-	return a.clipboardService.OCRFromClipboard()
+// OCRFromClipboard extracts text from clipboard image using the configured OCR providers.
+// A cached result for the same image is returned unless forceRefresh is set.
+func (a *App) OCRFromClipboard(forceRefresh bool) (string, error) {
+	return a.clipboardService.OCRFromClipboard(forceRefresh)
+}
+
+// OCRStructuredFromClipboard reads the clipboard image as a table, code or key-value fields
+func (a *App) OCRStructuredFromClipboard(mode string, forceRefresh bool) (ocr.StructuredResult, error) {
+	return a.clipboardService.OCRStructuredFromClipboard(mode, forceRefresh)
+}
+
+// OCRAndTranslate extracts text from the clipboard image and translates it into
+// language, or the configured language if empty, keeping the original text
+func (a *App) OCRAndTranslate(language string, forceRefresh bool) (models.OCRResult, error) {
+	return a.clipboardService.OCRAndTranslate(language, forceRefresh)
+}
+
+// GetOCRHistory returns up to limit recent OCR results, newest first
+func (a *App) GetOCRHistory(limit int) ([]models.OCRResult, error) {
+	return a.clipboardService.GetOCRHistory(limit)
+}
+
+// DeleteOCRResult removes a result from the OCR history
+func (a *App) DeleteOCRResult(id string) error {
+	return a.clipboardService.DeleteOCRResult(id)
+}
+
+// ClearOCRCache removes all cached OCR results
+func (a *App) ClearOCRCache() error {
+	return a.clipboardService.ClearOCRCache()
+}
+
+// CancelOCR aborts the running OCR request, if any
+func (a *App) CancelOCR() {
+	a.clipboardService.CancelOCR()
+}
+
+// TransformClipboard runs the clipboard text through the named transformers or chains
+// in order and puts the result back on the clipboard
+func (a *App) TransformClipboard(names []string) (string, error) {
+	return a.clipboardService.Transform(names...)
+}
+
+// ListTransformers returns the built-in clipboard text transformers and the configured chains
+func (a *App) ListTransformers() ([]transform.Transformer, error) {
+	return a.clipboardService.ListTransformers()
+}
+
+// ListOCRModels returns the models offered by the configured OpenAI-compatible endpoint
+func (a *App) ListOCRModels() ([]string, error) {
+	return a.clipboardService.ListModels()
+}
+
+// Clipboard history methods - delegated to ClipboardHistoryService
+
+// GetClipboardHistory returns up to limit clipboard history entries whose text or OCR text
+// contains query, pinned first. An empty query lists all entries.
+func (a *App) GetClipboardHistory(query string, limit int) ([]models.ClipboardEntry, error) {
+	return a.historyService.GetClipboardHistory(query, limit)
+}
+
+// CopyClipboardEntry puts a clipboard history entry back on the clipboard
+func (a *App) CopyClipboardEntry(id string) error {
+	return a.historyService.CopyClipboardEntry(id)
+}
+
+// PinClipboardEntry pins or unpins a clipboard history entry
+func (a *App) PinClipboardEntry(id string, pinned bool) error {
+	return a.historyService.PinClipboardEntry(id, pinned)
+}
+
+// DeleteClipboardEntry removes an entry from the clipboard history
+func (a *App) DeleteClipboardEntry(id string) error {
+	return a.historyService.DeleteClipboardEntry(id)
+}
+
+// ClearClipboardHistory removes all unpinned entries from the clipboard history
+func (a *App) ClearClipboardHistory() error {
+	return a.historyService.ClearClipboardHistory()
+}
+
+// Chat methods - delegated to ChatService
+
+// GetConversations returns all chat conversations, most recently updated first
+func (a *App) GetConversations() ([]models.Conversation, error) {
+	return a.chatService.GetConversations()
+}
+
+// CreateConversation starts an empty conversation; an empty title is taken from the first message
+func (a *App) CreateConversation(title string) (models.Conversation, error) {
+	return a.chatService.CreateConversation(title)
+}
+
+// GetChatMessages returns the messages of a conversation, oldest first
+func (a *App) GetChatMessages(conversationID string) ([]models.ChatMessage, error) {
+	return a.chatService.GetChatMessages(conversationID)
+}
+
+// SendChatMessage continues a conversation and returns the assistant's reply.
+// If attachImage is set, the clipboard image is sent with the text.
+func (a *App) SendChatMessage(conversationID, text string, attachImage bool) (models.ChatMessage, error) {
+	return a.chatService.SendMessage(conversationID, text, attachImage)
+}
+
+// DeleteConversation deletes a conversation, its messages and tool invocations
+func (a *App) DeleteConversation(id string) error {
+	return a.chatService.DeleteConversation(id)
+}
+
+// CancelChat aborts the running chat request, if any
+func (a *App) CancelChat() {
+	a.chatService.CancelChat()
+}
+
+// ConfirmToolCall approves or declines a destructive tool call requested with a chat:confirm event
+func (a *App) ConfirmToolCall(id string, approved bool) error {
+	return a.chatService.ConfirmToolCall(id, approved)
+}
+
+// GetToolInvocations returns the tools the assistant called in a conversation, oldest first
+func (a *App) GetToolInvocations(conversationID string) ([]models.ToolInvocation, error) {
+	return a.chatService.GetToolInvocations(conversationID)
+}
+
+// Usage methods - delegated to UsageService
+
+// GetUsageReport summarizes LLM token usage and cost over the last days days
+func (a *App) GetUsageReport(days int) (usage.Report, error) {
+	return a.usageService.GetUsageReport(days)
 }
 
 // printSystemInfo prints system information when in debug mode
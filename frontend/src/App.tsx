@@ -1,5 +1,8 @@
 import { BrowserRouter as Router, Routes, Route } from 'react-router-dom'
 import TodoList from './components/TodoList'
+import Chat from './components/Chat'
+import Search from './components/Search'
+import ClipboardHistory from './components/ClipboardHistory'
 import Settings from './components/Settings'
 import Tabs, { navigationTabs } from './components/Tabs'
 import { ThemeProvider } from './contexts/ThemeContext'
@@ -13,6 +16,9 @@ function App() {
           <main className="max-w-7xl mx-auto py-6 sm:px-6 lg:px-8">
             <Routes>
               <Route path="/" element={<TodoList />} />
+              <Route path="/chat" element={<Chat />} />
+              <Route path="/search" element={<Search />} />
+              <Route path="/clipboard" element={<ClipboardHistory />} />
               <Route path="/settings/*" element={<Settings />} />
             </Routes>
           </main>
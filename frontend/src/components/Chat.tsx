@@ -0,0 +1,313 @@
+import { useState, useEffect, useRef } from 'react'
+import { CancelChat, ConfirmToolCall, CreateConversation, DeleteConversation, GetChatMessages, GetConversations, GetToolInvocations, SendChatMessage } from '@wailsjs/go/main/App'
+import { EventsOn } from '@wailsjs/runtime/runtime'
+import { BudgetWarning, ChatMessage, Conversation, SensitiveReport, ToolConfirmation, ToolInvocation } from '../types'
+import { sensitiveMessage } from '../sensitive'
+import { AlertCircle, Check, Image as ImageIcon, MessageSquare, Plus, Send, Trash2, Wrench, X } from 'lucide-react'
+
+function Chat() {
+  const [conversations, setConversations] = useState<Conversation[]>([])
+  const [activeId, setActiveId] = useState<string | null>(null)
+  const [messages, setMessages] = useState<ChatMessage[]>([])
+  const [invocations, setInvocations] = useState<ToolInvocation[]>([])
+  // confirmation is a destructive tool call waiting for the user's answer
+  const [confirmation, setConfirmation] = useState<ToolConfirmation | null>(null)
+  const [input, setInput] = useState('')
+  const [attachImage, setAttachImage] = useState(false)
+  // pending is the user's message while its reply is on the way
+  const [pending, setPending] = useState<string | null>(null)
+  const [partialReply, setPartialReply] = useState('')
+  const [error, setError] = useState<string | null>(null)
+  const bottomRef = useRef<HTMLDivElement>(null)
+
+  useEffect(() => {
+    loadConversations()
+  }, [])
+
+  useEffect(() => {
+    if (activeId) {
+      loadMessages(activeId)
+    } else {
+      setMessages([])
+      setInvocations([])
+    }
+  }, [activeId])
+
+  // Show streamed replies as they arrive
+  useEffect(() => {
+    return EventsOn('chat:progress', (progress: { conversationId: string; text: string }) => {
+      setPartialReply(progress.text)
+    })
+  }, [])
+
+  useEffect(() => {
+    return EventsOn('chat:confirm', (request: ToolConfirmation) => {
+      setConfirmation(request)
+    })
+  }, [])
+
+  useEffect(() => {
+    return EventsOn('usage:budget', (warning: BudgetWarning) => {
+      setError(`The ${warning.period} AI budget is used up (${warning.spent.toFixed(2)} of ${warning.budget.toFixed(2)})`)
+    })
+  }, [])
+
+  // Tell the user when a message with sensitive data was redacted or not sent
+  useEffect(() => {
+    return EventsOn('sensitive:detected', (report: SensitiveReport) => {
+      if (report.source === 'chat') {
+        setError(sensitiveMessage(report))
+      }
+    })
+  }, [])
+
+  useEffect(() => {
+    bottomRef.current?.scrollIntoView({ behavior: 'smooth' })
+  }, [messages, pending, partialReply])
+
+  const loadConversations = async () => {
+    try {
+      setConversations((await GetConversations()) || [])
+    } catch (error) {
+      console.error('Failed to load conversations:', error)
+    }
+  }
+
+  const loadMessages = async (id: string) => {
+    try {
+      const [loaded, calls] = await Promise.all([GetChatMessages(id), GetToolInvocations(id)])
+      setMessages(loaded || [])
+      setInvocations(calls || [])
+    } catch (error) {
+      console.error('Failed to load messages:', error)
+    }
+  }
+
+  const handleConfirm = async (approved: boolean) => {
+    if (!confirmation) return
+    try {
+      await ConfirmToolCall(confirmation.id, approved)
+    } catch (error) {
+      console.error('Failed to answer tool confirmation:', error)
+    } finally {
+      setConfirmation(null)
+    }
+  }
+
+  // Show tool calls between the messages, in the order they happened
+  const timeline = [
+    ...messages.map(message => ({ time: new Date(message.createdAt).getTime(), message })),
+    ...invocations.map(invocation => ({ time: new Date(invocation.createdAt).getTime(), invocation })),
+  ].sort((a, b) => a.time - b.time)
+
+  const handleNewConversation = () => {
+    setActiveId(null)
+    setInput('')
+    setError(null)
+  }
+
+  const handleDeleteConversation = async (id: string) => {
+    try {
+      await DeleteConversation(id)
+      setConversations(prev => prev.filter(c => c.id !== id))
+      if (activeId === id) {
+        setActiveId(null)
+      }
+    } catch (error) {
+      console.error('Failed to delete conversation:', error)
+    }
+  }
+
+  const handleSend = async (e: React.FormEvent) => {
+    e.preventDefault()
+    if ((!input.trim() && !attachImage) || pending !== null) return
+
+    const text = input.trim()
+    try {
+      setError(null)
+      setPending(text || 'Image from clipboard')
+      setPartialReply('')
+
+      let id = activeId
+      if (!id) {
+        id = (await CreateConversation('')).id
+        setActiveId(id)
+      }
+      await SendChatMessage(id, text, attachImage)
+      setInput('')
+      setAttachImage(false)
+      await loadMessages(id)
+    } catch (error) {
+      console.error('Failed to send message:', error)
+      setError(String(error))
+    } finally {
+      setPending(null)
+      setPartialReply('')
+      setConfirmation(null)
+      loadConversations()
+    }
+  }
+
+  return (
+    <div className="px-4 py-6 sm:px-0">
+      <div className="flex gap-6">
+        {/* Conversation list */}
+        <div className="w-64 flex-shrink-0">
+          <button onClick={handleNewConversation} className="btn-primary w-full flex items-center justify-center gap-2 mb-4">
+            <Plus className="w-4 h-4" />
+            New Chat
+          </button>
+          <div className="space-y-1">
+            {conversations.map(conversation => (
+              <div
+                key={conversation.id}
+                className={`group flex items-center gap-2 px-3 py-2 rounded-md cursor-pointer ${
+                  conversation.id === activeId
+                    ? 'bg-primary-100 dark:bg-primary-900 text-primary-700 dark:text-primary-300'
+                    : 'text-gray-700 dark:text-gray-300 hover:bg-gray-100 dark:hover:bg-gray-800'
+                }`}
+                onClick={() => setActiveId(conversation.id)}
+              >
+                <MessageSquare className="w-4 h-4 flex-shrink-0" />
+                <span className="flex-1 truncate text-sm">{conversation.title || 'New chat'}</span>
+                <button
+                  onClick={(e) => {
+                    e.stopPropagation()
+                    handleDeleteConversation(conversation.id)
+                  }}
+                  className="opacity-0 group-hover:opacity-100 text-gray-400 hover:text-red-600 dark:hover:text-red-400"
+                  title="Delete conversation"
+                >
+                  <Trash2 className="w-4 h-4" />
+                </button>
+              </div>
+            ))}
+          </div>
+        </div>
+
+        {/* Messages */}
+        <div className="flex-1 card flex flex-col min-h-[32rem]">
+          {error && (
+            <div className="mb-4 p-4 message-error rounded-lg flex items-center gap-2">
+              <AlertCircle className="w-5 h-5" />
+              {error}
+            </div>
+          )}
+
+          <div className="flex-1 overflow-y-auto space-y-4 mb-4">
+            {messages.length === 0 && pending === null && (
+              <p className="text-center text-gray-500 dark:text-gray-400 py-12">
+                Ask anything, or attach the clipboard image to ask about it
+              </p>
+            )}
+            {timeline.map(({ message, invocation }) => message ? (
+              <MessageBubble key={message.id} role={message.role} text={message.content} image={message.image} imageFormat={message.imageFormat} />
+            ) : invocation && (
+              <ToolCall key={invocation.id} invocation={invocation} />
+            ))}
+            {pending !== null && (
+              <>
+                <MessageBubble role="user" text={pending} />
+                <MessageBubble role="assistant" text={partialReply || '…'} />
+              </>
+            )}
+            <div ref={bottomRef} />
+          </div>
+
+          {confirmation && (
+            <div className="mb-4 p-4 rounded-lg border border-yellow-300 dark:border-yellow-700 bg-yellow-50 dark:bg-yellow-900/30">
+              <p className="text-sm font-medium text-gray-900 dark:text-gray-100 mb-1">
+                The assistant wants to run <span className="font-mono">{confirmation.name}</span>
+              </p>
+              <p className="text-sm form-description mb-2">{confirmation.description}</p>
+              <pre className="text-xs font-mono whitespace-pre-wrap text-gray-700 dark:text-gray-300 mb-3">{confirmation.arguments}</pre>
+              <div className="flex gap-2">
+                <button onClick={() => handleConfirm(true)} className="btn-primary flex items-center gap-2">
+                  <Check className="w-4 h-4" />
+                  Allow
+                </button>
+                <button onClick={() => handleConfirm(false)} className="btn-secondary flex items-center gap-2">
+                  <X className="w-4 h-4" />
+                  Decline
+                </button>
+              </div>
+            </div>
+          )}
+
+          <form onSubmit={handleSend} className="flex gap-2">
+            <button
+              type="button"
+              onClick={() => setAttachImage(!attachImage)}
+              className={`${attachImage ? 'btn-primary' : 'btn-secondary'} flex items-center`}
+              title={attachImage ? 'The clipboard image will be sent' : 'Attach the clipboard image'}
+            >
+              <ImageIcon className="w-4 h-4" />
+            </button>
+            <input
+              type="text"
+              value={input}
+              onChange={(e) => setInput(e.target.value)}
+              placeholder="Message the assistant..."
+              className="input-field flex-1"
+              disabled={pending !== null}
+            />
+            {pending !== null ? (
+              <button type="button" onClick={() => CancelChat()} className="btn-secondary flex items-center gap-2">
+                <X className="w-4 h-4" />
+                Cancel
+              </button>
+            ) : (
+              <button type="submit" disabled={!input.trim() && !attachImage} className="btn-primary flex items-center gap-2">
+                <Send className="w-4 h-4" />
+                Send
+              </button>
+            )}
+          </form>
+        </div>
+      </div>
+    </div>
+  )
+}
+
+interface MessageBubbleProps {
+  role: string
+  text: string
+  // image is base64-encoded image data
+  image?: unknown
+  imageFormat?: string
+}
+
+function MessageBubble({ role, text, image, imageFormat }: MessageBubbleProps) {
+  const isUser = role === 'user'
+  return (
+    <div className={`flex ${isUser ? 'justify-end' : 'justify-start'}`}>
+      <div className={`max-w-[80%] rounded-lg px-4 py-2 ${
+        isUser
+          ? 'bg-primary-600 text-white'
+          : 'bg-gray-100 dark:bg-gray-700 text-gray-900 dark:text-gray-100'
+      }`}>
+        {typeof image === 'string' && image && (
+          <img src={`data:image/${imageFormat || 'png'};base64,${image}`} alt="" className="max-h-48 rounded mb-2" />
+        )}
+        {text && <p className="text-sm whitespace-pre-wrap">{text}</p>}
+      </div>
+    </div>
+  )
+}
+
+function ToolCall({ invocation }: { invocation: ToolInvocation }) {
+  const statusClass = invocation.status === 'ok'
+    ? 'text-gray-500 dark:text-gray-400'
+    : invocation.status === 'declined'
+      ? 'text-yellow-600 dark:text-yellow-400'
+      : 'text-red-600 dark:text-red-400'
+  return (
+    <div className={`flex items-center gap-2 text-xs ${statusClass}`} title={invocation.result}>
+      <Wrench className="w-3 h-3 flex-shrink-0" />
+      <span className="font-mono truncate">{invocation.name}({invocation.arguments})</span>
+      {invocation.status !== 'ok' && <span>· {invocation.status}</span>}
+    </div>
+  )
+}
+
+export default Chat
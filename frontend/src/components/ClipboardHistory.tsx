@@ -0,0 +1,220 @@
+import { useEffect, useState } from 'react'
+import { ClearClipboardHistory, CopyClipboardEntry, DeleteClipboardEntry, GetClipboardHistory, ListTransformers, PinClipboardEntry, TransformClipboard } from '@wailsjs/go/main/App'
+import { EventsOn } from '@wailsjs/runtime/runtime'
+import { ClipboardEntry, SensitiveReport, Transformer } from '../types'
+import { sensitiveMessage } from '../sensitive'
+import { AlertCircle, Copy, Pin, PinOff, Play, Search as SearchIcon, ShieldAlert, Trash2, Wand2, X } from 'lucide-react'
+
+function ClipboardHistory() {
+  const [entries, setEntries] = useState<ClipboardEntry[]>([])
+  const [query, setQuery] = useState('')
+  const [error, setError] = useState<string | null>(null)
+  const [copiedId, setCopiedId] = useState<string | null>(null)
+  const [transformers, setTransformers] = useState<Transformer[]>([])
+  const [pipeline, setPipeline] = useState<string[]>([])
+  const [transformed, setTransformed] = useState<string | null>(null)
+
+  const loadEntries = async (search: string) => {
+    try {
+      // 0 returns the default number of entries
+      setEntries((await GetClipboardHistory(search.trim(), 0)) || [])
+      setError(null)
+    } catch (error) {
+      console.error('Failed to load clipboard history:', error)
+      setError(String(error))
+    }
+  }
+
+  useEffect(() => {
+    loadEntries(query)
+    return EventsOn('clipboard:history', () => loadEntries(query))
+  }, [query])
+
+  // Tell the user when copied text with sensitive data was redacted or not kept
+  useEffect(() => {
+    return EventsOn('sensitive:detected', (report: SensitiveReport) => {
+      if (report.source === 'clipboard') {
+        setError(sensitiveMessage(report))
+      }
+    })
+  }, [])
+
+  useEffect(() => {
+    ListTransformers()
+      .then(list => setTransformers(list || []))
+      .catch(error => {
+        console.error('Failed to load transformers:', error)
+        setError(String(error))
+      })
+  }, [])
+
+  const handleTransform = async () => {
+    try {
+      setTransformed(await TransformClipboard(pipeline))
+      setError(null)
+    } catch (error) {
+      console.error('Failed to transform clipboard text:', error)
+      setTransformed(null)
+      setError(String(error))
+    }
+  }
+
+  const runAction = async (action: () => Promise<void>) => {
+    try {
+      await action()
+      await loadEntries(query)
+    } catch (error) {
+      console.error('Clipboard history action failed:', error)
+      setError(String(error))
+    }
+  }
+
+  const handleCopy = async (id: string) => {
+    await runAction(() => CopyClipboardEntry(id))
+    setCopiedId(id)
+    setTimeout(() => setCopiedId(null), 1500)
+  }
+
+  const handleClear = async () => {
+    if (!confirm('Remove all unpinned entries from the clipboard history?')) return
+    await runAction(() => ClearClipboardHistory())
+  }
+
+  return (
+    <div className="px-4 py-6 sm:px-0">
+      <div className="card mb-8">
+        <div className="flex gap-2">
+          <div className="relative flex-1">
+            <SearchIcon className="w-4 h-4 absolute left-3 top-1/2 -translate-y-1/2 text-gray-400" />
+            <input
+              type="text"
+              value={query}
+              onChange={(e) => setQuery(e.target.value)}
+              placeholder="Search copied text and the OCR text of images..."
+              className="input-field w-full pl-9"
+            />
+          </div>
+          <button onClick={handleClear} className="btn-secondary flex items-center gap-2">
+            <Trash2 className="w-4 h-4" />
+            Clear
+          </button>
+        </div>
+        <p className="text-sm form-description mt-2">
+          Texts and images are added as they are copied; pinned entries are kept until deleted
+        </p>
+      </div>
+
+      <div className="card mb-8">
+        <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+          <Wand2 className="w-5 h-5" />
+          Transform Clipboard Text
+        </h3>
+        <div className="flex flex-wrap gap-2">
+          {transformers.map(transformer => (
+            <button
+              key={transformer.name}
+              onClick={() => setPipeline([...pipeline, transformer.name])}
+              className={transformer.steps?.length ? 'btn-primary text-xs' : 'btn-secondary text-xs'}
+              title={transformer.description}
+            >
+              {transformer.name}
+            </button>
+          ))}
+        </div>
+        <div className="flex items-center gap-2 mt-4">
+          <p className="flex-1 text-sm text-gray-900 dark:text-gray-100 truncate">
+            {pipeline.length ? pipeline.join(' → ') : 'Pick transformers to run in order'}
+          </p>
+          <button
+            onClick={() => { setPipeline([]); setTransformed(null) }}
+            className="text-gray-400 hover:text-red-600 dark:hover:text-red-400"
+            title="Clear"
+          >
+            <X className="w-4 h-4" />
+          </button>
+          <button onClick={handleTransform} disabled={!pipeline.length} className="btn-primary flex items-center gap-2">
+            <Play className="w-4 h-4" />
+            Run
+          </button>
+        </div>
+        {transformed !== null && (
+          <pre className="mt-4 p-3 rounded-lg bg-gray-50 dark:bg-gray-800 text-sm text-gray-900 dark:text-gray-100 whitespace-pre-wrap break-words max-h-64 overflow-auto">
+            {transformed}
+          </pre>
+        )}
+        <p className="text-sm form-description mt-2">
+          The result replaces the clipboard text. Chains are highlighted and can be defined in the general settings.
+        </p>
+      </div>
+
+      {error && (
+        <div className="mb-4 p-4 message-error rounded-lg flex items-center gap-2">
+          <AlertCircle className="w-5 h-5" />
+          {error}
+        </div>
+      )}
+
+      <div className="card">
+        {entries.length === 0 ? (
+          <p className="text-center text-gray-500 dark:text-gray-400 py-8">
+            {query.trim() ? 'No matches' : 'Nothing copied yet'}
+          </p>
+        ) : (
+          <div className="space-y-3">
+            {entries.map(entry => (
+              <div key={entry.id} className="flex items-start gap-3 p-3 rounded-lg bg-gray-50 dark:bg-gray-800">
+                <div className="flex-1 min-w-0">
+                  {entry.kind === 'image' ? (
+                    <div className="flex items-start gap-3">
+                      <img src={`data:image/png;base64,${entry.thumbnail}`} alt="" className="max-h-32 rounded" />
+                      {entry.ocrText && (
+                        <p className="text-sm text-gray-600 dark:text-gray-400 whitespace-pre-wrap line-clamp-4">{entry.ocrText}</p>
+                      )}
+                    </div>
+                  ) : (
+                    <p className="text-sm text-gray-900 dark:text-gray-100 whitespace-pre-wrap break-words line-clamp-4">{entry.text}</p>
+                  )}
+                  <p className="text-xs text-gray-500 dark:text-gray-400 mt-1 flex items-center gap-2">
+                    {new Date(entry.copiedAt).toLocaleString()}
+                    {entry.sensitive?.length > 0 && (
+                      <span
+                        className="flex items-center gap-1 text-yellow-600 dark:text-yellow-400"
+                        title={entry.pinned ? 'Contains sensitive data' : 'Contains sensitive data; expires unless pinned'}
+                      >
+                        <ShieldAlert className="w-3 h-3" />
+                        {entry.sensitive.join(', ')}
+                      </span>
+                    )}
+                  </p>
+                </div>
+                <button
+                  onClick={() => handleCopy(entry.id)}
+                  className="text-gray-400 hover:text-primary-600 dark:hover:text-primary-400"
+                  title={copiedId === entry.id ? 'Copied' : 'Copy again'}
+                >
+                  <Copy className="w-4 h-4" />
+                </button>
+                <button
+                  onClick={() => runAction(() => PinClipboardEntry(entry.id, !entry.pinned))}
+                  className={entry.pinned ? 'text-primary-600 dark:text-primary-400' : 'text-gray-400 hover:text-primary-600 dark:hover:text-primary-400'}
+                  title={entry.pinned ? 'Unpin' : 'Pin'}
+                >
+                  {entry.pinned ? <PinOff className="w-4 h-4" /> : <Pin className="w-4 h-4" />}
+                </button>
+                <button
+                  onClick={() => runAction(() => DeleteClipboardEntry(entry.id))}
+                  className="text-gray-400 hover:text-red-600 dark:hover:text-red-400"
+                  title="Delete"
+                >
+                  <Trash2 className="w-4 h-4" />
+                </button>
+              </div>
+            ))}
+          </div>
+        )}
+      </div>
+    </div>
+  )
+}
+
+export default ClipboardHistory
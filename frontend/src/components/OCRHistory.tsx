@@ -0,0 +1,129 @@
+import { useState, useEffect } from 'react'
+import { DeleteOCRResult, GetOCRHistory } from '@wailsjs/go/main/App'
+import { OCRResult } from '../types'
+import { languageLabel } from '../languages'
+import { Copy, ListChecks, Plus, Trash2 } from 'lucide-react'
+
+interface OCRHistoryProps {
+  // onUse puts a result's text into the new todo field
+  onUse: (text: string) => void
+  onExtractTasks: (text: string) => void
+}
+
+const modeLabels: Record<string, string> = {
+  text: 'Text',
+  translate: 'Translation',
+  table: 'Table',
+  code: 'Code',
+  keyvalue: 'Fields',
+}
+
+function OCRHistory({ onUse, onExtractTasks }: OCRHistoryProps) {
+  const [results, setResults] = useState<OCRResult[]>([])
+  const [loading, setLoading] = useState(true)
+
+  useEffect(() => {
+    loadHistory()
+  }, [])
+
+  const loadHistory = async () => {
+    try {
+      setLoading(true)
+      setResults((await GetOCRHistory(0)) || [])
+    } catch (error) {
+      console.error('Failed to load OCR history:', error)
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  const handleDelete = async (id: string) => {
+    try {
+      await DeleteOCRResult(id)
+      setResults(prev => prev.filter(r => r.id !== id))
+    } catch (error) {
+      console.error('Failed to delete OCR result:', error)
+    }
+  }
+
+  const handleCopy = async (text: string) => {
+    try {
+      await navigator.clipboard.writeText(text)
+    } catch (error) {
+      console.error('Failed to copy OCR result:', error)
+    }
+  }
+
+  if (loading) {
+    return (
+      <div className="card mb-8 flex justify-center py-6">
+        <div className="animate-spin rounded-full h-6 w-6 border-b-2 border-primary-600"></div>
+      </div>
+    )
+  }
+
+  return (
+    <div className="card mb-8">
+      <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4">OCR History</h3>
+      {results.length === 0 ? (
+        <p className="text-sm form-description">No OCR results yet</p>
+      ) : (
+        <div className="space-y-3 max-h-96 overflow-y-auto">
+          {results.map(result => {
+            const text = result.translation || result.text
+            return (
+              <div key={result.id} className="p-3 bg-gray-50 dark:bg-gray-700 rounded-lg">
+                <div className="flex items-center justify-between mb-1">
+                  <span className="text-xs text-gray-500 dark:text-gray-400">
+                    {modeLabels[result.mode] || result.mode}
+                    {result.language && ` · ${languageLabel(result.language)}`}
+                    {' · '}
+                    {new Date(result.createdAt).toLocaleString()}
+                  </span>
+                  <div className="flex gap-1">
+                    <button
+                      onClick={() => onUse(text)}
+                      className="p-1 text-gray-400 hover:text-primary-600 dark:hover:text-primary-400"
+                      title="Use as new todo"
+                    >
+                      <Plus className="w-4 h-4" />
+                    </button>
+                    <button
+                      onClick={() => onExtractTasks(text)}
+                      className="p-1 text-gray-400 hover:text-primary-600 dark:hover:text-primary-400"
+                      title="Extract tasks"
+                    >
+                      <ListChecks className="w-4 h-4" />
+                    </button>
+                    <button
+                      onClick={() => handleCopy(text)}
+                      className="p-1 text-gray-400 hover:text-primary-600 dark:hover:text-primary-400"
+                      title="Copy to clipboard"
+                    >
+                      <Copy className="w-4 h-4" />
+                    </button>
+                    <button
+                      onClick={() => handleDelete(result.id)}
+                      className="p-1 text-gray-400 hover:text-red-600 dark:hover:text-red-400"
+                      title="Delete from history"
+                    >
+                      <Trash2 className="w-4 h-4" />
+                    </button>
+                  </div>
+                </div>
+                <p className="text-sm text-gray-900 dark:text-gray-100 whitespace-pre-wrap line-clamp-4">{text}</p>
+                {result.translation && (
+                  <p className="text-sm text-gray-500 dark:text-gray-400 whitespace-pre-wrap line-clamp-2 mt-1">
+                    {result.text}
+                  </p>
+                )}
+              </div>
+            )
+          })}
+        </div>
+      )}
+    </div>
+  )
+}
+
+export default OCRHistory
@@ -0,0 +1,124 @@
+import { useState, useEffect } from 'react'
+import { OCRResult, StructuredOCRResult } from '../types'
+import { ocr } from '@wailsjs/go/models'
+import { languageLabel } from '../languages'
+import { Check, Copy, X } from 'lucide-react'
+
+export interface ResultView {
+  id: string
+  label: string
+  text: string
+}
+
+interface OCRResultPanelProps {
+  views: ResultView[]
+  // table, if set, is shown as a table in the 'markdown' view
+  table?: ocr.Table
+  onClose: () => void
+}
+
+// structuredViews lists the renderings of a structured result that can be shown and copied
+export function structuredViews(result: StructuredOCRResult): ResultView[] {
+  switch (result.mode) {
+    case 'table':
+      return [
+        { id: 'markdown', label: 'Markdown', text: result.markdown || '' },
+        { id: 'csv', label: 'CSV', text: result.csv || '' },
+      ]
+    case 'code':
+      return [{ id: 'code', label: result.code?.language || 'Code', text: result.code?.code || '' }]
+    case 'keyvalue':
+      return [{ id: 'json', label: 'JSON', text: result.json || '' }]
+    default:
+      return [{ id: 'text', label: 'Text', text: result.text }]
+  }
+}
+
+// translationViews shows the translation first, then the original text
+export function translationViews(result: OCRResult): ResultView[] {
+  return [
+    { id: 'translation', label: languageLabel(result.language), text: result.translation },
+    { id: 'original', label: 'Original', text: result.text },
+  ]
+}
+
+function OCRResultPanel({ views, table, onClose }: OCRResultPanelProps) {
+  const [viewId, setViewId] = useState(views[0].id)
+  const [copied, setCopied] = useState(false)
+
+  useEffect(() => {
+    setViewId(views[0].id)
+  }, [views])
+
+  const view = views.find(v => v.id === viewId) || views[0]
+
+  const handleCopy = async () => {
+    try {
+      await navigator.clipboard.writeText(view.text)
+      setCopied(true)
+      setTimeout(() => setCopied(false), 2000)
+    } catch (error) {
+      console.error('Failed to copy OCR result:', error)
+    }
+  }
+
+  return (
+    <div className="card mb-8">
+      <div className="flex items-center justify-between mb-3">
+        <div className="flex gap-2">
+          {views.map(v => (
+            <button
+              key={v.id}
+              onClick={() => setViewId(v.id)}
+              className={`px-3 py-1 text-sm rounded-md ${
+                v.id === view.id
+                  ? 'bg-primary-600 text-white'
+                  : 'text-gray-600 dark:text-gray-400 hover:bg-gray-100 dark:hover:bg-gray-700'
+              }`}
+            >
+              {v.label}
+            </button>
+          ))}
+        </div>
+        <div className="flex gap-2">
+          <button onClick={handleCopy} className="btn-secondary flex items-center gap-2" title="Copy to clipboard">
+            {copied ? <Check className="w-4 h-4" /> : <Copy className="w-4 h-4" />}
+            {copied ? 'Copied' : 'Copy'}
+          </button>
+          <button onClick={onClose} className="btn-secondary" title="Close">
+            <X className="w-4 h-4" />
+          </button>
+        </div>
+      </div>
+
+      {table && view.id === 'markdown' ? (
+        <div className="overflow-x-auto">
+          <table className="w-full text-sm">
+            <thead>
+              <tr className="text-left text-gray-500 dark:text-gray-400">
+                {table.headers.map((header, i) => (
+                  <th key={i} className="py-1 pr-4 font-medium">{header}</th>
+                ))}
+              </tr>
+            </thead>
+            <tbody className="text-gray-900 dark:text-gray-100">
+              {table.rows.map((row, i) => (
+                <tr key={i} className="border-t border-gray-100 dark:border-gray-700">
+                  {row.map((cell, j) => (
+                    <td key={j} className="py-1 pr-4 whitespace-pre-wrap">{cell}</td>
+                  ))}
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </div>
+      ) : (
+        <pre className="text-sm font-mono whitespace-pre-wrap overflow-x-auto p-3 rounded-lg bg-gray-50 dark:bg-gray-900 text-gray-900 dark:text-gray-100">
+          {view.text}
+        </pre>
+      )}
+    </div>
+  )
+}
+
+export default OCRResultPanel
@@ -0,0 +1,99 @@
+import { ReactNode, useState } from 'react'
+import { SemanticSearch } from '@wailsjs/go/main/App'
+import { SearchResult } from '../types'
+import { AlertCircle, CheckSquare, Copy, FileText, List, Search as SearchIcon } from 'lucide-react'
+
+const sourceLabels: Record<string, { label: string, icon: ReactNode }> = {
+  todo: { label: 'Todo', icon: <CheckSquare className="w-4 h-4" /> },
+  ocr: { label: 'OCR', icon: <FileText className="w-4 h-4" /> },
+  workflowy: { label: 'Workflowy', icon: <List className="w-4 h-4" /> },
+}
+
+function Search() {
+  const [query, setQuery] = useState('')
+  const [results, setResults] = useState<SearchResult[] | null>(null)
+  const [searching, setSearching] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  const handleSearch = async (e: React.FormEvent) => {
+    e.preventDefault()
+    if (!query.trim()) return
+
+    try {
+      setSearching(true)
+      setError(null)
+      // 0 uses the configured number of results
+      setResults((await SemanticSearch(query.trim(), 0)) || [])
+    } catch (error) {
+      console.error('Failed to search:', error)
+      setError(String(error))
+    } finally {
+      setSearching(false)
+    }
+  }
+
+  return (
+    <div className="px-4 py-6 sm:px-0">
+      <div className="card mb-8">
+        <form onSubmit={handleSearch} className="flex gap-2">
+          <input
+            type="text"
+            value={query}
+            onChange={(e) => setQuery(e.target.value)}
+            placeholder="Search todos, OCR history and Workflowy by meaning..."
+            className="input-field flex-1"
+          />
+          <button type="submit" disabled={searching || !query.trim()} className="btn-primary flex items-center gap-2">
+            <SearchIcon className="w-4 h-4" />
+            {searching ? 'Searching...' : 'Search'}
+          </button>
+        </form>
+        <p className="text-sm form-description mt-2">
+          New and changed items are indexed before each search, so the first search can take a while
+        </p>
+      </div>
+
+      {error && (
+        <div className="mb-4 p-4 message-error rounded-lg flex items-center gap-2">
+          <AlertCircle className="w-5 h-5" />
+          {error}
+        </div>
+      )}
+
+      {results !== null && (
+        <div className="card">
+          {results.length === 0 ? (
+            <p className="text-center text-gray-500 dark:text-gray-400 py-8">No matches</p>
+          ) : (
+            <div className="space-y-3">
+              {results.map(result => {
+                const source = sourceLabels[result.source] || { label: result.source, icon: <FileText className="w-4 h-4" /> }
+                return (
+                  <div key={`${result.source}/${result.sourceId}`} className="flex items-start gap-3 p-3 rounded-lg bg-gray-50 dark:bg-gray-800">
+                    <span className="flex items-center gap-1 text-xs font-medium text-primary-700 dark:text-primary-300 w-24 flex-shrink-0">
+                      {source.icon}
+                      {source.label}
+                    </span>
+                    <p className="flex-1 text-sm text-gray-900 dark:text-gray-100 whitespace-pre-wrap line-clamp-4">{result.text}</p>
+                    <span className="text-xs text-gray-500 dark:text-gray-400" title="Similarity">
+                      {Math.round(result.score * 100)}%
+                    </span>
+                    <button
+                      onClick={() => navigator.clipboard.writeText(result.text)}
+                      className="text-gray-400 hover:text-primary-600 dark:hover:text-primary-400"
+                      title="Copy text"
+                    >
+                      <Copy className="w-4 h-4" />
+                    </button>
+                  </div>
+                )
+              })}
+            </div>
+          )}
+        </div>
+      )}
+    </div>
+  )
+}
+
+export default Search
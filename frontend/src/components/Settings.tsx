@@ -3,8 +3,10 @@ import SettingsSidebar from './settings/SettingsSidebar'
 import AppearanceSettings from './settings/AppearanceSettings'
 import TodoSettings from './settings/TodoSettings'
 import OCRSettings from './settings/OCRSettings'
+import ChatSettings from './settings/ChatSettings'
 import GeneralSettings from './settings/GeneralSettings'
 import LanguageSettings from './settings/LanguageSettings'
+import UsageSettings from './settings/UsageSettings'
 
 function Settings() {
   return (
@@ -20,6 +22,8 @@ function Settings() {
             <Route path="/appearance" element={<AppearanceSettings />} />
             <Route path="/todos" element={<TodoSettings />} />
             <Route path="/ocr" element={<OCRSettings />} />
+            <Route path="/chat" element={<ChatSettings />} />
+            <Route path="/usage" element={<UsageSettings />} />
             <Route path="/general" element={<GeneralSettings />} />
             <Route path="/language" element={<LanguageSettings />} />
           </Routes>
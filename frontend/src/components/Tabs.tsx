@@ -1,6 +1,6 @@
 import { ReactNode, useState } from 'react'
 import { Link, useLocation } from 'react-router-dom'
-import { CheckSquare, Settings, Menu } from 'lucide-react'
+import { CheckSquare, ClipboardList, MessageSquare, Search, Settings, Menu } from 'lucide-react'
 import ThemeToggle from './ThemeToggle'
 
 export const navigationTabs: TabItem[] = [
@@ -10,6 +10,24 @@ export const navigationTabs: TabItem[] = [
     path: '/',
     icon: <CheckSquare className="w-4 h-4" />
   },
+  {
+    id: 'chat',
+    label: 'Chat',
+    path: '/chat',
+    icon: <MessageSquare className="w-4 h-4" />
+  },
+  {
+    id: 'search',
+    label: 'Search',
+    path: '/search',
+    icon: <Search className="w-4 h-4" />
+  },
+  {
+    id: 'clipboard',
+    label: 'Clipboard',
+    path: '/clipboard',
+    icon: <ClipboardList className="w-4 h-4" />
+  },
   {
     id: 'settings',
     label: 'Settings',
@@ -0,0 +1,93 @@
+import { useState, useEffect } from 'react'
+import { Task } from '../types'
+import { Check, X } from 'lucide-react'
+
+interface TaskReviewProps {
+  tasks: Task[]
+  // onAdd receives the accepted tasks, with any edits
+  onAdd: (accepted: Task[]) => Promise<void>
+  onClose: () => void
+}
+
+interface ReviewItem {
+  task: Task
+  accepted: boolean
+}
+
+function TaskReview({ tasks, onAdd, onClose }: TaskReviewProps) {
+  const [items, setItems] = useState<ReviewItem[]>([])
+  const [adding, setAdding] = useState(false)
+
+  useEffect(() => {
+    setItems(tasks.map(task => ({ task, accepted: true })))
+  }, [tasks])
+
+  const updateItem = (index: number, change: Partial<ReviewItem>) => {
+    setItems(prev => prev.map((item, i) => i === index ? { ...item, ...change } : item))
+  }
+
+  const accepted = items.filter(item => item.accepted && item.task.text.trim())
+
+  const handleAdd = async () => {
+    try {
+      setAdding(true)
+      await onAdd(accepted.map(item => ({ ...item.task, text: item.task.text.trim() })))
+    } finally {
+      setAdding(false)
+    }
+  }
+
+  return (
+    <div className="card mb-8">
+      <div className="flex items-center justify-between mb-4">
+        <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100">Proposed Tasks</h3>
+        <button onClick={onClose} className="btn-secondary" title="Close">
+          <X className="w-4 h-4" />
+        </button>
+      </div>
+
+      {items.length === 0 ? (
+        <p className="text-sm form-description">No action items found</p>
+      ) : (
+        <div className="space-y-2 mb-4">
+          {items.map((item, index) => (
+            <div key={index} className="flex items-center gap-3">
+              <input
+                type="checkbox"
+                checked={item.accepted}
+                onChange={(e) => updateItem(index, { accepted: e.target.checked })}
+                className="w-4 h-4"
+              />
+              <input
+                type="text"
+                value={item.task.text}
+                onChange={(e) => updateItem(index, { task: { ...item.task, text: e.target.value } })}
+                className={`input-field flex-1 ${item.accepted ? '' : 'opacity-50'}`}
+              />
+              <span className="text-xs text-gray-500 dark:text-gray-400 w-40 truncate">
+                {[
+                  item.task.assignee && `@${item.task.assignee}`,
+                  item.task.due && `due ${item.task.due}`,
+                  item.task.priority === 'high' && 'high priority',
+                ].filter(Boolean).join(' · ')}
+              </span>
+            </div>
+          ))}
+        </div>
+      )}
+
+      {items.length > 0 && (
+        <button
+          onClick={handleAdd}
+          disabled={adding || accepted.length === 0}
+          className="btn-primary flex items-center gap-2"
+        >
+          <Check className="w-4 h-4" />
+          {adding ? 'Adding...' : `Add ${accepted.length} ${accepted.length === 1 ? 'Todo' : 'Todos'}`}
+        </button>
+      )}
+    </div>
+  )
+}
+
+export default TaskReview
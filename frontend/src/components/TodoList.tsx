@@ -1,7 +1,14 @@
 import { useState, useEffect } from 'react'
-import { GetTodos, AddTodo, UpdateTodo, DeleteTodo, OCRFromClipboard } from '@wailsjs/go/main/App'
-import { Todo } from '../types'
-import { Check, Plus, Clipboard, Edit2, Trash2, X, AlertCircle } from 'lucide-react'
+import { GetTodos, AddTodo, UpdateTodo, DeleteTodo, OCRFromClipboard, OCRAndTranslate, OCRStructuredFromClipboard, CancelOCR, ExtractTasks, AddTasks } from '@wailsjs/go/main/App'
+import { ClipboardGetText, EventsOn } from '@wailsjs/runtime/runtime'
+import { BudgetWarning, OCRMode, SensitiveReport, Task, Todo } from '../types'
+import { ocr } from '@wailsjs/go/models'
+import { languages } from '../languages'
+import { sensitiveMessage } from '../sensitive'
+import OCRHistory from './OCRHistory'
+import OCRResultPanel, { ResultView, structuredViews, translationViews } from './OCRResultPanel'
+import TaskReview from './TaskReview'
+import { Check, Plus, Clipboard, Edit2, Trash2, X, AlertCircle, RefreshCw, History, ListChecks } from 'lucide-react'
 
 function TodoList() {
   const [todos, setTodos] = useState<Todo[]>([])
@@ -10,6 +17,14 @@ function TodoList() {
   const [editingText, setEditingText] = useState('')
   const [loading, setLoading] = useState(true)
   const [ocrLoading, setOcrLoading] = useState(false)
+  const [ocrMode, setOcrMode] = useState<OCRMode>('text')
+  const [ocrViews, setOcrViews] = useState<ResultView[] | null>(null)
+  const [ocrTable, setOcrTable] = useState<ocr.Table | undefined>(undefined)
+  // An empty target language means the language configured in Settings
+  const [targetLanguage, setTargetLanguage] = useState('')
+  const [showHistory, setShowHistory] = useState(false)
+  const [proposedTasks, setProposedTasks] = useState<Task[] | null>(null)
+  const [extracting, setExtracting] = useState(false)
   const [error, setError] = useState<string | null>(null)
 
   // Load todos on component mount
@@ -17,6 +32,29 @@ function TodoList() {
     loadTodos()
   }, [])
 
+  // Show streamed OCR text as it arrives
+  useEffect(() => {
+    return EventsOn('ocr:progress', (progress: { delta: string; text: string }) => {
+      setNewTodoText(progress.text)
+    })
+  }, [])
+
+  // Warn when an AI budget is used up
+  useEffect(() => {
+    return EventsOn('usage:budget', (warning: BudgetWarning) => {
+      setError(`The ${warning.period} AI budget is used up (${warning.spent.toFixed(2)} of ${warning.budget.toFixed(2)})`)
+    })
+  }, [])
+
+  // Warn when sensitive data is found in OCR text or in text for task extraction
+  useEffect(() => {
+    return EventsOn('sensitive:detected', (report: SensitiveReport) => {
+      if (report.source === 'ocr' || report.source === 'tasks') {
+        setError(sensitiveMessage(report))
+      }
+    })
+  }, [])
+
   const loadTodos = async () => {
     try {
       setLoading(true)
@@ -89,13 +127,23 @@ function TodoList() {
     }
   }
 
-  const handleOCRFromClipboard = async () => {
+  const handleOCRFromClipboard = async (forceRefresh = false) => {
     try {
       setOcrLoading(true)
       setError(null)
       
-      const extractedText = await OCRFromClipboard()
-      setNewTodoText(extractedText)
+      if (ocrMode === 'text') {
+        const extractedText = await OCRFromClipboard(forceRefresh)
+        setNewTodoText(extractedText)
+      } else if (ocrMode === 'translate') {
+        const result = await OCRAndTranslate(targetLanguage, forceRefresh)
+        setOcrTable(undefined)
+        setOcrViews(translationViews(result))
+      } else {
+        const result = await OCRStructuredFromClipboard(ocrMode, forceRefresh)
+        setOcrTable(result.table)
+        setOcrViews(structuredViews(result))
+      }
     } catch (error) {
       console.error('OCR failed:', error)
       setError(error instanceof Error ? error.message : 'Failed to extract text from clipboard')
@@ -104,6 +152,37 @@ function TodoList() {
     }
   }
 
+  // handleExtractTasks proposes the action items in text; without text it
+  // uses the todo field, such as OCR'd notes, or else the clipboard text
+  const handleExtractTasks = async (text?: string) => {
+    try {
+      setExtracting(true)
+      setError(null)
+      const source = text || newTodoText.trim() || await ClipboardGetText()
+      if (!source.trim()) {
+        setError('There is no text to extract tasks from')
+        return
+      }
+      setProposedTasks((await ExtractTasks(source)) || [])
+    } catch (error) {
+      console.error('Task extraction failed:', error)
+      setError(error instanceof Error ? error.message : String(error))
+    } finally {
+      setExtracting(false)
+    }
+  }
+
+  const handleAddTasks = async (accepted: Task[]) => {
+    try {
+      const added = await AddTasks(accepted)
+      setTodos(prev => [...prev, ...(added || [])])
+      setProposedTasks(null)
+    } catch (error) {
+      console.error('Failed to add tasks:', error)
+      setError('Failed to add the accepted tasks')
+    }
+  }
+
   const completedCount = todos.filter(todo => todo.completed).length
   const totalCount = todos.length
 
@@ -147,9 +226,36 @@ function TodoList() {
               className="input-field flex-1"
               maxLength={200}
             />
+            <select
+              value={ocrMode}
+              onChange={(e) => setOcrMode(e.target.value as OCRMode)}
+              disabled={ocrLoading}
+              className="input-field w-auto"
+              title="What to read from the clipboard image"
+            >
+              <option value="text">Text</option>
+              <option value="translate">Translate</option>
+              <option value="table">Table</option>
+              <option value="code">Code</option>
+              <option value="keyvalue">Fields</option>
+            </select>
+            {ocrMode === 'translate' && (
+              <select
+                value={targetLanguage}
+                onChange={(e) => setTargetLanguage(e.target.value)}
+                disabled={ocrLoading}
+                className="input-field w-auto"
+                title="Language to translate into"
+              >
+                <option value="">Default language</option>
+                {languages.map(lang => (
+                  <option key={lang.code} value={lang.code}>{lang.name}</option>
+                ))}
+              </select>
+            )}
             <button
               type="button"
-              onClick={handleOCRFromClipboard}
+              onClick={() => handleOCRFromClipboard()}
               disabled={ocrLoading}
               className="btn-secondary flex items-center gap-2"
               title="Read text from clipboard image"
@@ -161,6 +267,27 @@ function TodoList() {
               )}
               {ocrLoading ? 'Reading...' : 'OCR'}
             </button>
+            {!ocrLoading && (
+              <button
+                type="button"
+                onClick={() => handleOCRFromClipboard(true)}
+                className="btn-secondary flex items-center"
+                title="Read text from clipboard image again, ignoring cached results"
+              >
+                <RefreshCw className="w-4 h-4" />
+              </button>
+            )}
+            {ocrLoading && (
+              <button
+                type="button"
+                onClick={() => CancelOCR()}
+                className="btn-secondary flex items-center gap-2"
+                title="Cancel reading the clipboard image"
+              >
+                <X className="w-4 h-4" />
+                Cancel
+              </button>
+            )}
             <button
               type="submit"
               className="btn-primary flex items-center gap-2"
@@ -172,6 +299,37 @@ function TodoList() {
           </div>
         </form>
 
+        <div className="flex justify-end gap-4 -mt-6 mb-4">
+          <button
+            type="button"
+            onClick={() => handleExtractTasks()}
+            disabled={extracting}
+            className="text-sm text-gray-500 dark:text-gray-400 hover:text-primary-600 dark:hover:text-primary-400 flex items-center gap-1"
+            title="Find action items in the text above, or in the clipboard text if it is empty"
+          >
+            <ListChecks className="w-4 h-4" />
+            {extracting ? 'Extracting tasks...' : 'Extract tasks'}
+          </button>
+          <button
+            type="button"
+            onClick={() => setShowHistory(!showHistory)}
+            className="text-sm text-gray-500 dark:text-gray-400 hover:text-primary-600 dark:hover:text-primary-400 flex items-center gap-1"
+          >
+            <History className="w-4 h-4" />
+            {showHistory ? 'Hide OCR history' : 'OCR history'}
+          </button>
+        </div>
+
+        {showHistory && <OCRHistory onUse={setNewTodoText} onExtractTasks={handleExtractTasks} />}
+
+        {proposedTasks && (
+          <TaskReview tasks={proposedTasks} onAdd={handleAddTasks} onClose={() => setProposedTasks(null)} />
+        )}
+
+        {ocrViews && (
+          <OCRResultPanel views={ocrViews} table={ocrTable} onClose={() => setOcrViews(null)} />
+        )}
+
         {/* Todo List */}
         <div className="space-y-2">
           {todos.length === 0 ? (
@@ -0,0 +1,304 @@
+import { useState, useEffect } from 'react'
+import { GetConfig, SaveConfig } from '@wailsjs/go/main/App'
+import { AppConfig, ChatConfig } from '../../types'
+
+const defaultChat: ChatConfig = {
+  Model: '',
+  SystemPrompt: 'You are a helpful desktop assistant. Answer concisely.',
+  Temperature: 0.7,
+  MaxTokens: 0,
+  Stream: true,
+  TimeoutSeconds: 120,
+  HistoryMessages: 20,
+  Tools: true,
+  MaxToolRounds: 8,
+}
+
+function ChatSettings() {
+  const [config, setConfig] = useState<AppConfig | null>(null)
+  const [loading, setLoading] = useState(true)
+  const [saving, setSaving] = useState(false)
+  const [message, setMessage] = useState<{ type: 'success' | 'error', text: string } | null>(null)
+
+  useEffect(() => {
+    loadConfig()
+  }, [])
+
+  const loadConfig = async () => {
+    try {
+      setLoading(true)
+      const configData = await GetConfig()
+      setConfig(configData)
+    } catch (error) {
+      console.error('Failed to load config:', error)
+      setMessage({ type: 'error', text: 'Failed to load configuration' })
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  const handleChatChange = (key: keyof ChatConfig, value: any) => {
+    if (!config) return
+    setConfig({ ...config, Chat: { ...config.Chat, [key]: value } } as AppConfig)
+  }
+
+  const handleSaveConfig = async () => {
+    if (!config) return
+
+    try {
+      setSaving(true)
+      await SaveConfig(config)
+      setMessage({ type: 'success', text: 'Chat settings saved successfully!' })
+      setTimeout(() => setMessage(null), 3000)
+    } catch (error) {
+      console.error('Failed to save config:', error)
+      setMessage({ type: 'error', text: 'Failed to save chat settings' })
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const resetToDefaults = () => {
+    if (!config) return
+    setConfig({ ...config, Chat: { ...defaultChat } } as AppConfig)
+  }
+
+  if (loading) {
+    return (
+      <div className="flex justify-center items-center h-64">
+        <div className="animate-spin rounded-full h-8 w-8 border-b-2 border-primary-600"></div>
+      </div>
+    )
+  }
+
+  if (!config) {
+    return (
+      <div className="text-center py-12">
+        <p className="text-gray-500 dark:text-gray-400">Failed to load configuration</p>
+      </div>
+    )
+  }
+
+  return (
+    <div className="max-w-2xl">
+      <div className="mb-8">
+        <h1 className="text-2xl font-bold text-gray-900 dark:text-gray-100 mb-2">
+          Chat Settings
+        </h1>
+        <p className="text-gray-600 dark:text-gray-400">
+          Configure the assistant chat on your OpenAI-compatible endpoint
+        </p>
+      </div>
+
+      {message && (
+        <div className={`mb-6 p-4 rounded-lg flex items-center gap-2 ${
+          message.type === 'success' ? 'message-success' : 'message-error'
+        }`}>
+          {message.type === 'success' ? (
+            <div className="w-4 h-4 rounded-full bg-green-500"></div>
+          ) : (
+            <div className="w-4 h-4 rounded-full bg-red-500"></div>
+          )}
+          {message.text}
+        </div>
+      )}
+
+      <div className="space-y-6">
+        {/* Model and Prompt */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4">
+            Model and Prompt
+          </h3>
+          <div className="space-y-4">
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                Model
+              </label>
+              <input
+                type="text"
+                value={config.Chat.Model}
+                onChange={(e) => handleChatChange('Model', e.target.value)}
+                className="input-field"
+                placeholder="Same as the OCR model"
+              />
+              <p className="text-sm form-description mt-1">
+                Use a vision-capable model to ask about clipboard images. Leave empty to use the OCR model.
+              </p>
+            </div>
+
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                System Prompt
+              </label>
+              <textarea
+                value={config.Chat.SystemPrompt}
+                onChange={(e) => handleChatChange('SystemPrompt', e.target.value)}
+                className="input-field"
+                rows={3}
+              />
+            </div>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Temperature
+                </label>
+                <input
+                  type="number"
+                  value={config.Chat.Temperature}
+                  onChange={(e) => handleChatChange('Temperature', parseFloat(e.target.value) || 0)}
+                  className="input-field"
+                  min="0"
+                  max="2"
+                  step="0.1"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Max Tokens
+                </label>
+                <input
+                  type="number"
+                  value={config.Chat.MaxTokens}
+                  onChange={(e) => handleChatChange('MaxTokens', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                  min="0"
+                />
+                <p className="text-sm form-description mt-1">
+                  0 uses the endpoint default
+                </p>
+              </div>
+            </div>
+          </div>
+        </div>
+
+        {/* Conversation */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4">
+            Conversation
+          </h3>
+          <div className="space-y-4">
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                History Messages
+              </label>
+              <input
+                type="number"
+                value={config.Chat.HistoryMessages}
+                onChange={(e) => handleChatChange('HistoryMessages', parseInt(e.target.value) || 0)}
+                className="input-field"
+                min="0"
+              />
+              <p className="text-sm form-description mt-1">
+                Earlier messages sent with each new one as context; 0 sends the whole conversation
+              </p>
+            </div>
+
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                Timeout (seconds)
+              </label>
+              <input
+                type="number"
+                value={config.Chat.TimeoutSeconds}
+                onChange={(e) => handleChatChange('TimeoutSeconds', parseInt(e.target.value) || 0)}
+                className="input-field"
+                min="0"
+              />
+            </div>
+
+            <div className="flex items-center justify-between">
+              <div>
+                <label className="text-sm font-medium form-label">
+                  Stream replies
+                </label>
+                <p className="text-sm form-description">
+                  Show replies as they are written
+                </p>
+              </div>
+              <label className="relative inline-flex items-center cursor-pointer">
+                <input
+                  type="checkbox"
+                  checked={config.Chat.Stream}
+                  onChange={(e) => handleChatChange('Stream', e.target.checked)}
+                  className="sr-only peer"
+                />
+                <div className="w-11 h-6 toggle-bg peer-focus:outline-none peer-focus:ring-4 peer-focus:ring-primary-300 rounded-full peer peer-checked:after:translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:left-[2px] after:bg-white after:border-gray-300 dark:after:border-gray-600 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:toggle-checked"></div>
+              </label>
+            </div>
+          </div>
+        </div>
+
+        {/* Tools */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4">
+            Tools
+          </h3>
+          <div className="space-y-4">
+            <div className="flex items-center justify-between">
+              <div>
+                <label className="text-sm font-medium form-label">
+                  Enable tools
+                </label>
+                <p className="text-sm form-description">
+                  Let the assistant manage your todos and Workflowy nodes. Edits and deletions ask for confirmation; replies are not streamed.
+                </p>
+              </div>
+              <label className="relative inline-flex items-center cursor-pointer">
+                <input
+                  type="checkbox"
+                  checked={config.Chat.Tools}
+                  onChange={(e) => handleChatChange('Tools', e.target.checked)}
+                  className="sr-only peer"
+                />
+                <div className="w-11 h-6 toggle-bg peer-focus:outline-none peer-focus:ring-4 peer-focus:ring-primary-300 rounded-full peer peer-checked:after:translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:left-[2px] after:bg-white after:border-gray-300 dark:after:border-gray-600 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:toggle-checked"></div>
+              </label>
+            </div>
+
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                Max Tool Rounds
+              </label>
+              <input
+                type="number"
+                value={config.Chat.MaxToolRounds}
+                onChange={(e) => handleChatChange('MaxToolRounds', parseInt(e.target.value) || 0)}
+                className="input-field"
+                min="0"
+                disabled={!config.Chat.Tools}
+              />
+              <p className="text-sm form-description mt-1">
+                Rounds of tool calls allowed for one message
+              </p>
+            </div>
+          </div>
+        </div>
+
+        {/* Action Buttons */}
+        <div className="flex gap-4">
+          <button
+            onClick={handleSaveConfig}
+            disabled={saving}
+            className="btn-primary flex items-center gap-2"
+          >
+            {saving ? (
+              <div className="w-4 h-4 border-2 border-gray-300 border-t-gray-600 rounded-full animate-spin" />
+            ) : (
+              <div className="w-4 h-4 rounded-full bg-white"></div>
+            )}
+            {saving ? 'Saving...' : 'Save Chat Settings'}
+          </button>
+          <button
+            onClick={resetToDefaults}
+            className="btn-secondary flex items-center gap-2"
+          >
+            <div className="w-4 h-4 rounded-full bg-gray-600"></div>
+            Reset to Defaults
+          </button>
+        </div>
+      </div>
+    </div>
+  )
+}
+
+export default ChatSettings
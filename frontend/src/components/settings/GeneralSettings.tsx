@@ -1,13 +1,19 @@
 import { useState, useEffect } from 'react'
-import { GetConfig, SaveConfig } from '@wailsjs/go/main/App'
-import { AppConfig } from '../../types'
-import { Settings as SettingsIcon, Bell, Save } from 'lucide-react'
+import { GetConfig, GetSecrets, SaveConfig } from '@wailsjs/go/main/App'
+import { AppConfig, ClipboardHistoryConfig, HTTPConfig, SecretStatus, SensitiveConfig } from '../../types'
+import { Settings as SettingsIcon, Bell, Save, Key, Globe, ClipboardList, Wand2, Plus, Trash2, ShieldAlert } from 'lucide-react'
+import SecretField from './SecretField'
+
+// Kinds of sensitive data that can be looked for; none checked means all
+const sensitiveKinds = ['password', 'api-key', 'private-key', 'card-number', 'email', 'secret']
 
 function GeneralSettings() {
   const [config, setConfig] = useState<AppConfig | null>(null)
+  const [secrets, setSecrets] = useState<SecretStatus[]>([])
   const [loading, setLoading] = useState(true)
   const [saving, setSaving] = useState(false)
   const [message, setMessage] = useState<{ type: 'success' | 'error', text: string } | null>(null)
+  const [newChain, setNewChain] = useState('')
 
   useEffect(() => {
     loadConfig()
@@ -18,6 +24,7 @@ function GeneralSettings() {
       setLoading(true)
       const configData = await GetConfig()
       setConfig(configData)
+      setSecrets(await GetSecrets())
     } catch (error) {
       console.error('Failed to load config:', error)
       setMessage({ type: 'error', text: 'Failed to load configuration' })
@@ -26,11 +33,63 @@ function GeneralSettings() {
     }
   }
 
+  const handleSecretSaved = async () => {
+    setSecrets(await GetSecrets())
+    setMessage({ type: 'success', text: 'API key updated successfully!' })
+    setTimeout(() => setMessage(null), 3000)
+  }
+
   const handleConfigChange = (key: keyof AppConfig, value: any) => {
     if (!config) return
     setConfig({ ...config, [key]: value })
   }
 
+  const handleHTTPChange = (key: keyof HTTPConfig, value: any) => {
+    if (!config) return
+    setConfig({ ...config, HTTP: { ...config.HTTP, [key]: value } } as AppConfig)
+  }
+
+  const handleHistoryChange = (key: keyof ClipboardHistoryConfig, value: any) => {
+    if (!config) return
+    setConfig({ ...config, ClipboardHistory: { ...config.ClipboardHistory, [key]: value } } as AppConfig)
+  }
+
+  const handleSensitiveChange = (key: keyof SensitiveConfig, value: any) => {
+    if (!config) return
+    setConfig({ ...config, Sensitive: { ...config.Sensitive, [key]: value } } as AppConfig)
+  }
+
+  const toggleSensitiveKind = (kind: string, checked: boolean) => {
+    if (!config) return
+    const kinds = (config.Sensitive.Kinds || []).filter(k => k !== kind)
+    handleSensitiveChange('Kinds', checked ? [...kinds, kind] : kinds)
+  }
+
+  const setChains = (chains: { [name: string]: string[] }) => {
+    if (!config) return
+    setConfig({ ...config, Transform: { ...config.Transform, Chains: chains } } as AppConfig)
+  }
+
+  // Steps are edited as a comma-separated list of transformer names
+  const handleChainChange = (name: string, steps: string) => {
+    if (!config) return
+    setChains({ ...(config.Transform.Chains || {}), [name]: steps.split(',').map(step => step.trim()).filter(Boolean) })
+  }
+
+  const addChain = () => {
+    const name = newChain.trim()
+    if (!config || !name) return
+    setChains({ ...(config.Transform.Chains || {}), [name]: [] })
+    setNewChain('')
+  }
+
+  const removeChain = (name: string) => {
+    if (!config) return
+    const chains = { ...(config.Transform.Chains || {}) }
+    delete chains[name]
+    setChains(chains)
+  }
+
   const handleSaveConfig = async () => {
     if (!config) return
 
@@ -146,6 +205,306 @@ function GeneralSettings() {
           </div>
         </div>
 
+        {/* Integrations */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <Key className="w-5 h-5" />
+            Integrations
+          </h3>
+          <SecretField
+            label="Workflowy API Key"
+            name={config.WorkflowyAPIKeySecret}
+            masked={secrets.find(secret => secret.name === config.WorkflowyAPIKeySecret)?.masked ?? ''}
+            onSaved={handleSecretSaved}
+            onError={(text) => setMessage({ type: 'error', text })}
+          />
+        </div>
+
+        {/* Network */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <Globe className="w-5 h-5" />
+            Network
+          </h3>
+          <div className="space-y-4">
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                Proxy URL
+              </label>
+              <input
+                type="text"
+                value={config.HTTP.ProxyURL}
+                onChange={(e) => handleHTTPChange('ProxyURL', e.target.value)}
+                className="input-field"
+                placeholder="http://proxy.example.com:8080"
+              />
+              <p className="text-sm form-description mt-1">
+                Leave empty to use the HTTP_PROXY and HTTPS_PROXY environment variables
+              </p>
+            </div>
+
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                CA Certificate File
+              </label>
+              <input
+                type="text"
+                value={config.HTTP.CAFile}
+                onChange={(e) => handleHTTPChange('CAFile', e.target.value)}
+                className="input-field"
+                placeholder="C:\certs\corporate-root.pem"
+              />
+              <p className="text-sm form-description mt-1">
+                PEM file of certificates trusted in addition to the system ones
+              </p>
+            </div>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Timeout (seconds)
+                </label>
+                <input
+                  type="number"
+                  value={config.HTTP.TimeoutSeconds}
+                  onChange={(e) => handleHTTPChange('TimeoutSeconds', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                  min="0"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Connect Timeout (seconds)
+                </label>
+                <input
+                  type="number"
+                  value={config.HTTP.ConnectTimeoutSeconds}
+                  onChange={(e) => handleHTTPChange('ConnectTimeoutSeconds', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                  min="0"
+                />
+              </div>
+            </div>
+
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                User-Agent
+              </label>
+              <input
+                type="text"
+                value={config.HTTP.UserAgent}
+                onChange={(e) => handleHTTPChange('UserAgent', e.target.value)}
+                className="input-field"
+              />
+            </div>
+
+            <label className="flex items-center gap-2 text-sm form-label">
+              <input
+                type="checkbox"
+                checked={config.HTTP.LogRequests}
+                onChange={(e) => handleHTTPChange('LogRequests', e.target.checked)}
+                className="w-4 h-4"
+              />
+              Log requests (credentials are redacted)
+            </label>
+
+            <label className="flex items-center gap-2 text-sm form-label">
+              <input
+                type="checkbox"
+                checked={config.HTTP.InsecureSkipVerify}
+                onChange={(e) => handleHTTPChange('InsecureSkipVerify', e.target.checked)}
+                className="w-4 h-4"
+              />
+              Skip TLS certificate verification (development only)
+            </label>
+          </div>
+        </div>
+
+        {/* Clipboard History */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <ClipboardList className="w-5 h-5" />
+            Clipboard History
+          </h3>
+          <div className="space-y-4">
+            <label className="flex items-center gap-2 text-sm form-label">
+              <input
+                type="checkbox"
+                checked={config.ClipboardHistory.Enabled}
+                onChange={(e) => handleHistoryChange('Enabled', e.target.checked)}
+                className="w-4 h-4"
+              />
+              Keep a history of copied text and images
+            </label>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Max Entries
+                </label>
+                <input
+                  type="number"
+                  value={config.ClipboardHistory.MaxEntries}
+                  onChange={(e) => handleHistoryChange('MaxEntries', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                  min="0"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Retention (days)
+                </label>
+                <input
+                  type="number"
+                  value={config.ClipboardHistory.RetentionDays}
+                  onChange={(e) => handleHistoryChange('RetentionDays', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                  min="0"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Max Item Size (KB)
+                </label>
+                <input
+                  type="number"
+                  value={config.ClipboardHistory.MaxItemKB}
+                  onChange={(e) => handleHistoryChange('MaxItemKB', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                  min="0"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Thumbnail Size (px)
+                </label>
+                <input
+                  type="number"
+                  value={config.ClipboardHistory.ThumbnailSize}
+                  onChange={(e) => handleHistoryChange('ThumbnailSize', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                  min="0"
+                />
+              </div>
+            </div>
+            <p className="text-sm form-description">
+              0 disables a limit. Pinned entries are never removed.
+            </p>
+          </div>
+        </div>
+
+        {/* Sensitive Data */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <ShieldAlert className="w-5 h-5" />
+            Sensitive Data
+          </h3>
+          <div className="space-y-4">
+            <label className="flex items-center gap-2 text-sm form-label">
+              <input
+                type="checkbox"
+                checked={config.Sensitive.Enabled}
+                onChange={(e) => handleSensitiveChange('Enabled', e.target.checked)}
+                className="w-4 h-4"
+              />
+              Look for sensitive data in OCR results and copied text
+            </label>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  When Found
+                </label>
+                <select
+                  value={config.Sensitive.Action}
+                  onChange={(e) => handleSensitiveChange('Action', e.target.value)}
+                  className="input-field"
+                >
+                  <option value="warn">Warn</option>
+                  <option value="redact">Redact</option>
+                  <option value="block">Block</option>
+                </select>
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  History Expiry (minutes)
+                </label>
+                <input
+                  type="number"
+                  value={config.Sensitive.HistoryExpiryMinutes}
+                  onChange={(e) => handleSensitiveChange('HistoryExpiryMinutes', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                  min="0"
+                />
+              </div>
+            </div>
+
+            <div className="flex flex-wrap gap-4">
+              {sensitiveKinds.map(kind => (
+                <label key={kind} className="flex items-center gap-2 text-sm form-label">
+                  <input
+                    type="checkbox"
+                    checked={(config.Sensitive.Kinds || []).includes(kind)}
+                    onChange={(e) => toggleSensitiveKind(kind, e.target.checked)}
+                    className="w-4 h-4"
+                  />
+                  {kind}
+                </label>
+              ))}
+            </div>
+            <p className="text-sm form-description">
+              Redact replaces the data with a placeholder; block drops the OCR result or history entry.
+              Unpinned history entries with sensitive data expire after the given minutes; 0 keeps them like other entries.
+            </p>
+          </div>
+        </div>
+
+        {/* Transform Chains */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <Wand2 className="w-5 h-5" />
+            Transform Chains
+          </h3>
+          <div className="space-y-2">
+            {Object.entries(config.Transform.Chains || {}).map(([name, steps]) => (
+              <div key={name} className="flex items-center gap-2">
+                <span className="w-32 text-sm text-gray-900 dark:text-gray-100 truncate">{name}</span>
+                <input
+                  type="text"
+                  defaultValue={steps.join(', ')}
+                  onBlur={(e) => handleChainChange(name, e.target.value)}
+                  className="input-field flex-1"
+                  placeholder="trim, json-pretty"
+                />
+                <button
+                  onClick={() => removeChain(name)}
+                  className="p-2 text-gray-400 hover:text-red-600 dark:hover:text-red-400"
+                  title="Remove chain"
+                >
+                  <Trash2 className="w-4 h-4" />
+                </button>
+              </div>
+            ))}
+            <div className="flex gap-2">
+              <input
+                type="text"
+                value={newChain}
+                onChange={(e) => setNewChain(e.target.value)}
+                className="input-field flex-1"
+                placeholder="Chain name, e.g. tidy-json"
+              />
+              <button onClick={addChain} className="btn-secondary flex items-center gap-2">
+                <Plus className="w-4 h-4" />
+                Add
+              </button>
+            </div>
+          </div>
+          <p className="text-sm form-description mt-2">
+            Each chain runs the listed transformers, or other chains, in order on the clipboard text.
+            Run them from the Clipboard tab.
+          </p>
+        </div>
+
         {/* Application Information */}
         <div className="card">
           <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
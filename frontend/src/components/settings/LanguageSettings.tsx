@@ -1,21 +1,9 @@
 import { useState, useEffect } from 'react'
 import { GetConfig, SaveConfig } from '@wailsjs/go/main/App'
 import { AppConfig } from '../../types'
+import { languages } from '../../languages'
 import { Globe, Check } from 'lucide-react'
 
-const languages = [
-  { code: 'en', name: 'English', flag: '🇺🇸' },
-  { code: 'es', name: 'Español', flag: '🇪🇸' },
-  { code: 'fr', name: 'Français', flag: '🇫🇷' },
-  { code: 'de', name: 'Deutsch', flag: '🇩🇪' },
-  { code: 'zh', name: '中文', flag: '🇨🇳' },
-  { code: 'ja', name: '日本語', flag: '🇯🇵' },
-  { code: 'ko', name: '한국어', flag: '🇰🇷' },
-  { code: 'pt', name: 'Português', flag: '🇵🇹' },
-  { code: 'ru', name: 'Русский', flag: '🇷🇺' },
-  { code: 'it', name: 'Italiano', flag: '🇮🇹' }
-]
-
 function LanguageSettings() {
   const [config, setConfig] = useState<AppConfig | null>(null)
   const [loading, setLoading] = useState(true)
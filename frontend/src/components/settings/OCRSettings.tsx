@@ -1,10 +1,14 @@
 import { useState, useEffect } from 'react'
-import { GetConfig, SaveConfig } from '@wailsjs/go/main/App'
-import { AppConfig } from '../../types'
-import { Eye, Key, Link as LinkIcon } from 'lucide-react'
+import { ClearOCRCache, GetConfig, GetSecrets, ListOCRModels, SaveConfig } from '@wailsjs/go/main/App'
+import { AppConfig, OCRConfig, SecretStatus } from '../../types'
+import { Database, Eye, Image as ImageIcon, Key, Layers, Link as LinkIcon, SlidersHorizontal } from 'lucide-react'
+import SecretField from './SecretField'
 
 function OCRSettings() {
   const [config, setConfig] = useState<AppConfig | null>(null)
+  const [secrets, setSecrets] = useState<SecretStatus[]>([])
+  const [models, setModels] = useState<string[]>([])
+  const [loadingModels, setLoadingModels] = useState(false)
   const [loading, setLoading] = useState(true)
   const [saving, setSaving] = useState(false)
   const [message, setMessage] = useState<{ type: 'success' | 'error', text: string } | null>(null)
@@ -18,6 +22,7 @@ function OCRSettings() {
       setLoading(true)
       const configData = await GetConfig()
       setConfig(configData)
+      setSecrets(await GetSecrets())
     } catch (error) {
       console.error('Failed to load config:', error)
       setMessage({ type: 'error', text: 'Failed to load configuration' })
@@ -26,11 +31,70 @@ function OCRSettings() {
     }
   }
 
+  const apiKeySecret = secrets.find(secret => secret.name === config?.OpenAIAPIKeySecret)
+
+  const handleSecretSaved = async () => {
+    setSecrets(await GetSecrets())
+    setMessage({ type: 'success', text: 'API key updated successfully!' })
+    setTimeout(() => setMessage(null), 3000)
+  }
+
   const handleConfigChange = (key: keyof AppConfig, value: any) => {
     if (!config) return
     setConfig({ ...config, [key]: value })
   }
 
+  const handleOCRChange = (key: keyof OCRConfig, value: any) => {
+    if (!config) return
+    setConfig({ ...config, OCR: { ...config.OCR, [key]: value } } as AppConfig)
+  }
+
+  const handleOllamaChange = (key: keyof OCRConfig['Ollama'], value: string) => {
+    if (!config) return
+    handleOCRChange('Ollama', { ...config.OCR.Ollama, [key]: value })
+  }
+
+  const handleCommandChange = (key: keyof OCRConfig['Command'], value: any) => {
+    if (!config) return
+    handleOCRChange('Command', { ...config.OCR.Command, [key]: value })
+  }
+
+  const handleCacheChange = (key: keyof OCRConfig['Cache'], value: any) => {
+    if (!config) return
+    handleOCRChange('Cache', { ...config.OCR.Cache, [key]: value })
+  }
+
+  const handlePreprocessChange = (key: keyof OCRConfig['Preprocess'], value: any) => {
+    if (!config) return
+    handleOCRChange('Preprocess', { ...config.OCR.Preprocess, [key]: value })
+  }
+
+  const handleClearCache = async () => {
+    try {
+      await ClearOCRCache()
+      setMessage({ type: 'success', text: 'OCR cache cleared' })
+      setTimeout(() => setMessage(null), 3000)
+    } catch (error) {
+      console.error('Failed to clear OCR cache:', error)
+      setMessage({ type: 'error', text: `Failed to clear OCR cache: ${error}` })
+    }
+  }
+
+  const splitList = (value: string, separator: RegExp) =>
+    value.split(separator).map(item => item.trim()).filter(item => item !== '')
+
+  const loadModels = async () => {
+    try {
+      setLoadingModels(true)
+      setModels(await ListOCRModels())
+    } catch (error) {
+      console.error('Failed to list models:', error)
+      setMessage({ type: 'error', text: `Failed to list models: ${error}` })
+    } finally {
+      setLoadingModels(false)
+    }
+  }
+
   const handleSaveConfig = async () => {
     if (!config) return
 
@@ -48,7 +112,7 @@ function OCRSettings() {
   }
 
   const testConnection = async () => {
-    if (!config?.OpenAIAPIKey || !config?.OpenAIBaseURL) {
+    if (!apiKeySecret?.set || !config?.OpenAIBaseURL) {
       setMessage({ type: 'error', text: 'Please enter both API key and base URL to test connection' })
       return
     }
@@ -111,15 +175,13 @@ function OCRSettings() {
           </h3>
           <div className="space-y-4">
             <div>
-              <label className="block text-sm font-medium form-label mb-2">
-                OpenAI API Key
-              </label>
-              <input
-                type="password"
-                value={config.OpenAIAPIKey}
-                onChange={(e) => handleConfigChange('OpenAIAPIKey', e.target.value)}
-                className="input-field"
+              <SecretField
+                label="OpenAI API Key"
+                name={config.OpenAIAPIKeySecret}
+                masked={apiKeySecret?.masked ?? ''}
                 placeholder="sk-..."
+                onSaved={handleSecretSaved}
+                onError={(text) => setMessage({ type: 'error', text })}
               />
               <p className="text-sm form-description mt-1">
                 Your OpenAI API key for OCR functionality. Get one from{' '}
@@ -149,6 +211,517 @@ function OCRSettings() {
                 Base URL for the OpenAI-compatible API (e.g., Moonshot, OpenAI, etc.)
               </p>
             </div>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Max Retries
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OpenAIMaxRetries}
+                  onChange={(e) => handleConfigChange('OpenAIMaxRetries', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Requests per Minute
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OpenAIRequestsPerMinute}
+                  onChange={(e) => handleConfigChange('OpenAIRequestsPerMinute', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+            </div>
+            <p className="text-sm form-description">
+              Rate-limited and failed requests are retried with backoff. A limit of 0 requests per minute means unlimited.
+            </p>
+          </div>
+        </div>
+
+        {/* Model and Prompts */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <SlidersHorizontal className="w-5 h-5" />
+            Model and Prompts
+          </h3>
+          <div className="space-y-4">
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                Model
+              </label>
+              <div className="flex gap-2">
+                <input
+                  type="text"
+                  list="ocr-models"
+                  value={config.OCR.Model}
+                  onChange={(e) => handleOCRChange('Model', e.target.value)}
+                  className="input-field flex-1"
+                  placeholder="moonshot-v1-8k-vision-preview"
+                />
+                <datalist id="ocr-models">
+                  {models.map(model => <option key={model} value={model} />)}
+                </datalist>
+                <button
+                  onClick={loadModels}
+                  disabled={loadingModels}
+                  className="btn-secondary"
+                >
+                  {loadingModels ? 'Loading...' : 'Load Models'}
+                </button>
+              </div>
+              <p className="text-sm form-description mt-1">
+                A vision-capable model offered by the endpoint. Load Models lists what the endpoint reports.
+              </p>
+            </div>
+
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                Translation Model
+              </label>
+              <input
+                type="text"
+                list="ocr-models"
+                value={config.OCR.TranslationModel}
+                onChange={(e) => handleOCRChange('TranslationModel', e.target.value)}
+                className="input-field"
+                placeholder="Same as the OCR model"
+              />
+              <p className="text-sm form-description mt-1">
+                Model used to translate OCR text. Leave empty to use the OCR model.
+              </p>
+            </div>
+
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                System Prompt
+              </label>
+              <textarea
+                value={config.OCR.SystemPrompt}
+                onChange={(e) => handleOCRChange('SystemPrompt', e.target.value)}
+                className="input-field"
+                rows={3}
+              />
+            </div>
+
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                User Prompt
+              </label>
+              <textarea
+                value={config.OCR.UserPrompt}
+                onChange={(e) => handleOCRChange('UserPrompt', e.target.value)}
+                className="input-field"
+                rows={2}
+              />
+            </div>
+
+            <div className="grid grid-cols-3 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Temperature
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  max={2}
+                  step={0.1}
+                  value={config.OCR.Temperature}
+                  onChange={(e) => handleOCRChange('Temperature', parseFloat(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Max Tokens
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OCR.MaxTokens}
+                  onChange={(e) => handleOCRChange('MaxTokens', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Timeout (seconds)
+                </label>
+                <input
+                  type="number"
+                  min={1}
+                  value={config.OCR.TimeoutSeconds}
+                  onChange={(e) => handleOCRChange('TimeoutSeconds', parseInt(e.target.value) || 30)}
+                  className="input-field"
+                />
+              </div>
+            </div>
+            <p className="text-sm form-description">
+              Max Tokens of 0 leaves the limit to the endpoint.
+            </p>
+
+            <div className="flex items-center justify-between">
+              <div>
+                <label className="text-sm font-medium form-label">
+                  Stream results
+                </label>
+                <p className="text-sm form-description">
+                  Show text as it is recognized instead of waiting for the full result
+                </p>
+              </div>
+              <label className="relative inline-flex items-center cursor-pointer">
+                <input
+                  type="checkbox"
+                  checked={config.OCR.Stream}
+                  onChange={(e) => handleOCRChange('Stream', e.target.checked)}
+                  className="sr-only peer"
+                />
+                <div className="w-11 h-6 toggle-bg peer-focus:outline-none peer-focus:ring-4 peer-focus:ring-primary-300 rounded-full peer peer-checked:after:translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:left-[2px] after:bg-white after:border-gray-300 dark:after:border-gray-600 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:toggle-checked"></div>
+              </label>
+            </div>
+
+            <div className="flex items-center justify-between">
+              <div>
+                <label className="text-sm font-medium form-label">
+                  Auto-copy OCR result
+                </label>
+                <p className="text-sm form-description">
+                  Put the recognized text, or the translation in translate mode, on the clipboard
+                </p>
+              </div>
+              <label className="relative inline-flex items-center cursor-pointer">
+                <input
+                  type="checkbox"
+                  checked={config.OCR.AutoCopy}
+                  onChange={(e) => handleOCRChange('AutoCopy', e.target.checked)}
+                  className="sr-only peer"
+                />
+                <div className="w-11 h-6 toggle-bg peer-focus:outline-none peer-focus:ring-4 peer-focus:ring-primary-300 rounded-full peer peer-checked:after:translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:left-[2px] after:bg-white after:border-gray-300 dark:after:border-gray-600 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:toggle-checked"></div>
+              </label>
+            </div>
+          </div>
+        </div>
+
+        {/* OCR Providers */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <Layers className="w-5 h-5" />
+            OCR Providers
+          </h3>
+          <div className="space-y-4">
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                Provider Order
+              </label>
+              <input
+                type="text"
+                defaultValue={(config.OCR.Providers || []).join(', ')}
+                onChange={(e) => handleOCRChange('Providers', splitList(e.target.value, /,/))}
+                className="input-field"
+                placeholder="openai, ollama, command"
+              />
+              <p className="text-sm form-description mt-1">
+                Comma-separated list of openai, ollama and command. Each is tried in turn until one succeeds.
+              </p>
+            </div>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Ollama URL
+                </label>
+                <input
+                  type="url"
+                  value={config.OCR.Ollama.BaseURL}
+                  onChange={(e) => handleOllamaChange('BaseURL', e.target.value)}
+                  className="input-field"
+                  placeholder="http://localhost:11434"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Ollama Model
+                </label>
+                <input
+                  type="text"
+                  value={config.OCR.Ollama.Model}
+                  onChange={(e) => handleOllamaChange('Model', e.target.value)}
+                  className="input-field"
+                  placeholder="llava"
+                />
+              </div>
+            </div>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Command
+                </label>
+                <input
+                  type="text"
+                  value={config.OCR.Command.Path}
+                  onChange={(e) => handleCommandChange('Path', e.target.value)}
+                  className="input-field"
+                  placeholder="tesseract"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Arguments
+                </label>
+                <input
+                  type="text"
+                  defaultValue={(config.OCR.Command.Args || []).join(' ')}
+                  onChange={(e) => handleCommandChange('Args', splitList(e.target.value, /\s+/))}
+                  className="input-field"
+                  placeholder="stdin stdout"
+                />
+              </div>
+            </div>
+            <p className="text-sm form-description">
+              The command receives the image on standard input and must print the text to standard output.
+            </p>
+          </div>
+        </div>
+
+        {/* Image Preprocessing */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <ImageIcon className="w-5 h-5" />
+            Image Preprocessing
+          </h3>
+          <div className="space-y-4">
+            <div className="flex items-center justify-between">
+              <div>
+                <label className="text-sm font-medium form-label">
+                  Prepare images
+                </label>
+                <p className="text-sm form-description">
+                  Convert, downscale and shrink clipboard images before OCR
+                </p>
+              </div>
+              <label className="relative inline-flex items-center cursor-pointer">
+                <input
+                  type="checkbox"
+                  checked={config.OCR.Preprocess.Enabled}
+                  onChange={(e) => handlePreprocessChange('Enabled', e.target.checked)}
+                  className="sr-only peer"
+                />
+                <div className="w-11 h-6 toggle-bg peer-focus:outline-none peer-focus:ring-4 peer-focus:ring-primary-300 rounded-full peer peer-checked:after:translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:left-[2px] after:bg-white after:border-gray-300 dark:after:border-gray-600 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:toggle-checked"></div>
+              </label>
+            </div>
+
+            <div className="grid grid-cols-3 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Format
+                </label>
+                <select
+                  value={config.OCR.Preprocess.Format}
+                  onChange={(e) => handlePreprocessChange('Format', e.target.value)}
+                  className="input-field"
+                >
+                  <option value="png">PNG</option>
+                  <option value="jpeg">JPEG</option>
+                </select>
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Max Edge (px)
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OCR.Preprocess.MaxEdge}
+                  onChange={(e) => handlePreprocessChange('MaxEdge', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Max Size (KB)
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OCR.Preprocess.MaxSizeKB}
+                  onChange={(e) => handlePreprocessChange('MaxSizeKB', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+            </div>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  JPEG Quality
+                </label>
+                <input
+                  type="number"
+                  min={1}
+                  max={100}
+                  value={config.OCR.Preprocess.JPEGQuality}
+                  onChange={(e) => handlePreprocessChange('JPEGQuality', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Contrast (%)
+                </label>
+                <input
+                  type="number"
+                  min={-100}
+                  max={100}
+                  value={config.OCR.Preprocess.Contrast}
+                  onChange={(e) => handlePreprocessChange('Contrast', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+            </div>
+
+            <div className="flex items-center justify-between">
+              <div>
+                <label className="text-sm font-medium form-label">
+                  Grayscale
+                </label>
+                <p className="text-sm form-description">
+                  Drop colours, which often helps with scanned text
+                </p>
+              </div>
+              <label className="relative inline-flex items-center cursor-pointer">
+                <input
+                  type="checkbox"
+                  checked={config.OCR.Preprocess.Grayscale}
+                  onChange={(e) => handlePreprocessChange('Grayscale', e.target.checked)}
+                  className="sr-only peer"
+                />
+                <div className="w-11 h-6 toggle-bg peer-focus:outline-none peer-focus:ring-4 peer-focus:ring-primary-300 rounded-full peer peer-checked:after:translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:left-[2px] after:bg-white after:border-gray-300 dark:after:border-gray-600 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:toggle-checked"></div>
+              </label>
+            </div>
+
+            <div className="grid grid-cols-4 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Crop Left
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OCR.Preprocess.CropLeft}
+                  onChange={(e) => handlePreprocessChange('CropLeft', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Crop Top
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OCR.Preprocess.CropTop}
+                  onChange={(e) => handlePreprocessChange('CropTop', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Crop Right
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OCR.Preprocess.CropRight}
+                  onChange={(e) => handlePreprocessChange('CropRight', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Crop Bottom
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OCR.Preprocess.CropBottom}
+                  onChange={(e) => handlePreprocessChange('CropBottom', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+            </div>
+            <p className="text-sm form-description">
+              Crop values are pixels removed from each edge. Images larger than the maximum size are
+              re-encoded as JPEG at lower quality, then downscaled further. 0 disables a limit.
+            </p>
+          </div>
+        </div>
+
+        {/* OCR Cache */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <Database className="w-5 h-5" />
+            Result Cache
+          </h3>
+          <div className="space-y-4">
+            <div className="flex items-center justify-between">
+              <div>
+                <label className="text-sm font-medium form-label">
+                  Cache results
+                </label>
+                <p className="text-sm form-description">
+                  Reuse the text of images that were already read with the same settings
+                </p>
+              </div>
+              <label className="relative inline-flex items-center cursor-pointer">
+                <input
+                  type="checkbox"
+                  checked={config.OCR.Cache.Enabled}
+                  onChange={(e) => handleCacheChange('Enabled', e.target.checked)}
+                  className="sr-only peer"
+                />
+                <div className="w-11 h-6 toggle-bg peer-focus:outline-none peer-focus:ring-4 peer-focus:ring-primary-300 rounded-full peer peer-checked:after:translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:left-[2px] after:bg-white after:border-gray-300 dark:after:border-gray-600 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:toggle-checked"></div>
+              </label>
+            </div>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Expire After (hours)
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OCR.Cache.TTLHours}
+                  onChange={(e) => handleCacheChange('TTLHours', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Maximum Size (MB)
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={config.OCR.Cache.MaxSizeMB}
+                  onChange={(e) => handleCacheChange('MaxSizeMB', parseInt(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+            </div>
+            <p className="text-sm form-description">
+              A value of 0 means no limit. Use the refresh button next to OCR to read an image again.
+            </p>
+
+            <button onClick={handleClearCache} className="btn-secondary">
+              Clear Cache
+            </button>
           </div>
         </div>
 
@@ -191,19 +764,20 @@ function OCRSettings() {
           </h3>
           <div className="space-y-3 text-sm text-gray-600 dark:text-gray-400">
             <p>
-              The OCR feature uses OpenAI's Vision API to extract text from images in your clipboard.
+              The OCR feature extracts text from images in your clipboard using OpenAI's Vision API,
+              a local Ollama model or a command-line tool such as Tesseract.
             </p>
             <p>
               When you click the "OCR" button in the todo list, the app will:
             </p>
             <ol className="list-decimal list-inside space-y-1 ml-4">
               <li>Read the image from your clipboard</li>
-              <li>Send it to the configured OpenAI API</li>
+              <li>Send it to the configured providers, in order</li>
               <li>Extract the text content</li>
               <li>Populate the todo input field with the extracted text</li>
             </ol>
             <p className="text-xs text-gray-500 dark:text-gray-500 mt-3">
-              Note: Images are sent to the API for processing. Make sure you're comfortable with this before using the feature.
+              Note: With the openai provider, images are sent to the API for processing. Use ollama or command to keep them on this machine.
             </p>
           </div>
         </div>
@@ -0,0 +1,71 @@
+import { useState } from 'react'
+import { SetSecret } from '@wailsjs/go/main/App'
+
+interface SecretFieldProps {
+  label: string
+  name: string
+  masked: string
+  placeholder?: string
+  onSaved: () => void
+  onError: (text: string) => void
+}
+
+// SecretField is a write-only input: the stored value is never sent to the
+// frontend, only its masked form, and a new value replaces it on save.
+function SecretField({ label, name, masked, placeholder, onSaved, onError }: SecretFieldProps) {
+  const [value, setValue] = useState('')
+  const [saving, setSaving] = useState(false)
+
+  const save = async (newValue: string) => {
+    try {
+      setSaving(true)
+      await SetSecret(name, newValue)
+      setValue('')
+      onSaved()
+    } catch (error) {
+      console.error('Failed to save secret:', error)
+      onError(`Failed to save ${label}`)
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  return (
+    <div>
+      <label className="block text-sm font-medium form-label mb-2">
+        {label}
+      </label>
+      <div className="flex gap-2">
+        <input
+          type="password"
+          autoComplete="off"
+          value={value}
+          onChange={(e) => setValue(e.target.value)}
+          className="input-field flex-1"
+          placeholder={masked || placeholder}
+        />
+        <button
+          onClick={() => save(value)}
+          disabled={saving || value === ''}
+          className="btn-primary"
+        >
+          Update
+        </button>
+        {masked && (
+          <button
+            onClick={() => save('')}
+            disabled={saving}
+            className="btn-secondary"
+          >
+            Clear
+          </button>
+        )}
+      </div>
+      <p className="text-sm form-description mt-1">
+        {masked ? `Stored encrypted (${masked}).` : 'Not set.'} Enter a new value to replace it.
+      </p>
+    </div>
+  )
+}
+
+export default SecretField
@@ -1,5 +1,5 @@
 import { Link, useLocation } from 'react-router-dom'
-import { Palette, CheckSquare, Eye, Globe, Settings as SettingsIcon } from 'lucide-react'
+import { Palette, CheckSquare, Eye, Globe, BarChart3, MessageSquare, Settings as SettingsIcon } from 'lucide-react'
 
 export interface SettingsSection {
   id: string
@@ -31,6 +31,20 @@ export const settingsSections: SettingsSection[] = [
     icon: <Eye className="w-4 h-4" />,
     description: 'OpenAI API and image recognition settings'
   },
+  {
+    id: 'chat',
+    label: 'Chat',
+    path: '/settings/chat',
+    icon: <MessageSquare className="w-4 h-4" />,
+    description: 'Assistant model, prompt and history'
+  },
+  {
+    id: 'usage',
+    label: 'Usage',
+    path: '/settings/usage',
+    icon: <BarChart3 className="w-4 h-4" />,
+    description: 'AI token usage, cost and budgets'
+  },
   {
     id: 'general',
     label: 'General',
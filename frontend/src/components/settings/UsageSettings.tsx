@@ -0,0 +1,347 @@
+import { useState, useEffect } from 'react'
+import { GetConfig, GetUsageReport, SaveConfig } from '@wailsjs/go/main/App'
+import { AppConfig, UsageConfig, UsageReport, UsageTotal } from '../../types'
+import { BarChart3, Plus, Trash2, Wallet } from 'lucide-react'
+
+const periods = [7, 30, 90]
+
+function UsageSettings() {
+  const [config, setConfig] = useState<AppConfig | null>(null)
+  const [report, setReport] = useState<UsageReport | null>(null)
+  const [days, setDays] = useState(30)
+  const [newModel, setNewModel] = useState('')
+  const [loading, setLoading] = useState(true)
+  const [saving, setSaving] = useState(false)
+  const [message, setMessage] = useState<{ type: 'success' | 'error', text: string } | null>(null)
+
+  useEffect(() => {
+    loadConfig()
+  }, [])
+
+  useEffect(() => {
+    loadReport()
+  }, [days])
+
+  const loadConfig = async () => {
+    try {
+      setLoading(true)
+      const configData = await GetConfig()
+      setConfig(configData)
+    } catch (error) {
+      console.error('Failed to load config:', error)
+      setMessage({ type: 'error', text: 'Failed to load configuration' })
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  const loadReport = async () => {
+    try {
+      setReport(await GetUsageReport(days))
+    } catch (error) {
+      console.error('Failed to load usage report:', error)
+      setMessage({ type: 'error', text: 'Failed to load usage report' })
+    }
+  }
+
+  const handleUsageChange = (key: keyof UsageConfig, value: any) => {
+    if (!config) return
+    setConfig({ ...config, Usage: { ...config.Usage, [key]: value } } as AppConfig)
+  }
+
+  const handlePriceChange = (model: string, key: 'prompt' | 'completion', value: number) => {
+    if (!config) return
+    const prices = config.Usage.Prices || {}
+    handleUsageChange('Prices', { ...prices, [model]: { ...prices[model], [key]: value } })
+  }
+
+  const addPrice = () => {
+    const model = newModel.trim()
+    if (!config || !model) return
+    handleUsageChange('Prices', { ...(config.Usage.Prices || {}), [model]: { prompt: 0, completion: 0 } })
+    setNewModel('')
+  }
+
+  const removePrice = (model: string) => {
+    if (!config) return
+    const prices = { ...(config.Usage.Prices || {}) }
+    delete prices[model]
+    handleUsageChange('Prices', prices)
+  }
+
+  const handleSaveConfig = async () => {
+    if (!config) return
+
+    try {
+      setSaving(true)
+      await SaveConfig(config)
+      await loadReport()
+      setMessage({ type: 'success', text: 'Usage settings saved successfully!' })
+      setTimeout(() => setMessage(null), 3000)
+    } catch (error) {
+      console.error('Failed to save config:', error)
+      setMessage({ type: 'error', text: 'Failed to save usage settings' })
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const formatCost = (cost: number) => cost.toFixed(cost < 1 ? 4 : 2)
+
+  const budgetLine = (label: string, spent: number, budget: number) => (
+    <div>
+      <div className="flex justify-between text-sm mb-1">
+        <span className="form-label">{label}</span>
+        <span className="text-gray-600 dark:text-gray-400">
+          {formatCost(spent)}{budget > 0 ? ` of ${formatCost(budget)}` : ' (no budget)'}
+        </span>
+      </div>
+      {budget > 0 && (
+        <div className="w-full h-2 bg-gray-200 dark:bg-gray-700 rounded-full overflow-hidden">
+          <div
+            className={`h-2 ${spent >= budget ? 'bg-red-500' : 'bg-primary-600'}`}
+            style={{ width: `${Math.min(100, (spent / budget) * 100)}%` }}
+          />
+        </div>
+      )}
+    </div>
+  )
+
+  const totalsTable = (title: string, totals: UsageTotal[]) => (
+    <div>
+      <h4 className="text-sm font-medium form-label mb-2">{title}</h4>
+      {totals.length === 0 ? (
+        <p className="text-sm form-description">No calls recorded</p>
+      ) : (
+        <table className="w-full text-sm">
+          <thead>
+            <tr className="text-left text-gray-500 dark:text-gray-400">
+              <th className="py-1 font-medium"></th>
+              <th className="py-1 font-medium text-right">Calls</th>
+              <th className="py-1 font-medium text-right">Tokens</th>
+              <th className="py-1 font-medium text-right">Avg latency</th>
+              <th className="py-1 font-medium text-right">Cost</th>
+            </tr>
+          </thead>
+          <tbody className="text-gray-900 dark:text-gray-100">
+            {totals.map((total) => (
+              <tr key={total.key} className="border-t border-gray-100 dark:border-gray-700">
+                <td className="py-1">{total.key}</td>
+                <td className="py-1 text-right">
+                  {total.requests}{total.failures > 0 ? ` (${total.failures} failed)` : ''}
+                </td>
+                <td className="py-1 text-right">{total.promptTokens + total.completionTokens}</td>
+                <td className="py-1 text-right">{total.avgLatencyMs} ms</td>
+                <td className="py-1 text-right">{formatCost(total.cost)}</td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+    </div>
+  )
+
+  if (loading) {
+    return (
+      <div className="flex justify-center items-center h-64">
+        <div className="animate-spin rounded-full h-8 w-8 border-b-2 border-primary-600"></div>
+      </div>
+    )
+  }
+
+  if (!config) {
+    return (
+      <div className="text-center py-12">
+        <p className="text-gray-500 dark:text-gray-400">Failed to load configuration</p>
+      </div>
+    )
+  }
+
+  return (
+    <div className="max-w-2xl">
+      <div className="mb-8">
+        <h1 className="text-2xl font-bold text-gray-900 dark:text-gray-100 mb-2">
+          Usage
+        </h1>
+        <p className="text-gray-600 dark:text-gray-400">
+          Token usage, cost and budgets for AI requests
+        </p>
+      </div>
+
+      {message && (
+        <div className={`mb-6 p-4 rounded-lg flex items-center gap-2 ${
+          message.type === 'success' ? 'message-success' : 'message-error'
+        }`}>
+          {message.type === 'success' ? (
+            <div className="w-4 h-4 rounded-full bg-green-500"></div>
+          ) : (
+            <div className="w-4 h-4 rounded-full bg-red-500"></div>
+          )}
+          {message.text}
+        </div>
+      )}
+
+      <div className="space-y-6">
+        {/* Usage Report */}
+        <div className="card">
+          <div className="flex items-center justify-between mb-4">
+            <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 flex items-center gap-2">
+              <BarChart3 className="w-5 h-5" />
+              Usage Report
+            </h3>
+            <select
+              value={days}
+              onChange={(e) => setDays(parseInt(e.target.value))}
+              className="input-field w-auto"
+            >
+              {periods.map((period) => (
+                <option key={period} value={period}>Last {period} days</option>
+              ))}
+            </select>
+          </div>
+          {report && (
+            <div className="space-y-6">
+              <div className="space-y-3">
+                {budgetLine('Today', report.todayCost, report.dailyBudget)}
+                {budgetLine('This month', report.monthCost, report.monthlyBudget)}
+              </div>
+              <p className="text-sm form-description">
+                {report.total.requests} calls, {report.total.promptTokens} prompt and {report.total.completionTokens} completion
+                tokens, {formatCost(report.total.cost)} in total over the last {days} days.
+              </p>
+              {totalsTable('By model', report.byModel || [])}
+              {totalsTable('By feature', report.byFeature || [])}
+              {totalsTable('By day', report.byDay || [])}
+            </div>
+          )}
+        </div>
+
+        {/* Budgets */}
+        <div className="card">
+          <h3 className="text-lg font-semibold text-gray-900 dark:text-gray-100 mb-4 flex items-center gap-2">
+            <Wallet className="w-5 h-5" />
+            Budgets and Prices
+          </h3>
+          <div className="space-y-4">
+            <div className="grid grid-cols-3 gap-4">
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Daily Budget
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  step={0.01}
+                  value={config.Usage.DailyBudget}
+                  onChange={(e) => handleUsageChange('DailyBudget', parseFloat(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  Monthly Budget
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  step={0.01}
+                  value={config.Usage.MonthlyBudget}
+                  onChange={(e) => handleUsageChange('MonthlyBudget', parseFloat(e.target.value) || 0)}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium form-label mb-2">
+                  When Exceeded
+                </label>
+                <select
+                  value={config.Usage.BudgetAction}
+                  onChange={(e) => handleUsageChange('BudgetAction', e.target.value)}
+                  className="input-field"
+                >
+                  <option value="warn">Warn</option>
+                  <option value="block">Block</option>
+                </select>
+              </div>
+            </div>
+            <p className="text-sm form-description">
+              A budget of 0 means no limit. Blocked requests fall back to the other OCR providers.
+            </p>
+
+            <div>
+              <label className="block text-sm font-medium form-label mb-2">
+                Prices per Million Tokens
+              </label>
+              <div className="space-y-2">
+                {Object.entries(config.Usage.Prices || {}).map(([model, price]) => (
+                  <div key={model} className="flex items-center gap-2">
+                    <span className="flex-1 text-sm text-gray-900 dark:text-gray-100 truncate">{model}</span>
+                    <input
+                      type="number"
+                      min={0}
+                      step={0.01}
+                      value={price.prompt}
+                      onChange={(e) => handlePriceChange(model, 'prompt', parseFloat(e.target.value) || 0)}
+                      className="input-field w-28"
+                      title="Prompt"
+                    />
+                    <input
+                      type="number"
+                      min={0}
+                      step={0.01}
+                      value={price.completion}
+                      onChange={(e) => handlePriceChange(model, 'completion', parseFloat(e.target.value) || 0)}
+                      className="input-field w-28"
+                      title="Completion"
+                    />
+                    <button
+                      onClick={() => removePrice(model)}
+                      className="p-2 text-gray-400 hover:text-red-600 dark:hover:text-red-400"
+                      title="Remove price"
+                    >
+                      <Trash2 className="w-4 h-4" />
+                    </button>
+                  </div>
+                ))}
+                <div className="flex gap-2">
+                  <input
+                    type="text"
+                    value={newModel}
+                    onChange={(e) => setNewModel(e.target.value)}
+                    className="input-field flex-1"
+                    placeholder="Model name or prefix, e.g. gpt-4o"
+                  />
+                  <button onClick={addPrice} className="btn-secondary flex items-center gap-2">
+                    <Plus className="w-4 h-4" />
+                    Add
+                  </button>
+                </div>
+              </div>
+              <p className="text-sm form-description mt-1">
+                Prompt and completion prices, in the currency of your budgets. Calls to unpriced models cost 0.
+              </p>
+            </div>
+          </div>
+        </div>
+
+        {/* Action Buttons */}
+        <div className="flex gap-4">
+          <button
+            onClick={handleSaveConfig}
+            disabled={saving}
+            className="btn-primary flex items-center gap-2"
+          >
+            {saving ? (
+              <div className="w-4 h-4 border-2 border-gray-300 border-t-gray-600 rounded-full animate-spin" />
+            ) : (
+              <div className="w-4 h-4 rounded-full bg-white"></div>
+            )}
+            {saving ? 'Saving...' : 'Save Usage Settings'}
+          </button>
+        </div>
+      </div>
+    </div>
+  )
+}
+
+export default UsageSettings
@@ -0,0 +1,24 @@
+import { SensitiveReport } from './types'
+
+// sourceNames names the text a report is about
+const sourceNames: Record<SensitiveReport['source'], string> = {
+  ocr: 'The OCR text',
+  clipboard: 'The copied text',
+  chat: 'The message',
+  tasks: 'The text for task extraction',
+}
+
+// sensitiveMessage describes a sensitive:detected report for the user,
+// naming the kinds of data found and what was done about them
+export function sensitiveMessage(report: SensitiveReport): string {
+  const kinds = Array.from(new Set(report.findings.map(finding => finding.kind))).join(', ')
+  const source = sourceNames[report.source]
+  switch (report.action) {
+    case 'block':
+      return `${source} was not kept or sent because it contains sensitive data (${kinds})`
+    case 'redact':
+      return `Sensitive data (${kinds}) was redacted from ${source.toLowerCase()}`
+    default:
+      return `${source} contains sensitive data (${kinds})`
+  }
+}
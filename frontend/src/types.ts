@@ -1,5 +1,60 @@
 // Import and re-export types for convenience
-import { models, config } from '@wailsjs/go/models'
+import { models, config, ocr, services, tasks, transform, usage } from '@wailsjs/go/models'
 
 export type Todo = models.Todo
+export type Conversation = models.Conversation
+export type ChatMessage = models.ChatMessage
+export type ToolInvocation = models.ToolInvocation
+export type OCRResult = models.OCRResult
+export type SearchResult = models.SearchResult
+export type ClipboardEntry = models.ClipboardEntry
 export type AppConfig = config.Config
+export type OCRConfig = config.OCRConfig
+export type ChatConfig = config.ChatConfig
+export type HTTPConfig = config.HTTPConfig
+export type ClipboardHistoryConfig = config.ClipboardHistoryConfig
+export type SensitiveConfig = config.SensitiveConfig
+export type TransformConfig = config.TransformConfig
+export type SecretStatus = services.SecretStatus
+export type StructuredOCRResult = ocr.StructuredResult
+export type Task = tasks.Task
+export type Transformer = transform.Transformer
+export type UsageConfig = config.UsageConfig
+export type UsageReport = usage.Report
+export type UsageTotal = usage.Total
+
+// OCR modes: plain text, text with a translation, and the structured modes
+// accepted by OCRStructuredFromClipboard
+export type OCRMode = 'text' | 'translate' | 'table' | 'code' | 'keyvalue'
+
+// Payload of the usage:budget event
+export interface BudgetWarning {
+  period: string
+  spent: number
+  budget: number
+}
+
+// A piece of sensitive data found in OCR or clipboard text; preview masks
+// all but its first and last characters
+export interface SensitiveFinding {
+  kind: string
+  start: number
+  end: number
+  preview: string
+}
+
+// Payload of the sensitive:detected event
+export interface SensitiveReport {
+  source: 'ocr' | 'clipboard' | 'chat' | 'tasks'
+  action: 'warn' | 'redact' | 'block'
+  findings: SensitiveFinding[]
+}
+
+// Payload of the chat:confirm event, answered with ConfirmToolCall
+export interface ToolConfirmation {
+  id: string
+  conversationId: string
+  name: string
+  description: string
+  arguments: string
+}
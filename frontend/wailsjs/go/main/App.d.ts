@@ -2,17 +2,92 @@
 // This file is automatically generated. DO NOT EDIT
 import {models} from '../models';
 import {config} from '../models';
+import {ocr} from '../models';
+import {services} from '../models';
+import {tasks} from '../models';
+import {transform} from '../models';
+import {usage} from '../models';
+
+export function AddTasks(arg1:Array<tasks.Task>):Promise<Array<models.Todo>>;
 
 export function AddTodo(arg1:string):Promise<models.Todo>;
 
+export function CancelChat():Promise<void>;
+
+export function CancelOCR():Promise<void>;
+
+export function ClearClipboardHistory():Promise<void>;
+
+export function ClearOCRCache():Promise<void>;
+
+export function ConfirmToolCall(arg1:string,arg2:boolean):Promise<void>;
+
+export function CopyClipboardEntry(arg1:string):Promise<void>;
+
+export function CreateConversation(arg1:string):Promise<models.Conversation>;
+
+export function DeleteClipboardEntry(arg1:string):Promise<void>;
+
+export function DeleteConversation(arg1:string):Promise<void>;
+
+export function DeleteOCRResult(arg1:string):Promise<void>;
+
 export function DeleteTodo(arg1:string):Promise<void>;
 
+export function ExplainConfig():Promise<Array<config.FieldSource>>;
+
+export function ExportBundle(arg1:string):Promise<void>;
+
+export function ExportBundleWithSecrets(arg1:string,arg2:string):Promise<void>;
+
+export function ExtractTasks(arg1:string):Promise<Array<tasks.Task>>;
+
+export function GetChatMessages(arg1:string):Promise<Array<models.ChatMessage>>;
+
+export function GetClipboardHistory(arg1:string,arg2:number):Promise<Array<models.ClipboardEntry>>;
+
 export function GetConfig():Promise<config.Config>;
 
+export function GetConversations():Promise<Array<models.Conversation>>;
+
+export function GetOCRHistory(arg1:number):Promise<Array<models.OCRResult>>;
+
+export function GetSecrets():Promise<Array<services.SecretStatus>>;
+
 export function GetTodos():Promise<Array<models.Todo>>;
 
-export function OCRFromClipboard():Promise<string>;
+export function GetToolInvocations(arg1:string):Promise<Array<models.ToolInvocation>>;
+
+export function GetUsageReport(arg1:number):Promise<usage.Report>;
+
+export function ImportBundle(arg1:string):Promise<services.ImportResult>;
+
+export function ImportBundleWithSecrets(arg1:string,arg2:string):Promise<services.ImportResult>;
+
+export function ListConfigHistory():Promise<Array<config.HistoryEntry>>;
+
+export function ListOCRModels():Promise<Array<string>>;
+
+export function ListTransformers():Promise<Array<transform.Transformer>>;
+
+export function OCRAndTranslate(arg1:string,arg2:boolean):Promise<models.OCRResult>;
+
+export function OCRFromClipboard(arg1:boolean):Promise<string>;
+
+export function OCRStructuredFromClipboard(arg1:string,arg2:boolean):Promise<ocr.StructuredResult>;
+
+export function PinClipboardEntry(arg1:string,arg2:boolean):Promise<void>;
+
+export function RestoreConfig(arg1:string):Promise<void>;
 
 export function SaveConfig(arg1:config.Config):Promise<void>;
 
+export function SemanticSearch(arg1:string,arg2:number):Promise<Array<models.SearchResult>>;
+
+export function SendChatMessage(arg1:string,arg2:string,arg3:boolean):Promise<models.ChatMessage>;
+
+export function SetSecret(arg1:string,arg2:string):Promise<void>;
+
+export function TransformClipboard(arg1:Array<string>):Promise<string>;
+
 export function UpdateTodo(arg1:string,arg2:string,arg3:boolean):Promise<models.Todo>;
@@ -2,30 +2,170 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddTasks(arg1) {
+  return window['go']['main']['App']['AddTasks'](arg1);
+}
+
 export function AddTodo(arg1) {
   return window['go']['main']['App']['AddTodo'](arg1);
 }
 
+export function CancelChat() {
+  return window['go']['main']['App']['CancelChat']();
+}
+
+export function CancelOCR() {
+  return window['go']['main']['App']['CancelOCR']();
+}
+
+export function ClearClipboardHistory() {
+  return window['go']['main']['App']['ClearClipboardHistory']();
+}
+
+export function ClearOCRCache() {
+  return window['go']['main']['App']['ClearOCRCache']();
+}
+
+export function ConfirmToolCall(arg1, arg2) {
+  return window['go']['main']['App']['ConfirmToolCall'](arg1, arg2);
+}
+
+export function CopyClipboardEntry(arg1) {
+  return window['go']['main']['App']['CopyClipboardEntry'](arg1);
+}
+
+export function CreateConversation(arg1) {
+  return window['go']['main']['App']['CreateConversation'](arg1);
+}
+
+export function DeleteClipboardEntry(arg1) {
+  return window['go']['main']['App']['DeleteClipboardEntry'](arg1);
+}
+
+export function DeleteConversation(arg1) {
+  return window['go']['main']['App']['DeleteConversation'](arg1);
+}
+
+export function DeleteOCRResult(arg1) {
+  return window['go']['main']['App']['DeleteOCRResult'](arg1);
+}
+
 export function DeleteTodo(arg1) {
   return window['go']['main']['App']['DeleteTodo'](arg1);
 }
 
+export function ExplainConfig() {
+  return window['go']['main']['App']['ExplainConfig']();
+}
+
+export function ExportBundle(arg1) {
+  return window['go']['main']['App']['ExportBundle'](arg1);
+}
+
+export function ExportBundleWithSecrets(arg1, arg2) {
+  return window['go']['main']['App']['ExportBundleWithSecrets'](arg1, arg2);
+}
+
+export function ExtractTasks(arg1) {
+  return window['go']['main']['App']['ExtractTasks'](arg1);
+}
+
+export function GetChatMessages(arg1) {
+  return window['go']['main']['App']['GetChatMessages'](arg1);
+}
+
+export function GetClipboardHistory(arg1, arg2) {
+  return window['go']['main']['App']['GetClipboardHistory'](arg1, arg2);
+}
+
 export function GetConfig() {
   return window['go']['main']['App']['GetConfig']();
 }
 
+export function GetConversations() {
+  return window['go']['main']['App']['GetConversations']();
+}
+
+export function GetOCRHistory(arg1) {
+  return window['go']['main']['App']['GetOCRHistory'](arg1);
+}
+
+export function GetSecrets() {
+  return window['go']['main']['App']['GetSecrets']();
+}
+
 export function GetTodos() {
   return window['go']['main']['App']['GetTodos']();
 }
 
-export function OCRFromClipboard() {
-  return window['go']['main']['App']['OCRFromClipboard']();
+export function GetToolInvocations(arg1) {
+  return window['go']['main']['App']['GetToolInvocations'](arg1);
+}
+
+export function GetUsageReport(arg1) {
+  return window['go']['main']['App']['GetUsageReport'](arg1);
+}
+
+export function ImportBundle(arg1) {
+  return window['go']['main']['App']['ImportBundle'](arg1);
+}
+
+export function ImportBundleWithSecrets(arg1, arg2) {
+  return window['go']['main']['App']['ImportBundleWithSecrets'](arg1, arg2);
+}
+
+export function ListConfigHistory() {
+  return window['go']['main']['App']['ListConfigHistory']();
+}
+
+export function ListOCRModels() {
+  return window['go']['main']['App']['ListOCRModels']();
+}
+
+export function ListTransformers() {
+  return window['go']['main']['App']['ListTransformers']();
+}
+
+export function OCRAndTranslate(arg1, arg2) {
+  return window['go']['main']['App']['OCRAndTranslate'](arg1, arg2);
+}
+
+export function OCRFromClipboard(arg1) {
+  return window['go']['main']['App']['OCRFromClipboard'](arg1);
+}
+
+export function OCRStructuredFromClipboard(arg1, arg2) {
+  return window['go']['main']['App']['OCRStructuredFromClipboard'](arg1, arg2);
+}
+
+export function PinClipboardEntry(arg1, arg2) {
+  return window['go']['main']['App']['PinClipboardEntry'](arg1, arg2);
+}
+
+export function RestoreConfig(arg1) {
+  return window['go']['main']['App']['RestoreConfig'](arg1);
 }
 
 export function SaveConfig(arg1) {
   return window['go']['main']['App']['SaveConfig'](arg1);
 }
 
+export function SemanticSearch(arg1, arg2) {
+  return window['go']['main']['App']['SemanticSearch'](arg1, arg2);
+}
+
+export function SendChatMessage(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SendChatMessage'](arg1, arg2, arg3);
+}
+
+export function SetSecret(arg1, arg2) {
+  return window['go']['main']['App']['SetSecret'](arg1, arg2);
+}
+
+export function TransformClipboard(arg1) {
+  return window['go']['main']['App']['TransformClipboard'](arg1);
+}
+
 export function UpdateTodo(arg1, arg2, arg3) {
   return window['go']['main']['App']['UpdateTodo'](arg1, arg2, arg3);
 }
@@ -1,4 +1,346 @@
+export namespace bundle {
+  export class Manifest {
+    formatVersion: number;
+    appVersion: string;
+    configSchemaVersion: number;
+    databaseSchemaVersion: number;
+    // Go type: time
+    createdAt: any;
+    includesSecrets: boolean;
+
+    static createFrom(source: any = {}) {
+      return new Manifest(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.formatVersion = source["formatVersion"];
+      this.appVersion = source["appVersion"];
+      this.configSchemaVersion = source["configSchemaVersion"];
+      this.databaseSchemaVersion = source["databaseSchemaVersion"];
+      this.createdAt = this.convertValues(source["createdAt"], null);
+      this.includesSecrets = source["includesSecrets"];
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+}
+
 export namespace config {
+  export class ChatConfig {
+    Model: string;
+    SystemPrompt: string;
+    Temperature: number;
+    MaxTokens: number;
+    Stream: boolean;
+    TimeoutSeconds: number;
+    HistoryMessages: number;
+    Tools: boolean;
+    MaxToolRounds: number;
+
+    static createFrom(source: any = {}) {
+      return new ChatConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.Model = source["Model"];
+      this.SystemPrompt = source["SystemPrompt"];
+      this.Temperature = source["Temperature"];
+      this.MaxTokens = source["MaxTokens"];
+      this.Stream = source["Stream"];
+      this.TimeoutSeconds = source["TimeoutSeconds"];
+      this.HistoryMessages = source["HistoryMessages"];
+      this.Tools = source["Tools"];
+      this.MaxToolRounds = source["MaxToolRounds"];
+    }
+  }
+  export class ClipboardHistoryConfig {
+    Enabled: boolean;
+    MaxEntries: number;
+    RetentionDays: number;
+    MaxItemKB: number;
+    ThumbnailSize: number;
+
+    static createFrom(source: any = {}) {
+      return new ClipboardHistoryConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.Enabled = source["Enabled"];
+      this.MaxEntries = source["MaxEntries"];
+      this.RetentionDays = source["RetentionDays"];
+      this.MaxItemKB = source["MaxItemKB"];
+      this.ThumbnailSize = source["ThumbnailSize"];
+    }
+  }
+  export class CommandOCRConfig {
+    Path: string;
+    Args: string[];
+
+    static createFrom(source: any = {}) {
+      return new CommandOCRConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.Path = source["Path"];
+      this.Args = source["Args"];
+    }
+  }
+  export class HTTPConfig {
+    ProxyURL: string;
+    CAFile: string;
+    InsecureSkipVerify: boolean;
+    TimeoutSeconds: number;
+    ConnectTimeoutSeconds: number;
+    UserAgent: string;
+    LogRequests: boolean;
+
+    static createFrom(source: any = {}) {
+      return new HTTPConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.ProxyURL = source["ProxyURL"];
+      this.CAFile = source["CAFile"];
+      this.InsecureSkipVerify = source["InsecureSkipVerify"];
+      this.TimeoutSeconds = source["TimeoutSeconds"];
+      this.ConnectTimeoutSeconds = source["ConnectTimeoutSeconds"];
+      this.UserAgent = source["UserAgent"];
+      this.LogRequests = source["LogRequests"];
+    }
+  }
+  export class OCRCacheConfig {
+    Enabled: boolean;
+    TTLHours: number;
+    MaxSizeMB: number;
+
+    static createFrom(source: any = {}) {
+      return new OCRCacheConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.Enabled = source["Enabled"];
+      this.TTLHours = source["TTLHours"];
+      this.MaxSizeMB = source["MaxSizeMB"];
+    }
+  }
+  export class OCRPreprocessConfig {
+    Enabled: boolean;
+    Format: string;
+    MaxEdge: number;
+    MaxSizeKB: number;
+    JPEGQuality: number;
+    Grayscale: boolean;
+    Contrast: number;
+    CropLeft: number;
+    CropTop: number;
+    CropRight: number;
+    CropBottom: number;
+
+    static createFrom(source: any = {}) {
+      return new OCRPreprocessConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.Enabled = source["Enabled"];
+      this.Format = source["Format"];
+      this.MaxEdge = source["MaxEdge"];
+      this.MaxSizeKB = source["MaxSizeKB"];
+      this.JPEGQuality = source["JPEGQuality"];
+      this.Grayscale = source["Grayscale"];
+      this.Contrast = source["Contrast"];
+      this.CropLeft = source["CropLeft"];
+      this.CropTop = source["CropTop"];
+      this.CropRight = source["CropRight"];
+      this.CropBottom = source["CropBottom"];
+    }
+  }
+  export class OllamaOCRConfig {
+    BaseURL: string;
+    Model: string;
+
+    static createFrom(source: any = {}) {
+      return new OllamaOCRConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.BaseURL = source["BaseURL"];
+      this.Model = source["Model"];
+    }
+  }
+  export class OCRConfig {
+    Model: string;
+    SystemPrompt: string;
+    UserPrompt: string;
+    Temperature: number;
+    MaxTokens: number;
+    TimeoutSeconds: number;
+    Stream: boolean;
+    TranslationModel: string;
+    AutoCopy: boolean;
+    Providers: string[];
+    Ollama: OllamaOCRConfig;
+    Command: CommandOCRConfig;
+    Cache: OCRCacheConfig;
+    Preprocess: OCRPreprocessConfig;
+
+    static createFrom(source: any = {}) {
+      return new OCRConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.Model = source["Model"];
+      this.SystemPrompt = source["SystemPrompt"];
+      this.UserPrompt = source["UserPrompt"];
+      this.Temperature = source["Temperature"];
+      this.MaxTokens = source["MaxTokens"];
+      this.TimeoutSeconds = source["TimeoutSeconds"];
+      this.Stream = source["Stream"];
+      this.TranslationModel = source["TranslationModel"];
+      this.AutoCopy = source["AutoCopy"];
+      this.Providers = source["Providers"];
+      this.Ollama = this.convertValues(source["Ollama"], OllamaOCRConfig);
+      this.Command = this.convertValues(source["Command"], CommandOCRConfig);
+      this.Cache = this.convertValues(source["Cache"], OCRCacheConfig);
+      this.Preprocess = this.convertValues(source["Preprocess"], OCRPreprocessConfig);
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+  export class SearchConfig {
+    Model: string;
+    Limit: number;
+    MinScore: number;
+    BatchSize: number;
+    OCRResults: number;
+    WorkflowyMaxNodes: number;
+    WorkflowyRefreshMinutes: number;
+
+    static createFrom(source: any = {}) {
+      return new SearchConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.Model = source["Model"];
+      this.Limit = source["Limit"];
+      this.MinScore = source["MinScore"];
+      this.BatchSize = source["BatchSize"];
+      this.OCRResults = source["OCRResults"];
+      this.WorkflowyMaxNodes = source["WorkflowyMaxNodes"];
+      this.WorkflowyRefreshMinutes = source["WorkflowyRefreshMinutes"];
+    }
+  }
+  export class SensitiveConfig {
+    Enabled: boolean;
+    Action: string;
+    Kinds: string[];
+    MinEntropy: number;
+    MinSecretLength: number;
+    HistoryExpiryMinutes: number;
+
+    static createFrom(source: any = {}) {
+      return new SensitiveConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.Enabled = source["Enabled"];
+      this.Action = source["Action"];
+      this.Kinds = source["Kinds"];
+      this.MinEntropy = source["MinEntropy"];
+      this.MinSecretLength = source["MinSecretLength"];
+      this.HistoryExpiryMinutes = source["HistoryExpiryMinutes"];
+    }
+  }
+  export class TransformConfig {
+    Chains: {[key: string]: string[]};
+
+    static createFrom(source: any = {}) {
+      return new TransformConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.Chains = source["Chains"];
+    }
+  }
+  export class UsageConfig {
+    DailyBudget: number;
+    MonthlyBudget: number;
+    BudgetAction: string;
+    Prices: {[key: string]: usage.Price};
+
+    static createFrom(source: any = {}) {
+      return new UsageConfig(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.DailyBudget = source["DailyBudget"];
+      this.MonthlyBudget = source["MonthlyBudget"];
+      this.BudgetAction = source["BudgetAction"];
+      this.Prices = this.convertValues(source["Prices"], usage.Price, true);
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
   export class Config {
     Theme: string;
     AutoSave: boolean;
@@ -7,9 +349,21 @@ export namespace config {
     DefaultTodoCategory: string;
     MaxTodos: number;
     Language: string;
-    OpenAIAPIKey: string;
-    WorkflowyAPIKey: string;
     Debug: boolean;
+    OpenAIMaxRetries: number;
+    OpenAIRequestsPerMinute: number;
+    HTTP: HTTPConfig;
+    OCR: OCRConfig;
+    Chat: ChatConfig;
+    Search: SearchConfig;
+    ClipboardHistory: ClipboardHistoryConfig;
+    Transform: TransformConfig;
+    Sensitive: SensitiveConfig;
+    Usage: UsageConfig;
+    ClipboardBackend: string;
+    SecretBackend: string;
+    OpenAIAPIKeySecret: string;
+    WorkflowyAPIKeySecret: string;
 
     static createFrom(source: any = {}) {
       return new Config(source);
@@ -24,31 +378,623 @@ export namespace config {
       this.DefaultTodoCategory = source["DefaultTodoCategory"];
       this.MaxTodos = source["MaxTodos"];
       this.Language = source["Language"];
-      this.OpenAIAPIKey = source["OpenAIAPIKey"];
-      this.WorkflowyAPIKey = source["WorkflowyAPIKey"];
       this.Debug = source["Debug"];
+      this.OpenAIMaxRetries = source["OpenAIMaxRetries"];
+      this.OpenAIRequestsPerMinute = source["OpenAIRequestsPerMinute"];
+      this.HTTP = this.convertValues(source["HTTP"], HTTPConfig);
+      this.OCR = this.convertValues(source["OCR"], OCRConfig);
+      this.Chat = this.convertValues(source["Chat"], ChatConfig);
+      this.Search = this.convertValues(source["Search"], SearchConfig);
+      this.ClipboardHistory = this.convertValues(source["ClipboardHistory"], ClipboardHistoryConfig);
+      this.Transform = this.convertValues(source["Transform"], TransformConfig);
+      this.Sensitive = this.convertValues(source["Sensitive"], SensitiveConfig);
+      this.Usage = this.convertValues(source["Usage"], UsageConfig);
+      this.ClipboardBackend = source["ClipboardBackend"];
+      this.SecretBackend = source["SecretBackend"];
+      this.OpenAIAPIKeySecret = source["OpenAIAPIKeySecret"];
+      this.WorkflowyAPIKeySecret = source["WorkflowyAPIKeySecret"];
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+  export class FieldSource {
+    key: string;
+    value: string;
+    layer: string;
+    envVars: string[];
+    flag: string;
+
+    static createFrom(source: any = {}) {
+      return new FieldSource(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.key = source["key"];
+      this.value = source["value"];
+      this.layer = source["layer"];
+      this.envVars = source["envVars"];
+      this.flag = source["flag"];
+    }
+  }
+  export class HistoryEntry {
+    id: string;
+    // Go type: time
+    savedAt: any;
+    size: number;
+
+    static createFrom(source: any = {}) {
+      return new HistoryEntry(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.id = source["id"];
+      this.savedAt = this.convertValues(source["savedAt"], null);
+      this.size = source["size"];
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
     }
   }
 }
 
 export namespace models {
-  export class Todo {
+  export class ChatMessage {
+    id: string;
+    conversationId: string;
+    role: string;
+    content: string;
+    image?: number[];
+    imageFormat?: string;
+    // Go type: time
+    createdAt: any;
+
+    static createFrom(source: any = {}) {
+      return new ChatMessage(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.id = source["id"];
+      this.conversationId = source["conversationId"];
+      this.role = source["role"];
+      this.content = source["content"];
+      this.image = source["image"];
+      this.imageFormat = source["imageFormat"];
+      this.createdAt = this.convertValues(source["createdAt"], null);
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+  export class ClipboardEntry {
     id: string;
+    kind: string;
     text: string;
-    completed: boolean;
+    ocrText: string;
+    format: string;
+    thumbnail: number[];
+    hash: string;
+    size: number;
+    pinned: boolean;
+    sensitive: string[];
     // Go type: time
     createdAt: any;
+    // Go type: time
+    copiedAt: any;
 
     static createFrom(source: any = {}) {
-      return new Todo(source);
+      return new ClipboardEntry(source);
     }
 
     constructor(source: any = {}) {
       if ("string" === typeof source) source = JSON.parse(source);
       this.id = source["id"];
+      this.kind = source["kind"];
       this.text = source["text"];
-      this.completed = source["completed"];
+      this.ocrText = source["ocrText"];
+      this.format = source["format"];
+      this.thumbnail = source["thumbnail"];
+      this.hash = source["hash"];
+      this.size = source["size"];
+      this.pinned = source["pinned"];
+      this.sensitive = source["sensitive"];
       this.createdAt = this.convertValues(source["createdAt"], null);
+      this.copiedAt = this.convertValues(source["copiedAt"], null);
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+  export class Conversation {
+    id: string;
+    title: string;
+    // Go type: time
+    createdAt: any;
+    // Go type: time
+    updatedAt: any;
+
+    static createFrom(source: any = {}) {
+      return new Conversation(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.id = source["id"];
+      this.title = source["title"];
+      this.createdAt = this.convertValues(source["createdAt"], null);
+      this.updatedAt = this.convertValues(source["updatedAt"], null);
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+  export class OCRResult {
+    id: string;
+    mode: string;
+    text: string;
+    translation: string;
+    language: string;
+    // Go type: time
+    createdAt: any;
+
+    static createFrom(source: any = {}) {
+      return new OCRResult(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.id = source["id"];
+      this.mode = source["mode"];
+      this.text = source["text"];
+      this.translation = source["translation"];
+      this.language = source["language"];
+      this.createdAt = this.convertValues(source["createdAt"], null);
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+  export class SearchResult {
+    source: string;
+    sourceId: string;
+    text: string;
+    score: number;
+
+    static createFrom(source: any = {}) {
+      return new SearchResult(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.source = source["source"];
+      this.sourceId = source["sourceId"];
+      this.text = source["text"];
+      this.score = source["score"];
+    }
+  }
+  export class Todo {
+    id: string;
+    text: string;
+    completed: boolean;
+    // Go type: time
+    createdAt: any;
+
+    static createFrom(source: any = {}) {
+      return new Todo(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.id = source["id"];
+      this.text = source["text"];
+      this.completed = source["completed"];
+      this.createdAt = this.convertValues(source["createdAt"], null);
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+  export class ToolInvocation {
+    id: string;
+    conversationId: string;
+    name: string;
+    arguments: string;
+    result: string;
+    status: string;
+    // Go type: time
+    createdAt: any;
+
+    static createFrom(source: any = {}) {
+      return new ToolInvocation(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.id = source["id"];
+      this.conversationId = source["conversationId"];
+      this.name = source["name"];
+      this.arguments = source["arguments"];
+      this.result = source["result"];
+      this.status = source["status"];
+      this.createdAt = this.convertValues(source["createdAt"], null);
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+}
+
+export namespace ocr {
+  export class Code {
+    language: string;
+    code: string;
+
+    static createFrom(source: any = {}) {
+      return new Code(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.language = source["language"];
+      this.code = source["code"];
+    }
+  }
+  export class KeyValue {
+    key: string;
+    value: string;
+
+    static createFrom(source: any = {}) {
+      return new KeyValue(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.key = source["key"];
+      this.value = source["value"];
+    }
+  }
+  export class Table {
+    headers: string[];
+    rows: string[][];
+
+    static createFrom(source: any = {}) {
+      return new Table(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.headers = source["headers"];
+      this.rows = source["rows"];
+    }
+  }
+  export class StructuredResult {
+    mode: string;
+    text: string;
+    table?: Table;
+    csv?: string;
+    markdown?: string;
+    code?: Code;
+    fields?: KeyValue[];
+    json?: string;
+
+    static createFrom(source: any = {}) {
+      return new StructuredResult(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.mode = source["mode"];
+      this.text = source["text"];
+      this.table = this.convertValues(source["table"], Table);
+      this.csv = source["csv"];
+      this.markdown = source["markdown"];
+      this.code = this.convertValues(source["code"], Code);
+      this.fields = this.convertValues(source["fields"], KeyValue);
+      this.json = source["json"];
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+}
+
+export namespace services {
+  export class ImportResult {
+    manifest: bundle.Manifest;
+    secretsImported: number;
+    secretsSkipped: boolean;
+
+    static createFrom(source: any = {}) {
+      return new ImportResult(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.manifest = this.convertValues(source["manifest"], bundle.Manifest);
+      this.secretsImported = source["secretsImported"];
+      this.secretsSkipped = source["secretsSkipped"];
+    }
+
+    convertValues(a: any, classs: any, asMap: boolean = false): any {
+      if (!a) {
+        return a;
+      }
+      if (a.slice && a.map) {
+        return (a as any[]).map((elem) => this.convertValues(elem, classs));
+      } else if ("object" === typeof a) {
+        if (asMap) {
+          for (const key of Object.keys(a)) {
+            a[key] = new classs(a[key]);
+          }
+          return a;
+        }
+        return new classs(a);
+      }
+      return a;
+    }
+  }
+  export class SecretStatus {
+    name: string;
+    set: boolean;
+    masked: string;
+
+    static createFrom(source: any = {}) {
+      return new SecretStatus(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.name = source["name"];
+      this.set = source["set"];
+      this.masked = source["masked"];
+    }
+  }
+}
+
+export namespace tasks {
+  export class Task {
+    text: string;
+    assignee: string;
+    due: string;
+    priority: string;
+
+    static createFrom(source: any = {}) {
+      return new Task(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.text = source["text"];
+      this.assignee = source["assignee"];
+      this.due = source["due"];
+      this.priority = source["priority"];
+    }
+  }
+}
+
+export namespace transform {
+  export class Transformer {
+    name: string;
+    description: string;
+    steps?: string[];
+
+    static createFrom(source: any = {}) {
+      return new Transformer(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.name = source["name"];
+      this.description = source["description"];
+      this.steps = source["steps"];
+    }
+  }
+}
+
+export namespace usage {
+  export class Price {
+    prompt: number;
+    completion: number;
+
+    static createFrom(source: any = {}) {
+      return new Price(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.prompt = source["prompt"];
+      this.completion = source["completion"];
+    }
+  }
+  export class Total {
+    key: string;
+    requests: number;
+    failures: number;
+    promptTokens: number;
+    completionTokens: number;
+    cost: number;
+    avgLatencyMs: number;
+
+    static createFrom(source: any = {}) {
+      return new Total(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.key = source["key"];
+      this.requests = source["requests"];
+      this.failures = source["failures"];
+      this.promptTokens = source["promptTokens"];
+      this.completionTokens = source["completionTokens"];
+      this.cost = source["cost"];
+      this.avgLatencyMs = source["avgLatencyMs"];
+    }
+  }
+  export class Report {
+    // Go type: time
+    since: any;
+    total: Total;
+    byModel: Total[];
+    byFeature: Total[];
+    byDay: Total[];
+    todayCost: number;
+    monthCost: number;
+    dailyBudget: number;
+    monthlyBudget: number;
+
+    static createFrom(source: any = {}) {
+      return new Report(source);
+    }
+
+    constructor(source: any = {}) {
+      if ("string" === typeof source) source = JSON.parse(source);
+      this.since = this.convertValues(source["since"], null);
+      this.total = this.convertValues(source["total"], Total);
+      this.byModel = this.convertValues(source["byModel"], Total);
+      this.byFeature = this.convertValues(source["byFeature"], Total);
+      this.byDay = this.convertValues(source["byDay"], Total);
+      this.todayCost = source["todayCost"];
+      this.monthCost = source["monthCost"];
+      this.dailyBudget = source["dailyBudget"];
+      this.monthlyBudget = source["monthlyBudget"];
     }
 
     convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -0,0 +1,209 @@
+// Package bundle reads and writes settings-and-data bundles: a zip file
+// holding config.toml, a snapshot of the database, optionally the
+// passphrase-encrypted secrets, and a manifest describing their versions.
+package bundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FormatVersion is the bundle layout written by this version
+const FormatVersion = 1
+
+// Files inside a bundle
+const (
+	ManifestFile = "manifest.json"
+	ConfigFile   = "config.toml"
+	DatabaseFile = "todos.db"
+	SecretsFile  = "secrets.enc"
+)
+
+// Manifest describes the contents of a bundle
+type Manifest struct {
+	FormatVersion         int       `json:"formatVersion"`
+	AppVersion            string    `json:"appVersion"`
+	ConfigSchemaVersion   int       `json:"configSchemaVersion"`
+	DatabaseSchemaVersion int       `json:"databaseSchemaVersion"`
+	CreatedAt             time.Time `json:"createdAt"`
+	IncludesSecrets       bool      `json:"includesSecrets"`
+}
+
+// Validate checks that the bundle can be imported by a version supporting
+// the given config and database schema versions
+func (m Manifest) Validate(configSchemaVersion, databaseSchemaVersion int) error {
+	if m.FormatVersion < 1 || m.FormatVersion > FormatVersion {
+		return fmt.Errorf("unsupported bundle format version %d", m.FormatVersion)
+	}
+	if m.ConfigSchemaVersion > configSchemaVersion {
+		return fmt.Errorf("bundle config schema version %d is newer than supported version %d (created by app version %s)",
+			m.ConfigSchemaVersion, configSchemaVersion, m.AppVersion)
+	}
+	if m.DatabaseSchemaVersion > databaseSchemaVersion {
+		return fmt.Errorf("bundle database schema version %d is newer than supported version %d (created by app version %s)",
+			m.DatabaseSchemaVersion, databaseSchemaVersion, m.AppVersion)
+	}
+	return nil
+}
+
+// Writer creates a bundle file
+type Writer struct {
+	path    string
+	tmpPath string
+	file    *os.File
+	zip     *zip.Writer
+}
+
+// Create starts writing a bundle to path. The file only appears at path
+// once Close succeeds.
+func Create(path string) (*Writer, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle: %w", err)
+	}
+	return &Writer{
+		path:    path,
+		tmpPath: file.Name(),
+		file:    file,
+		zip:     zip.NewWriter(file),
+	}, nil
+}
+
+// AddBytes adds a file with the given contents to the bundle
+func (w *Writer) AddBytes(name string, data []byte) error {
+	entry, err := w.zip.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := entry.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+// AddFile adds the file at srcPath to the bundle under name
+func (w *Writer) AddFile(name, srcPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", srcPath, err)
+	}
+	defer src.Close()
+
+	entry, err := w.zip.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := io.Copy(entry, src); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+// Close writes the manifest and moves the finished bundle into place
+func (w *Writer) Close(manifest Manifest) error {
+	defer os.Remove(w.tmpPath)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := w.AddBytes(ManifestFile, data); err != nil {
+		w.file.Close()
+		return err
+	}
+
+	if err := w.zip.Close(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to sync bundle: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close bundle: %w", err)
+	}
+	return os.Rename(w.tmpPath, w.path)
+}
+
+// Abort discards a bundle that will not be finished
+func (w *Writer) Abort() {
+	w.file.Close()
+	os.Remove(w.tmpPath)
+}
+
+// Reader reads a bundle file
+type Reader struct {
+	zip      *zip.ReadCloser
+	Manifest Manifest
+}
+
+// Open opens a bundle and reads its manifest
+func Open(path string) (*Reader, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+
+	r := &Reader{zip: zr}
+	data, err := r.ReadFile(ManifestFile)
+	if err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("bundle has no manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.Manifest); err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return r, nil
+}
+
+// Has reports whether the bundle contains the named file
+func (r *Reader) Has(name string) bool {
+	for _, file := range r.zip.File {
+		if file.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadFile returns the contents of the named file
+func (r *Reader) ReadFile(name string) ([]byte, error) {
+	src, err := r.zip.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return io.ReadAll(src)
+}
+
+// ExtractFile writes the named file to destPath
+func (r *Reader) ExtractFile(name, destPath string) error {
+	src, err := r.zip.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dest, src); err != nil {
+		dest.Close()
+		return err
+	}
+	return dest.Close()
+}
+
+// Close closes the bundle
+func (r *Reader) Close() error {
+	return r.zip.Close()
+}
@@ -0,0 +1,118 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBundle_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "backup.zip")
+
+	dbPath := filepath.Join(dir, "todos.db")
+	if err := os.WriteFile(dbPath, []byte("database bytes"), 0644); err != nil {
+		t.Fatalf("Failed to write db file: %v", err)
+	}
+
+	w, err := Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	if err := w.AddBytes(ConfigFile, []byte("theme = \"dark\"\n")); err != nil {
+		t.Fatalf("Failed to add config: %v", err)
+	}
+	if err := w.AddFile(DatabaseFile, dbPath); err != nil {
+		t.Fatalf("Failed to add database: %v", err)
+	}
+
+	// Nothing is visible at the destination until Close
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected bundle to not exist before Close, got %v", err)
+	}
+
+	manifest := Manifest{
+		FormatVersion:         FormatVersion,
+		AppVersion:            "1.0.0",
+		ConfigSchemaVersion:   1,
+		DatabaseSchemaVersion: 1,
+		CreatedAt:             time.Now().UTC().Truncate(time.Second),
+	}
+	if err := w.Close(manifest); err != nil {
+		t.Fatalf("Failed to close bundle: %v", err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open bundle: %v", err)
+	}
+	defer r.Close()
+
+	if r.Manifest != manifest {
+		t.Errorf("Expected manifest %+v, got %+v", manifest, r.Manifest)
+	}
+	if !r.Has(ConfigFile) || !r.Has(DatabaseFile) {
+		t.Error("Expected config and database in bundle")
+	}
+	if r.Has(SecretsFile) {
+		t.Error("Expected no secrets in bundle")
+	}
+
+	data, err := r.ReadFile(ConfigFile)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if string(data) != "theme = \"dark\"\n" {
+		t.Errorf("Unexpected config contents: %q", data)
+	}
+
+	extracted := filepath.Join(dir, "extracted.db")
+	if err := r.ExtractFile(DatabaseFile, extracted); err != nil {
+		t.Fatalf("Failed to extract database: %v", err)
+	}
+	if data, _ := os.ReadFile(extracted); string(data) != "database bytes" {
+		t.Errorf("Unexpected database contents: %q", data)
+	}
+}
+
+func TestManifest_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest Manifest
+		wantErr  bool
+	}{
+		{"current", Manifest{FormatVersion: 1, ConfigSchemaVersion: 1, DatabaseSchemaVersion: 1}, false},
+		{"older schemas", Manifest{FormatVersion: 1, ConfigSchemaVersion: 0, DatabaseSchemaVersion: 0}, false},
+		{"unknown format", Manifest{FormatVersion: 2, ConfigSchemaVersion: 1, DatabaseSchemaVersion: 1}, true},
+		{"missing format", Manifest{ConfigSchemaVersion: 1, DatabaseSchemaVersion: 1}, true},
+		{"newer config", Manifest{FormatVersion: 1, ConfigSchemaVersion: 2, DatabaseSchemaVersion: 1}, true},
+		{"newer database", Manifest{FormatVersion: 1, ConfigSchemaVersion: 1, DatabaseSchemaVersion: 2}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.manifest.Validate(1, 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpen_RequiresManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.zip")
+
+	w, err := Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	w.Abort()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected aborted bundle to not exist, got %v", err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Expected error opening a missing bundle")
+	}
+}
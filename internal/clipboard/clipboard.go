@@ -1,73 +1,187 @@
 package clipboard
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"image"
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
-	"io"
+	"sync"
 
 	"golang.design/x/clipboard"
 )
 
 // Clipboard interface defines methods for clipboard operations
 type Clipboard interface {
+	// ReadImage returns the clipboard image converted to PNG, and its
+	// format, "png"
 	ReadImage() ([]byte, string, error)
+	// ReadText returns the clipboard text
+	ReadText() (string, error)
+	// WriteText replaces the clipboard contents with text
+	WriteText(text string) error
+	// WriteImage replaces the clipboard contents with an image in format;
+	// images that are not PNG are converted to PNG
+	WriteImage(data []byte, format string) error
+	// Watch reports every new text or image put on the clipboard until ctx
+	// is done, when the channel is closed
+	Watch(ctx context.Context) (<-chan Change, error)
+}
+
+// Kinds of clipboard content
+const (
+	KindText  = "text"
+	KindImage = "image"
+)
+
+// Change is new clipboard content reported by Watch. Text is set for
+// KindText; Image and Format are set for KindImage.
+type Change struct {
+	Kind   string
+	Text   string
+	Image  []byte
+	Format string
+}
+
+// Supported clipboard backends
+const (
+	BackendSystem = "system"
+	BackendFake   = "fake"
+)
+
+// Open creates the clipboard for the given backend. An empty backend
+// selects the system clipboard; the fake backend is an in-memory clipboard
+// for running without a desktop.
+func Open(backend string) (Clipboard, error) {
+	switch backend {
+	case "", BackendSystem:
+		return NewClipboard(), nil
+	case BackendFake:
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("unsupported clipboard backend %q", backend)
+	}
 }
 
 // WindowsClipboard implements Clipboard interface for Windows
-type WindowsClipboard struct{}
+type WindowsClipboard struct {
+	initOnce sync.Once
+	initErr  error
+}
 
 // NewClipboard creates a new clipboard instance
 func NewClipboard() Clipboard {
 	return &WindowsClipboard{}
 }
 
-// ReadImage reads image data from the Windows clipboard
+// init initializes the clipboard package on first use. Reading or writing
+// after a failed initialization may panic, so every method checks it first.
+func (w *WindowsClipboard) init() error {
+	w.initOnce.Do(func() {
+		if err := clipboard.Init(); err != nil {
+			w.initErr = fmt.Errorf("clipboard is not available: %w", err)
+		}
+	})
+	return w.initErr
+}
+
+// ReadImage reads image data from the Windows clipboard and normalizes it to PNG
 func (w *WindowsClipboard) ReadImage() ([]byte, string, error) {
+	if err := w.init(); err != nil {
+		return nil, "", err
+	}
+
 	// Read clipboard content
 	clipboardData := clipboard.Read(clipboard.FmtImage)
 	if len(clipboardData) == 0 {
 		return nil, "", fmt.Errorf("no image found in clipboard")
 	}
 
-	// Detect image format
-	format := detectFormat(clipboardData)
-	if format == "" {
-		return nil, "", fmt.Errorf("unsupported image format")
+	pngData, err := Normalize(clipboardData)
+	if err != nil {
+		return nil, "", err
 	}
-
-	return clipboardData, format, nil
+	return pngData, "png", nil
 }
 
-// detectFormat detects the image format from the data
-func detectFormat(data []byte) string {
-	if len(data) < 8 {
-		return ""
+// ReadText reads text from the Windows clipboard
+func (w *WindowsClipboard) ReadText() (string, error) {
+	if err := w.init(); err != nil {
+		return "", err
 	}
 
-	// Check for PNG signature
-	if len(data) >= 8 && data[0] == 0x89 && data[1] == 0x50 && data[2] == 0x4E && data[3] == 0x47 {
-		return "png"
+	data := clipboard.Read(clipboard.FmtText)
+	if len(data) == 0 {
+		return "", fmt.Errorf("no text found in clipboard")
 	}
+	return string(data), nil
+}
 
-	// Check for JPEG signature
-	if len(data) >= 2 && data[0] == 0xFF && data[1] == 0xD8 {
-		return "jpeg"
+// WriteText puts text on the Windows clipboard
+func (w *WindowsClipboard) WriteText(text string) error {
+	if err := w.init(); err != nil {
+		return err
+	}
+	if text == "" {
+		return fmt.Errorf("no text to copy")
 	}
 
-	// Check for BMP signature
-	if len(data) >= 2 && data[0] == 0x42 && data[1] == 0x4D {
-		return "bmp"
+	clipboard.Write(clipboard.FmtText, []byte(text))
+	return nil
+}
+
+// WriteImage puts an image on the Windows clipboard
+func (w *WindowsClipboard) WriteImage(data []byte, format string) error {
+	if err := w.init(); err != nil {
+		return err
 	}
 
-	// Try to decode as image to validate
-	_, format, err := image.DecodeConfig(io.Reader(bytes.NewReader(data)))
+	if len(data) == 0 {
+		return fmt.Errorf("no image to copy")
+	}
+	pngData, err := Normalize(data)
 	if err != nil {
-		return ""
+		return fmt.Errorf("failed to copy %s image: %w", format, err)
+	}
+	clipboard.Write(clipboard.FmtImage, pngData)
+	return nil
+}
+
+// Watch reports text and images put on the Windows clipboard
+func (w *WindowsClipboard) Watch(ctx context.Context) (<-chan Change, error) {
+	if err := w.init(); err != nil {
+		return nil, err
 	}
 
-	return format
+	texts := clipboard.Watch(ctx, clipboard.FmtText)
+	images := clipboard.Watch(ctx, clipboard.FmtImage)
+	changes := make(chan Change)
+	go func() {
+		defer close(changes)
+		for texts != nil || images != nil {
+			var change Change
+			select {
+			case data, ok := <-texts:
+				if !ok {
+					texts = nil
+					continue
+				}
+				change = Change{Kind: KindText, Text: string(data)}
+			case data, ok := <-images:
+				if !ok {
+					images = nil
+					continue
+				}
+				pngData, err := Normalize(data)
+				if err != nil {
+					continue
+				}
+				change = Change{Kind: KindImage, Image: pngData, Format: "png"}
+			}
+
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
 }
@@ -0,0 +1,214 @@
+package clipboard
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Call records a method called on a Fake with its arguments
+type Call struct {
+	Method string
+	Text   string
+	Image  []byte
+	Format string
+}
+
+// Fake is an in-memory Clipboard for tests and machines without a desktop.
+// Like a real clipboard it holds either text or an image: putting one on it
+// removes the other. Every method call is recorded, and watchers are told
+// about each change, whether it is scripted with SetText and SetImage or
+// made through WriteText and WriteImage.
+type Fake struct {
+	mu    sync.Mutex
+	text  string
+	image []byte
+	calls []Call
+	errs  map[string]error
+
+	// notifyMu is held while changes are sent, so that a watcher's channel
+	// is not closed during a send
+	notifyMu sync.Mutex
+	watchers []*fakeWatcher
+}
+
+// fakeWatcher is a channel returned by Fake.Watch
+type fakeWatcher struct {
+	ctx     context.Context
+	changes chan Change
+}
+
+// NewFake creates an empty Fake clipboard
+func NewFake() *Fake {
+	return &Fake{errs: make(map[string]error)}
+}
+
+// SetText puts text on the clipboard as another application would, without
+// recording a call
+func (f *Fake) SetText(text string) {
+	f.mu.Lock()
+	f.text, f.image = text, nil
+	f.mu.Unlock()
+	f.notify(Change{Kind: KindText, Text: text})
+}
+
+// SetImage puts an image on the clipboard as another application would,
+// without recording a call. Like the system clipboard, ReadImage and
+// watchers get it converted to PNG; data that cannot be converted makes
+// ReadImage fail and is not reported to watchers.
+func (f *Fake) SetImage(data []byte) {
+	f.mu.Lock()
+	f.text, f.image = "", data
+	f.mu.Unlock()
+	if pngData, err := Normalize(data); err == nil {
+		f.notify(Change{Kind: KindImage, Image: pngData, Format: "png"})
+	}
+}
+
+// Clear empties the clipboard
+func (f *Fake) Clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.text, f.image = "", nil
+}
+
+// FailWith makes calls of method, such as "ReadImage", return err; a nil
+// err makes them succeed again
+func (f *Fake) FailWith(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// Calls returns the calls made so far, oldest first
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// record adds a call to the log and returns the error scripted for it
+func (f *Fake) record(call Call) error {
+	f.calls = append(f.calls, call)
+	return f.errs[call.Method]
+}
+
+// ReadImage returns the image on the clipboard converted to PNG
+func (f *Fake) ReadImage() ([]byte, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record(Call{Method: "ReadImage"}); err != nil {
+		return nil, "", err
+	}
+	if len(f.image) == 0 {
+		return nil, "", fmt.Errorf("no image found in clipboard")
+	}
+	pngData, err := Normalize(f.image)
+	if err != nil {
+		return nil, "", err
+	}
+	return pngData, "png", nil
+}
+
+// ReadText returns the text on the clipboard
+func (f *Fake) ReadText() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record(Call{Method: "ReadText"}); err != nil {
+		return "", err
+	}
+	if f.text == "" {
+		return "", fmt.Errorf("no text found in clipboard")
+	}
+	return f.text, nil
+}
+
+// WriteText puts text on the clipboard
+func (f *Fake) WriteText(text string) error {
+	f.mu.Lock()
+	err := f.record(Call{Method: "WriteText", Text: text})
+	if err == nil && text == "" {
+		err = fmt.Errorf("no text to copy")
+	}
+	if err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	f.text, f.image = text, nil
+	f.mu.Unlock()
+
+	f.notify(Change{Kind: KindText, Text: text})
+	return nil
+}
+
+// WriteImage puts an image on the clipboard, converted to PNG like the
+// system clipboard does
+func (f *Fake) WriteImage(data []byte, format string) error {
+	f.mu.Lock()
+	err := f.record(Call{Method: "WriteImage", Image: data, Format: format})
+	if err == nil && len(data) == 0 {
+		err = fmt.Errorf("no image to copy")
+	}
+	var pngData []byte
+	if err == nil {
+		if pngData, err = Normalize(data); err != nil {
+			err = fmt.Errorf("failed to copy %s image: %w", format, err)
+		}
+	}
+	if err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	f.text, f.image = "", pngData
+	f.mu.Unlock()
+
+	f.notify(Change{Kind: KindImage, Image: pngData, Format: "png"})
+	return nil
+}
+
+// Watch reports every later change of the clipboard. Changes are delivered
+// before the call that made them returns, unless ctx is done, so a watcher
+// must not change the clipboard itself while handling one.
+func (f *Fake) Watch(ctx context.Context) (<-chan Change, error) {
+	f.mu.Lock()
+	err := f.record(Call{Method: "Watch"})
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := &fakeWatcher{ctx: ctx, changes: make(chan Change)}
+	f.notifyMu.Lock()
+	f.watchers = append(f.watchers, watcher)
+	f.notifyMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.notifyMu.Lock()
+		defer f.notifyMu.Unlock()
+		for i, w := range f.watchers {
+			if w == watcher {
+				f.watchers = append(f.watchers[:i], f.watchers[i+1:]...)
+				break
+			}
+		}
+		close(watcher.changes)
+	}()
+	return watcher.changes, nil
+}
+
+// notify sends change to every watcher
+func (f *Fake) notify(change Change) {
+	f.notifyMu.Lock()
+	defer f.notifyMu.Unlock()
+	for _, watcher := range f.watchers {
+		select {
+		case watcher.changes <- change:
+		case <-watcher.ctx.Done():
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func TestFake_ScriptedContents(t *testing.T) {
+	fake := NewFake()
+	if _, _, err := fake.ReadImage(); err == nil {
+		t.Error("Expected an error reading an image from an empty clipboard")
+	}
+
+	fake.SetText("hello")
+	if text, err := fake.ReadText(); err != nil || text != "hello" {
+		t.Errorf("Expected 'hello', got %q and %v", text, err)
+	}
+
+	fake.SetImage([]byte("\x89PNG\r\n\x1a\n"))
+	data, format, err := fake.ReadImage()
+	if err != nil || format != "png" || len(data) != 8 {
+		t.Errorf("Expected the PNG with its format detected, got %q and %v", format, err)
+	}
+	if _, err := fake.ReadText(); err == nil {
+		t.Error("Expected the image to replace the text")
+	}
+
+	fake.Clear()
+	if _, _, err := fake.ReadImage(); err == nil {
+		t.Error("Expected the clipboard to be empty after Clear")
+	}
+}
+
+func TestFake_WriteAndRecord(t *testing.T) {
+	fake := NewFake()
+
+	var jpegData bytes.Buffer
+	jpeg.Encode(&jpegData, image.NewGray(image.Rect(0, 0, 2, 2)), nil)
+	if err := fake.WriteImage(jpegData.Bytes(), "jpeg"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, format, _ := fake.ReadImage(); format != "png" {
+		t.Errorf("Expected the image stored as PNG, got %q", format)
+	}
+
+	if err := fake.WriteText("copied"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := fake.WriteText(""); err == nil {
+		t.Error("Expected an error writing empty text")
+	}
+
+	calls := fake.Calls()
+	want := []string{"WriteImage", "ReadImage", "WriteText", "WriteText"}
+	if len(calls) != len(want) {
+		t.Fatalf("Expected %d calls, got %+v", len(want), calls)
+	}
+	for i, method := range want {
+		if calls[i].Method != method {
+			t.Errorf("Expected call %d to be %s, got %s", i, method, calls[i].Method)
+		}
+	}
+	if calls[0].Format != "jpeg" || calls[2].Text != "copied" {
+		t.Errorf("Expected call arguments recorded, got %+v", calls)
+	}
+}
+
+func TestFake_FailWith(t *testing.T) {
+	fake := NewFake()
+	fake.SetText("hello")
+	failure := errors.New("clipboard is locked")
+
+	fake.FailWith("ReadText", failure)
+	if _, err := fake.ReadText(); !errors.Is(err, failure) {
+		t.Errorf("Expected the scripted error, got %v", err)
+	}
+
+	fake.FailWith("ReadText", nil)
+	if _, err := fake.ReadText(); err != nil {
+		t.Errorf("Expected no error once cleared, got %v", err)
+	}
+}
+
+func TestFake_Watch(t *testing.T) {
+	fake := NewFake()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	changes, err := fake.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	received := make(chan Change, 2)
+	done := make(chan struct{})
+	go func() {
+		for change := range changes {
+			received <- change
+		}
+		close(done)
+	}()
+
+	fake.SetText("first")
+	fake.WriteText("second")
+
+	if change := <-received; change.Kind != KindText || change.Text != "first" {
+		t.Errorf("Expected the scripted text, got %+v", change)
+	}
+	if change := <-received; change.Text != "second" {
+		t.Errorf("Expected the written text, got %+v", change)
+	}
+
+	cancel()
+	<-done
+	// Changes after the watch ended are not sent
+	fake.SetText("third")
+}
+
+func TestOpen(t *testing.T) {
+	if clip, err := Open(BackendFake); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	} else if _, ok := clip.(*Fake); !ok {
+		t.Errorf("Expected a Fake, got %T", clip)
+	}
+	if _, err := Open("pasteboard"); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+}
@@ -0,0 +1,325 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"math/bits"
+
+	"golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrUnsupportedFormat is returned for clipboard data that is not an image
+// in one of the formats Decode reads
+var ErrUnsupportedFormat = errors.New("unsupported clipboard image format")
+
+// DIB compression methods
+const (
+	biRGB       = 0
+	biBitfields = 3
+	biJPEG      = 4
+	biPNG       = 5
+)
+
+// bmpFileHeaderLen is the size of the header a BMP file has before its DIB
+const bmpFileHeaderLen = 14
+
+// maxPixels is the largest image decoded, 8192 by 8192 pixels, in any
+// format, so that a header claiming a huge size cannot make the decoder
+// allocate for it
+const maxPixels = 8192 * 8192
+
+// Decode decodes clipboard image data in any supported format: PNG, JPEG,
+// GIF, BMP, TIFF, WebP, or a DIB, which is a BMP without its file header as
+// Windows puts it on the clipboard. It returns the image and the name of its
+// format. Data in another format is an ErrUnsupportedFormat error that names
+// the format when it is recognized. Images over maxPixels are rejected from
+// their headers, before they are decoded.
+func Decode(data []byte) (image.Image, string, error) {
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("no image data")
+	}
+
+	format := detectFormat(data)
+	if format == "" {
+		if name := unsupportedFormat(data); name != "" {
+			return nil, "", fmt.Errorf("%w: %s images cannot be read", ErrUnsupportedFormat, name)
+		}
+		return nil, "", fmt.Errorf("%w: data starting with % x is not a known image format",
+			ErrUnsupportedFormat, data[:min(8, len(data))])
+	}
+
+	var img image.Image
+	var err error
+	if format == "dib" {
+		img, err = decodeDIB(data)
+	} else {
+		img, err = decodeChecked(data)
+	}
+	if errors.Is(err, ErrUnsupportedFormat) {
+		return nil, "", err
+	}
+	if errors.Is(err, bmp.ErrUnsupported) {
+		return nil, "", fmt.Errorf("%w: this kind of %s image cannot be read", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
+	return img, format, nil
+}
+
+// Normalize converts clipboard image data in any format Decode reads to
+// PNG. PNG data is returned unchanged.
+func Normalize(data []byte) ([]byte, error) {
+	if detectFormat(data) == "png" {
+		return data, nil
+	}
+
+	img, _, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image as PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// detectFormat returns the format of image data from its signature, or ""
+// if it is not a supported image
+func detectFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	case bytes.HasPrefix(data, []byte("BM")):
+		return "bmp"
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return "tiff"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	case isDIB(data):
+		return "dib"
+	}
+	return ""
+}
+
+// unsupportedFormat names well-known image formats that cannot be decoded,
+// so that they get a clearer error than unknown data
+func unsupportedFormat(data []byte) string {
+	switch {
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		switch string(data[8:12]) {
+		case "heic", "heix", "hevc", "mif1", "msf1":
+			return "HEIC"
+		case "avif", "avis":
+			return "AVIF"
+		}
+	case bytes.HasPrefix(data, []byte{0, 0, 1, 0}):
+		return "ICO"
+	case bytes.HasPrefix(data, []byte("8BPS")):
+		return "PSD"
+	case bytes.HasPrefix(data, []byte("%PDF")):
+		return "PDF"
+	}
+	return ""
+}
+
+// isDIB reports whether data starts with a BITMAPINFOHEADER or one of its
+// larger versions
+func isDIB(data []byte) bool {
+	if len(data) < 40 {
+		return false
+	}
+	switch binary.LittleEndian.Uint32(data[0:4]) {
+	case 40, 52, 56, 108, 124:
+	default:
+		return false
+	}
+	planes := binary.LittleEndian.Uint16(data[12:14])
+	switch binary.LittleEndian.Uint16(data[14:16]) {
+	case 1, 2, 4, 8, 16, 24, 32:
+		return planes == 1
+	}
+	return false
+}
+
+// decodeDIB decodes a DIB. Uncompressed ones are decoded as a BMP file;
+// 16 and 32 bit images with colour masks, and embedded JPEG or PNG images,
+// are decoded here.
+func decodeDIB(data []byte) (image.Image, error) {
+	headerLen := int(binary.LittleEndian.Uint32(data[0:4]))
+	bitCount := int(binary.LittleEndian.Uint16(data[14:16]))
+	compression := binary.LittleEndian.Uint32(data[16:20])
+	if headerLen > len(data) {
+		return nil, fmt.Errorf("DIB header is truncated")
+	}
+
+	paletteLen := 0
+	if bitCount <= 8 {
+		colors := int(binary.LittleEndian.Uint32(data[32:36]))
+		if colors == 0 {
+			colors = 1 << bitCount
+		}
+		paletteLen = 4 * colors
+	}
+
+	switch compression {
+	case biRGB:
+		if _, _, err := dibSize(data); err != nil {
+			return nil, err
+		}
+		return decodeDIBAsBMP(data, bmpFileHeaderLen+headerLen+paletteLen)
+	case biBitfields:
+		return decodeBitfields(data, headerLen)
+	case biJPEG, biPNG:
+		start := headerLen + paletteLen
+		if start > len(data) {
+			return nil, fmt.Errorf("DIB is truncated")
+		}
+		return decodeChecked(data[start:])
+	default:
+		return nil, fmt.Errorf("%w: DIB with compression method %d", ErrUnsupportedFormat, compression)
+	}
+}
+
+// decodeChecked decodes an image in a registered format once its header
+// shows that it is within maxPixels
+func decodeChecked(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("image has invalid size %dx%d", config.Width, config.Height)
+	}
+	if config.Width > maxPixels/config.Height {
+		return nil, fmt.Errorf("image size %dx%d is too large", config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// dibSize returns the width and height in the header of a DIB, the height
+// negative for top-down images, and fails for sizes that are not positive
+// or over maxPixels
+func dibSize(data []byte) (int, int, error) {
+	width := int(int32(binary.LittleEndian.Uint32(data[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(data[8:12])))
+	rows := height
+	if rows < 0 {
+		rows = -rows
+	}
+	if width <= 0 || rows <= 0 {
+		return 0, 0, fmt.Errorf("DIB has invalid size %dx%d", width, rows)
+	}
+	if width > maxPixels/rows {
+		return 0, 0, fmt.Errorf("DIB size %dx%d is too large", width, rows)
+	}
+	return width, height, nil
+}
+
+// decodeDIBAsBMP adds the BMP file header to a DIB whose pixels start at
+// pixelOffset in the file, and decodes the result
+func decodeDIBAsBMP(data []byte, pixelOffset int) (image.Image, error) {
+	file := make([]byte, bmpFileHeaderLen, bmpFileHeaderLen+len(data))
+	copy(file, "BM")
+	binary.LittleEndian.PutUint32(file[2:6], uint32(bmpFileHeaderLen+len(data)))
+	binary.LittleEndian.PutUint32(file[10:14], uint32(pixelOffset))
+	return bmp.Decode(bytes.NewReader(append(file, data...)))
+}
+
+// decodeBitfields decodes a 16 or 32 bit DIB whose channels are given by
+// bit masks. With a 40 byte header the red, green and blue masks follow the
+// header; larger headers contain them, and from 56 bytes an alpha mask too.
+// An alpha channel that is zero everywhere is ignored, as many applications
+// leave it unset.
+func decodeBitfields(data []byte, headerLen int) (image.Image, error) {
+	width, height, err := dibSize(data)
+	if err != nil {
+		return nil, err
+	}
+	bitCount := int(binary.LittleEndian.Uint16(data[14:16]))
+	if bitCount != 16 && bitCount != 32 {
+		return nil, fmt.Errorf("%w: DIB with colour masks and %d bits per pixel", ErrUnsupportedFormat, bitCount)
+	}
+
+	pixelOffset := headerLen
+	if headerLen == 40 {
+		pixelOffset += 12
+	}
+	if len(data) < 52 || len(data) < pixelOffset {
+		return nil, fmt.Errorf("DIB colour masks are truncated")
+	}
+	masks := [4]uint32{
+		binary.LittleEndian.Uint32(data[40:44]),
+		binary.LittleEndian.Uint32(data[44:48]),
+		binary.LittleEndian.Uint32(data[48:52]),
+	}
+	if headerLen >= 56 {
+		masks[3] = binary.LittleEndian.Uint32(data[52:56])
+	}
+
+	topDown := height < 0
+	if topDown {
+		height = -height
+	}
+	stride := (width*bitCount + 31) / 32 * 4
+	if height > (len(data)-pixelOffset)/stride {
+		return nil, fmt.Errorf("DIB pixel data is truncated")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := pixelOffset + y*stride
+		if !topDown {
+			row = pixelOffset + (height-1-y)*stride
+		}
+		for x := 0; x < width; x++ {
+			var value uint32
+			if bitCount == 16 {
+				value = uint32(binary.LittleEndian.Uint16(data[row+2*x:]))
+			} else {
+				value = binary.LittleEndian.Uint32(data[row+4*x:])
+			}
+			pixel := color.NRGBA{
+				R: channel(value, masks[0]),
+				G: channel(value, masks[1]),
+				B: channel(value, masks[2]),
+				A: channel(value, masks[3]),
+			}
+			hasAlpha = hasAlpha || pixel.A != 0
+			img.SetNRGBA(x, y, pixel)
+		}
+	}
+
+	if !hasAlpha {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xFF
+		}
+	}
+	return img, nil
+}
+
+// channel extracts the bits of value selected by mask, scaled to 8 bits
+func channel(value, mask uint32) uint8 {
+	if mask == 0 {
+		return 0
+	}
+	shift := bits.TrailingZeros32(mask)
+	maxValue := uint64(mask >> shift)
+	return uint8(uint64((value&mask)>>shift) * 255 / maxValue)
+}
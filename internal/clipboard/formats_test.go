@@ -0,0 +1,250 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pixels are the colours of the quadrants of the fixtures in testdata, row
+// by row. Most fixtures are 2x2; the JPEG is 16x16 so that its colours
+// survive chroma subsampling.
+var pixels = []color.NRGBA{
+	{R: 255, A: 255}, {G: 255, A: 255},
+	{B: 255, A: 255}, {R: 255, G: 255, B: 255, A: 255},
+}
+
+// readFixture returns the contents of a file in testdata
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return data
+}
+
+// near reports whether two colour channels differ by at most tolerance
+func near(a, b uint8, tolerance int) bool {
+	d := int(a) - int(b)
+	return d >= -tolerance && d <= tolerance
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		format    string
+		tolerance int
+	}{
+		{"pixels.png", "png", 0},
+		{"pixels.jpg", "jpeg", 24},
+		{"pixels.gif", "gif", 0},
+		{"pixels.bmp", "bmp", 0},
+		{"pixels.tiff", "tiff", 0},
+		{"rgb24.dib", "dib", 0},
+		{"bitfields32.dib", "dib", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			img, format, err := Decode(readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if format != tt.format {
+				t.Errorf("Expected format %q, got %q", tt.format, format)
+			}
+			width, height := img.Bounds().Dx(), img.Bounds().Dy()
+			if width != height || width%2 != 0 {
+				t.Fatalf("Expected a square image with quadrants, got %v", img.Bounds())
+			}
+			for i, want := range pixels {
+				x, y := (2*(i%2)+1)*width/4, (2*(i/2)+1)*height/4
+				got := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				if !near(got.R, want.R, tt.tolerance) || !near(got.G, want.G, tt.tolerance) ||
+					!near(got.B, want.B, tt.tolerance) || got.A != want.A {
+					t.Errorf("Expected pixel (%d,%d) to be %v, got %v", x, y, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDecode_WebP(t *testing.T) {
+	img, format, err := Decode(readFixture(t, "pixel.webp"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if format != "webp" || img.Bounds().Dx() != 1 || img.Bounds().Dy() != 1 {
+		t.Fatalf("Expected a 1x1 webp image, got %q %v", format, img.Bounds())
+	}
+	got := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA)
+	if !near(got.R, 128, 8) || !near(got.G, 128, 8) || !near(got.B, 128, 8) {
+		t.Errorf("Expected a gray pixel, got %v", got)
+	}
+}
+
+func TestDecode_DIBAlpha(t *testing.T) {
+	img, _, err := Decode(readFixture(t, "v5alpha.dib"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i, want := range pixels {
+		got := color.NRGBAModel.Convert(img.At(i%2, i/2)).(color.NRGBA)
+		want.A = 0x80
+		if got != want {
+			t.Errorf("Expected pixel (%d,%d) to be %v, got %v", i%2, i/2, want, got)
+		}
+	}
+}
+
+// dibHeader returns a BITMAPINFOHEADER for the given size, bits per pixel
+// and compression, followed by colour masks and a few bytes of pixels
+func dibHeader(width, height int32, bitCount uint16, compression uint32) []byte {
+	data := make([]byte, 40+12+16)
+	binary.LittleEndian.PutUint32(data[0:4], 40)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(width))
+	binary.LittleEndian.PutUint32(data[8:12], uint32(height))
+	binary.LittleEndian.PutUint16(data[12:14], 1)
+	binary.LittleEndian.PutUint16(data[14:16], bitCount)
+	binary.LittleEndian.PutUint32(data[16:20], compression)
+	binary.LittleEndian.PutUint32(data[40:44], 0x00FF0000)
+	binary.LittleEndian.PutUint32(data[44:48], 0x0000FF00)
+	binary.LittleEndian.PutUint32(data[48:52], 0x000000FF)
+	return data
+}
+
+func TestDecode_HugeDIB(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"bitfields overflowing the size check", dibHeader(math.MaxInt32, math.MaxInt32, 32, biBitfields)},
+		{"bitfields top-down", dibHeader(1<<20, -(1 << 20), 32, biBitfields)},
+		{"bitfields over the pixel limit", dibHeader(maxPixels, 2, 16, biBitfields)},
+		{"uncompressed", dibHeader(math.MaxInt32, math.MaxInt32, 24, biRGB)},
+		{"negative width", dibHeader(-4, 4, 32, biBitfields)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Decode(tt.data); err == nil {
+				t.Error("Expected an error for a DIB with a crafted size")
+			} else if !strings.Contains(err.Error(), "DIB") {
+				t.Errorf("Expected a DIB size error, got %v", err)
+			}
+		})
+	}
+}
+
+// pngHeader returns the signature and IHDR chunk of an 8-bit RGB PNG of the
+// given size, which is all a decoder reads before allocating the image
+func pngHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:8], width)
+	binary.BigEndian.PutUint32(ihdr[8:12], height)
+	ihdr[12], ihdr[13] = 8, 2
+
+	data := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0d")
+	data = append(data, ihdr...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(ihdr))
+}
+
+func TestDecode_HugeImage(t *testing.T) {
+	gif := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+	embedded := append(dibHeader(1, 1, 24, biPNG)[:40], pngHeader(100000, 100000)...)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"png", pngHeader(100000, 100000)},
+		{"png just over the limit", pngHeader(8193, 8192)},
+		{"gif", gif},
+		{"png in a dib", embedded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Decode(tt.data); err == nil || !strings.Contains(err.Error(), "too large") {
+				t.Errorf("Expected a size error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecode_Unsupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		message string
+	}{
+		{"heic", readFixture(t, "sample.heic"), "HEIC"},
+		{"rle dib", readFixture(t, "rle4.dib"), "compression method 2"},
+		{"unknown", []byte("not an image"), "not a known image format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Decode(tt.data)
+			if !errors.Is(err, ErrUnsupportedFormat) {
+				t.Fatalf("Expected ErrUnsupportedFormat, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected the error to mention %q, got %q", tt.message, err)
+			}
+		})
+	}
+
+	if _, _, err := Decode(nil); err == nil || errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected an error for empty data, got %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+
+	var pngData, jpegData bytes.Buffer
+	png.Encode(&pngData, img)
+	jpeg.Encode(&jpegData, img, nil)
+
+	got, err := Normalize(pngData.Bytes())
+	if err != nil || !bytes.Equal(got, pngData.Bytes()) {
+		t.Errorf("Expected PNG data unchanged, got error %v", err)
+	}
+
+	got, err = Normalize(jpegData.Bytes())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded, err := png.Decode(bytes.NewReader(got)); err != nil || decoded.Bounds().Dx() != 4 {
+		t.Errorf("Expected a 4px wide PNG, got %v", err)
+	}
+
+	for _, fixture := range []string{"rgb24.dib", "pixels.tiff", "pixel.webp"} {
+		got, err := Normalize(readFixture(t, fixture))
+		if err != nil {
+			t.Errorf("Expected %s converted, got %v", fixture, err)
+			continue
+		}
+		if detectFormat(got) != "png" {
+			t.Errorf("Expected %s converted to PNG, got %q", fixture, detectFormat(got))
+		}
+	}
+
+	if _, err := Normalize(readFixture(t, "sample.heic")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package cliphistory turns clipboard changes into clipboard history
+// entries: content is hashed so that copying the same text or image again
+// updates one entry, empty and oversized content is skipped, and images get
+// a PNG thumbnail for listing.
+package cliphistory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"talus_helper_windows/internal/clipboard"
+	"talus_helper_windows/internal/imaging"
+	"talus_helper_windows/internal/models"
+)
+
+// DefaultThumbnailEdge is the longest side of a thumbnail, in pixels, when no size is given
+const DefaultThumbnailEdge = 160
+
+// ErrEmpty is returned for clipboard content with nothing worth keeping
+var ErrEmpty = errors.New("clipboard content is empty")
+
+// ErrTooLarge is returned for clipboard content over the size limit
+var ErrTooLarge = errors.New("clipboard content is too large for the history")
+
+// Options limits the entries built by NewEntry
+type Options struct {
+	// MaxBytes is the largest text or image kept; 0 means no limit
+	MaxBytes int
+	// ThumbnailEdge is the longest side of image thumbnails; 0 means DefaultThumbnailEdge
+	ThumbnailEdge int
+}
+
+// Hash identifies clipboard content of a kind
+func Hash(kind string, data []byte) string {
+	h := sha256.New()
+	h.Write([]byte(kind + "\x00"))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewEntry builds the history entry of a clipboard change copied at now.
+// The ID is left for the caller to set. Text that is only whitespace is
+// ErrEmpty, content over opts.MaxBytes is ErrTooLarge, and an image that
+// cannot be decoded is an error.
+func NewEntry(change clipboard.Change, opts Options, now time.Time) (models.ClipboardEntry, error) {
+	entry := models.ClipboardEntry{Kind: change.Kind, CreatedAt: now, CopiedAt: now}
+
+	var data []byte
+	switch change.Kind {
+	case clipboard.KindText:
+		if strings.TrimSpace(change.Text) == "" {
+			return models.ClipboardEntry{}, ErrEmpty
+		}
+		data = []byte(change.Text)
+		entry.Text = change.Text
+	case clipboard.KindImage:
+		if len(change.Image) == 0 {
+			return models.ClipboardEntry{}, ErrEmpty
+		}
+		data = change.Image
+	default:
+		return models.ClipboardEntry{}, fmt.Errorf("unknown clipboard content kind %q", change.Kind)
+	}
+
+	if opts.MaxBytes > 0 && len(data) > opts.MaxBytes {
+		return models.ClipboardEntry{}, ErrTooLarge
+	}
+	entry.Size = len(data)
+	entry.Hash = Hash(change.Kind, data)
+
+	if change.Kind == clipboard.KindImage {
+		edge := opts.ThumbnailEdge
+		if edge <= 0 {
+			edge = DefaultThumbnailEdge
+		}
+		thumbnail, err := imaging.Thumbnail(change.Image, edge)
+		if err != nil {
+			return models.ClipboardEntry{}, fmt.Errorf("failed to make thumbnail: %w", err)
+		}
+		entry.Image = change.Image
+		entry.Format = change.Format
+		entry.Thumbnail = thumbnail.Data
+	}
+
+	return entry, nil
+}
+
+// Cutoff returns the time before which unpinned entries are removed when
+// entries are kept for retentionDays, or the zero time if retentionDays is 0
+func Cutoff(now time.Time, retentionDays int) time.Time {
+	if retentionDays <= 0 {
+		return time.Time{}
+	}
+	return now.AddDate(0, 0, -retentionDays)
+}
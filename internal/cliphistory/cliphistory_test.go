@@ -0,0 +1,95 @@
+package cliphistory
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"talus_helper_windows/internal/clipboard"
+)
+
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewEntry_Text(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	entry, err := NewEntry(clipboard.Change{Kind: clipboard.KindText, Text: "hello"}, Options{}, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entry.Text != "hello" || entry.Size != 5 || !entry.CreatedAt.Equal(now) || !entry.CopiedAt.Equal(now) {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry.Hash != Hash(clipboard.KindText, []byte("hello")) {
+		t.Errorf("Expected the hash of the text, got %s", entry.Hash)
+	}
+	if entry.Hash == Hash(clipboard.KindImage, []byte("hello")) {
+		t.Error("Expected text and image hashes of the same bytes to differ")
+	}
+}
+
+func TestNewEntry_Image(t *testing.T) {
+	data := pngImage(t, 800, 400)
+
+	entry, err := NewEntry(clipboard.Change{Kind: clipboard.KindImage, Image: data, Format: "png"}, Options{ThumbnailEdge: 100}, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(entry.Image, data) || entry.Format != "png" || entry.Size != len(data) {
+		t.Errorf("Expected the image kept, got format %q and size %d", entry.Format, entry.Size)
+	}
+
+	thumbnail, _, err := clipboard.Decode(entry.Thumbnail)
+	if err != nil {
+		t.Fatalf("Expected a decodable thumbnail, got %v", err)
+	}
+	if bounds := thumbnail.Bounds(); bounds.Dx() != 100 || bounds.Dy() != 50 {
+		t.Errorf("Expected a 100x50 thumbnail, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestNewEntry_Skipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		change clipboard.Change
+		opts   Options
+		want   error
+	}{
+		{"blank text", clipboard.Change{Kind: clipboard.KindText, Text: " \n\t"}, Options{}, ErrEmpty},
+		{"empty image", clipboard.Change{Kind: clipboard.KindImage}, Options{}, ErrEmpty},
+		{"text over limit", clipboard.Change{Kind: clipboard.KindText, Text: "too long"}, Options{MaxBytes: 4}, ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEntry(tt.change, tt.opts, time.Now()); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := NewEntry(clipboard.Change{Kind: clipboard.KindImage, Image: []byte("not an image")}, Options{}, time.Now()); err == nil {
+		t.Error("Expected an error for an undecodable image")
+	}
+}
+
+func TestCutoff(t *testing.T) {
+	now := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+
+	if got := Cutoff(now, 30); !got.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 30 days before now, got %v", got)
+	}
+	if got := Cutoff(now, 0); !got.IsZero() {
+		t.Errorf("Expected no cutoff without retention, got %v", got)
+	}
+}
@@ -1,26 +1,262 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"talus_helper_windows/internal/clipboard"
+	"talus_helper_windows/internal/secrets"
+	"talus_helper_windows/internal/sensitive"
+	"talus_helper_windows/internal/usage"
 
 	"github.com/BurntSushi/toml"
 	"github.com/joho/godotenv"
 )
 
-// Config represents application configuration
+// Default secret names for API keys kept in the secret store
+const (
+	SecretOpenAIAPIKey    = "openai-api-key"
+	SecretWorkflowyAPIKey = "workflowy-api-key"
+)
+
+// Config represents application configuration.
+// Each persisted field documents the environment variables that override it
+// in its env tag; the first name listed is the canonical one.
 type Config struct {
-	Theme               string `toml:"theme"`
-	AutoSave            bool   `toml:"autoSave"`
-	Notifications       bool   `toml:"notifications"`
-	OpenAIBaseURL       string `toml:"openAIBaseURL"`
-	DefaultTodoCategory string `toml:"defaultTodoCategory"`
-	MaxTodos            int    `toml:"maxTodos"`
-	Language            string `toml:"language"`
-	OpenAIAPIKey        string `toml:"openAIAPIKey"`
-	WorkflowyAPIKey     string `toml:"workflowyAPIKey"`
-	Debug               bool   `toml:"debug"`
+	Theme               string `toml:"theme" env:"TALUS_THEME"`
+	AutoSave            bool   `toml:"autoSave" env:"TALUS_AUTO_SAVE"`
+	Notifications       bool   `toml:"notifications" env:"TALUS_NOTIFICATIONS"`
+	OpenAIBaseURL       string `toml:"openAIBaseURL" env:"TALUS_OPENAI_BASE_URL,OPENAI_BASE_URL"`
+	DefaultTodoCategory string `toml:"defaultTodoCategory" env:"TALUS_DEFAULT_TODO_CATEGORY"`
+	MaxTodos            int    `toml:"maxTodos" env:"TALUS_MAX_TODOS"`
+	Language            string `toml:"language" env:"TALUS_LANGUAGE"`
+	Debug               bool   `toml:"debug" env:"TALUS_DEBUG"`
+
+	// OpenAI client retry and rate limit settings
+	OpenAIMaxRetries        int `toml:"openAIMaxRetries" env:"TALUS_OPENAI_MAX_RETRIES"`
+	OpenAIRequestsPerMinute int `toml:"openAIRequestsPerMinute" env:"TALUS_OPENAI_REQUESTS_PER_MINUTE"`
+
+	// Network settings shared by the OpenAI, Ollama and Workflowy clients
+	HTTP HTTPConfig `toml:"http"`
+
+	// Image text recognition settings
+	OCR OCRConfig `toml:"ocr"`
+
+	// Assistant chat settings
+	Chat ChatConfig `toml:"chat"`
+
+	// Semantic search settings
+	Search SearchConfig `toml:"search"`
+
+	// Clipboard history settings
+	ClipboardHistory ClipboardHistoryConfig `toml:"clipboardHistory"`
+
+	// Clipboard text transformation settings
+	Transform TransformConfig `toml:"transform"`
+
+	// Detection of passwords, keys and other sensitive data in clipboard and OCR text
+	Sensitive SensitiveConfig `toml:"sensitive"`
+
+	// LLM usage prices and budgets
+	Usage UsageConfig `toml:"usage"`
+
+	// ClipboardBackend is "system", or "fake" for an in-memory clipboard without a desktop
+	ClipboardBackend string `toml:"clipboardBackend" env:"TALUS_CLIPBOARD_BACKEND"`
+
+	// Secret store backend and the names of the secrets holding API keys
+	SecretBackend         string `toml:"secretBackend" env:"TALUS_SECRET_BACKEND"`
+	OpenAIAPIKeySecret    string `toml:"openAIAPIKeySecret" env:"TALUS_OPENAI_API_KEY_SECRET"`
+	WorkflowyAPIKeySecret string `toml:"workflowyAPIKeySecret" env:"TALUS_WORKFLOWY_API_KEY_SECRET"`
+
+	// Resolved API keys; never written to config.toml or sent to the frontend
+	OpenAIAPIKey    string `toml:"-" json:"-"`
+	WorkflowyAPIKey string `toml:"-" json:"-"`
+
+	// Plaintext keys found in a config.toml written by an older version
+	legacySecrets map[string]string
+}
+
+// OCRConfig holds the model, prompts and sampling parameters used for OCR
+type OCRConfig struct {
+	Model          string  `toml:"model" env:"TALUS_OCR_MODEL"`
+	SystemPrompt   string  `toml:"systemPrompt" env:"TALUS_OCR_SYSTEM_PROMPT"`
+	UserPrompt     string  `toml:"userPrompt" env:"TALUS_OCR_USER_PROMPT"`
+	Temperature    float64 `toml:"temperature" env:"TALUS_OCR_TEMPERATURE"`
+	MaxTokens      int     `toml:"maxTokens" env:"TALUS_OCR_MAX_TOKENS"`
+	TimeoutSeconds int     `toml:"timeoutSeconds" env:"TALUS_OCR_TIMEOUT_SECONDS"`
+	Stream         bool    `toml:"stream" env:"TALUS_OCR_STREAM"`
+	// TranslationModel translates OCR text; empty means Model
+	TranslationModel string `toml:"translationModel" env:"TALUS_OCR_TRANSLATION_MODEL"`
+	// AutoCopy puts each OCR result on the clipboard, the translation in translate mode
+	AutoCopy bool `toml:"autoCopy" env:"TALUS_OCR_AUTO_COPY"`
+
+	// Providers lists the OCR backends to try, in order, until one succeeds
+	Providers []string         `toml:"providers" env:"TALUS_OCR_PROVIDERS"`
+	Ollama    OllamaOCRConfig  `toml:"ollama"`
+	Command   CommandOCRConfig `toml:"command"`
+
+	Cache      OCRCacheConfig      `toml:"cache"`
+	Preprocess OCRPreprocessConfig `toml:"preprocess"`
+}
+
+// OCRPreprocessConfig controls how clipboard images are converted before
+// they are sent for OCR; 0 disables a limit or step
+type OCRPreprocessConfig struct {
+	Enabled bool `toml:"enabled" env:"TALUS_OCR_PREPROCESS_ENABLED"`
+	// Format is "png" or "jpeg"
+	Format      string  `toml:"format" env:"TALUS_OCR_PREPROCESS_FORMAT"`
+	MaxEdge     int     `toml:"maxEdge" env:"TALUS_OCR_PREPROCESS_MAX_EDGE"`
+	MaxSizeKB   int     `toml:"maxSizeKB" env:"TALUS_OCR_PREPROCESS_MAX_SIZE_KB"`
+	JPEGQuality int     `toml:"jpegQuality" env:"TALUS_OCR_PREPROCESS_JPEG_QUALITY"`
+	Grayscale   bool    `toml:"grayscale" env:"TALUS_OCR_PREPROCESS_GRAYSCALE"`
+	Contrast    float64 `toml:"contrast" env:"TALUS_OCR_PREPROCESS_CONTRAST"`
+	// Pixels trimmed from each edge of the image
+	CropLeft   int `toml:"cropLeft" env:"TALUS_OCR_PREPROCESS_CROP_LEFT"`
+	CropTop    int `toml:"cropTop" env:"TALUS_OCR_PREPROCESS_CROP_TOP"`
+	CropRight  int `toml:"cropRight" env:"TALUS_OCR_PREPROCESS_CROP_RIGHT"`
+	CropBottom int `toml:"cropBottom" env:"TALUS_OCR_PREPROCESS_CROP_BOTTOM"`
+}
+
+// OCRCacheConfig controls the on-disk cache of OCR results; 0 disables a limit
+type OCRCacheConfig struct {
+	Enabled   bool `toml:"enabled" env:"TALUS_OCR_CACHE_ENABLED"`
+	TTLHours  int  `toml:"ttlHours" env:"TALUS_OCR_CACHE_TTL_HOURS"`
+	MaxSizeMB int  `toml:"maxSizeMB" env:"TALUS_OCR_CACHE_MAX_SIZE_MB"`
+}
+
+// HTTPConfig holds the proxy, TLS, timeout and logging settings of API requests
+type HTTPConfig struct {
+	// ProxyURL is the proxy for all requests; empty uses the HTTP_PROXY and HTTPS_PROXY environment variables
+	ProxyURL string `toml:"proxyURL" env:"TALUS_HTTP_PROXY_URL"`
+	// CAFile is a PEM file of extra trusted certificates, such as a corporate root CA
+	CAFile string `toml:"caFile" env:"TALUS_HTTP_CA_FILE"`
+	// InsecureSkipVerify disables TLS certificate checks; for development only
+	InsecureSkipVerify bool `toml:"insecureSkipVerify" env:"TALUS_HTTP_INSECURE_SKIP_VERIFY"`
+	// TimeoutSeconds bounds requests without a timeout of their own, such as Workflowy calls
+	TimeoutSeconds        int    `toml:"timeoutSeconds" env:"TALUS_HTTP_TIMEOUT_SECONDS"`
+	ConnectTimeoutSeconds int    `toml:"connectTimeoutSeconds" env:"TALUS_HTTP_CONNECT_TIMEOUT_SECONDS"`
+	UserAgent             string `toml:"userAgent" env:"TALUS_HTTP_USER_AGENT"`
+	// LogRequests prints every request with its status and latency, credentials redacted
+	LogRequests bool `toml:"logRequests" env:"TALUS_HTTP_LOG_REQUESTS"`
+}
+
+// ChatConfig holds the model, prompt and sampling parameters of the assistant chat
+type ChatConfig struct {
+	// Model answers chat messages; empty means the OCR model
+	Model        string  `toml:"model" env:"TALUS_CHAT_MODEL"`
+	SystemPrompt string  `toml:"systemPrompt" env:"TALUS_CHAT_SYSTEM_PROMPT"`
+	Temperature  float64 `toml:"temperature" env:"TALUS_CHAT_TEMPERATURE"`
+	MaxTokens    int     `toml:"maxTokens" env:"TALUS_CHAT_MAX_TOKENS"`
+	Stream       bool    `toml:"stream" env:"TALUS_CHAT_STREAM"`
+	// TimeoutSeconds bounds a whole answer, including a streamed one
+	TimeoutSeconds int `toml:"timeoutSeconds" env:"TALUS_CHAT_TIMEOUT_SECONDS"`
+	// HistoryMessages is the number of earlier messages sent with each new one; 0 sends all
+	HistoryMessages int `toml:"historyMessages" env:"TALUS_CHAT_HISTORY_MESSAGES"`
+	// Tools lets the assistant manage todos and Workflowy nodes
+	Tools bool `toml:"tools" env:"TALUS_CHAT_TOOLS"`
+	// MaxToolRounds caps the rounds of tool calls for one message
+	MaxToolRounds int `toml:"maxToolRounds" env:"TALUS_CHAT_MAX_TOOL_ROUNDS"`
+}
+
+// SearchConfig holds the embedding model and limits of the semantic search
+// across todos, OCR history and Workflowy nodes
+type SearchConfig struct {
+	Model string `toml:"model" env:"TALUS_SEARCH_MODEL"`
+	// Limit is the number of results returned when the caller gives none
+	Limit int `toml:"limit" env:"TALUS_SEARCH_LIMIT"`
+	// MinScore is the lowest cosine similarity, from -1 to 1, of a result
+	MinScore float64 `toml:"minScore" env:"TALUS_SEARCH_MIN_SCORE"`
+	// BatchSize is the number of texts embedded per request
+	BatchSize int `toml:"batchSize" env:"TALUS_SEARCH_BATCH_SIZE"`
+	// OCRResults is the number of recent OCR results indexed
+	OCRResults int `toml:"ocrResults" env:"TALUS_SEARCH_OCR_RESULTS"`
+	// WorkflowyMaxNodes caps the Workflowy nodes indexed; 0 skips Workflowy
+	WorkflowyMaxNodes int `toml:"workflowyMaxNodes" env:"TALUS_SEARCH_WORKFLOWY_MAX_NODES"`
+	// WorkflowyRefreshMinutes is how long the indexed Workflowy nodes are reused before they are fetched again
+	WorkflowyRefreshMinutes int `toml:"workflowyRefreshMinutes" env:"TALUS_SEARCH_WORKFLOWY_REFRESH_MINUTES"`
+}
+
+// ClipboardHistoryConfig controls which clipboard contents are kept in the
+// history and for how long; pinned entries are never removed
+type ClipboardHistoryConfig struct {
+	Enabled bool `toml:"enabled" env:"TALUS_CLIPBOARD_HISTORY_ENABLED"`
+	// MaxEntries caps the unpinned entries kept; 0 means no cap
+	MaxEntries int `toml:"maxEntries" env:"TALUS_CLIPBOARD_HISTORY_MAX_ENTRIES"`
+	// RetentionDays removes unpinned entries not copied for that long; 0 keeps them
+	RetentionDays int `toml:"retentionDays" env:"TALUS_CLIPBOARD_HISTORY_RETENTION_DAYS"`
+	// MaxItemKB skips larger texts and images; 0 means no limit
+	MaxItemKB int `toml:"maxItemKB" env:"TALUS_CLIPBOARD_HISTORY_MAX_ITEM_KB"`
+	// ThumbnailSize is the longest side of image thumbnails, in pixels
+	ThumbnailSize int `toml:"thumbnailSize" env:"TALUS_CLIPBOARD_HISTORY_THUMBNAIL_SIZE"`
+}
+
+// TransformConfig holds the user-defined chains of clipboard text
+// transformers
+type TransformConfig struct {
+	// Chains lists the transformers each chain runs, in order, keyed by chain name
+	Chains map[string][]string `toml:"chains"`
+}
+
+// SensitiveConfig controls how passwords, API keys, card numbers, emails
+// and other secrets are handled in clipboard text and OCR results
+type SensitiveConfig struct {
+	Enabled bool `toml:"enabled" env:"TALUS_SENSITIVE_ENABLED"`
+	// Action is "warn", "redact" or "block" when sensitive data is found
+	Action string `toml:"action" env:"TALUS_SENSITIVE_ACTION"`
+	// Kinds lists the kinds of sensitive data looked for; empty means all
+	Kinds []string `toml:"kinds" env:"TALUS_SENSITIVE_KINDS"`
+	// MinEntropy is the bits per character from which a long random-looking token counts as a secret
+	MinEntropy float64 `toml:"minEntropy" env:"TALUS_SENSITIVE_MIN_ENTROPY"`
+	// MinSecretLength is the shortest token checked for entropy
+	MinSecretLength int `toml:"minSecretLength" env:"TALUS_SENSITIVE_MIN_SECRET_LENGTH"`
+	// HistoryExpiryMinutes removes unpinned sensitive clipboard history entries after that long; 0 keeps them like other entries
+	HistoryExpiryMinutes int `toml:"historyExpiryMinutes" env:"TALUS_SENSITIVE_HISTORY_EXPIRY_MINUTES"`
+}
+
+// UsageConfig holds per-model prices and the spending budgets for LLM calls.
+// Budgets are in the same currency as the prices; 0 means no budget.
+type UsageConfig struct {
+	DailyBudget   float64 `toml:"dailyBudget" env:"TALUS_USAGE_DAILY_BUDGET"`
+	MonthlyBudget float64 `toml:"monthlyBudget" env:"TALUS_USAGE_MONTHLY_BUDGET"`
+	// BudgetAction is "warn" or "block" once a budget is used up
+	BudgetAction string `toml:"budgetAction" env:"TALUS_USAGE_BUDGET_ACTION"`
+	// Prices per million tokens, keyed by model name or name prefix
+	Prices map[string]usage.Price `toml:"prices"`
+}
+
+// OCR provider names
+const (
+	OCRProviderOpenAI  = "openai"
+	OCRProviderOllama  = "ollama"
+	OCRProviderCommand = "command"
+)
+
+// OllamaOCRConfig holds the settings for a local Ollama-style vision model
+type OllamaOCRConfig struct {
+	BaseURL string `toml:"baseURL" env:"TALUS_OCR_OLLAMA_BASE_URL"`
+	Model   string `toml:"model" env:"TALUS_OCR_OLLAMA_MODEL"`
+}
+
+// CommandOCRConfig holds the executable that reads an image on stdin and writes text to stdout
+type CommandOCRConfig struct {
+	Path string   `toml:"path" env:"TALUS_OCR_COMMAND_PATH"`
+	Args []string `toml:"args" env:"TALUS_OCR_COMMAND_ARGS"`
+}
+
+// legacySecretFields holds API keys stored in plaintext by older versions
+type legacySecretFields struct {
+	OpenAIAPIKey    string `toml:"openAIAPIKey"`
+	WorkflowyAPIKey string `toml:"workflowyAPIKey"`
+}
+
+// legacySecretKeys are the config.toml keys of legacySecretFields
+var legacySecretKeys = map[string]bool{
+	"openAIAPIKey":    true,
+	"workflowyAPIKey": true,
 }
 
 // LoadEnvForDebug loads .env file if it exists (for debug mode)
@@ -53,68 +289,339 @@ func GetDefault() Config {
 		Theme:               "light",
 		AutoSave:            true,
 		Notifications:       true,
-		OpenAIAPIKey:        "",
 		OpenAIBaseURL:       "https://api.moonshot.cn/v1",
 		DefaultTodoCategory: "General",
 		MaxTodos:            100,
 		Language:            "en",
-		WorkflowyAPIKey:     "",
 		Debug:               false,
+		OpenAIMaxRetries:    3,
+		HTTP: HTTPConfig{
+			TimeoutSeconds:        30,
+			ConnectTimeoutSeconds: 10,
+			UserAgent:             "talus-helper",
+		},
+		OCR: OCRConfig{
+			Model:          "moonshot-v1-8k-vision-preview",
+			SystemPrompt:   "Extract all text from images accurately. Return only the text content, no explanations or additional formatting.",
+			UserPrompt:     "Extract all text from this image. Return only the text content.",
+			Temperature:    0.3,
+			MaxTokens:      0,
+			TimeoutSeconds: 30,
+			Stream:         true,
+			Providers:      []string{OCRProviderOpenAI},
+			Ollama: OllamaOCRConfig{
+				BaseURL: "http://localhost:11434",
+				Model:   "llava",
+			},
+			Command: CommandOCRConfig{
+				Path: "tesseract",
+				Args: []string{"stdin", "stdout"},
+			},
+			Cache: OCRCacheConfig{
+				Enabled:   true,
+				TTLHours:  24 * 30,
+				MaxSizeMB: 50,
+			},
+			Preprocess: OCRPreprocessConfig{
+				Enabled:     true,
+				Format:      "png",
+				MaxEdge:     2048,
+				MaxSizeKB:   4096,
+				JPEGQuality: 85,
+			},
+		},
+		Chat: ChatConfig{
+			SystemPrompt:    "You are a helpful desktop assistant. Answer concisely.",
+			Temperature:     0.7,
+			Stream:          true,
+			TimeoutSeconds:  120,
+			HistoryMessages: 20,
+			Tools:           true,
+			MaxToolRounds:   8,
+		},
+		Search: SearchConfig{
+			Model:                   "text-embedding-3-small",
+			Limit:                   20,
+			MinScore:                0.25,
+			BatchSize:               64,
+			OCRResults:              500,
+			WorkflowyMaxNodes:       500,
+			WorkflowyRefreshMinutes: 60,
+		},
+		ClipboardHistory: ClipboardHistoryConfig{
+			Enabled:       true,
+			MaxEntries:    500,
+			RetentionDays: 30,
+			MaxItemKB:     10240,
+			ThumbnailSize: 160,
+		},
+		Transform: TransformConfig{
+			Chains: map[string][]string{},
+		},
+		Sensitive: SensitiveConfig{
+			Enabled:              true,
+			Action:               sensitive.ActionWarn,
+			Kinds:                sensitive.AllKinds(),
+			MinEntropy:           sensitive.DefaultMinEntropy,
+			MinSecretLength:      sensitive.DefaultMinSecretLength,
+			HistoryExpiryMinutes: 60,
+		},
+		Usage: UsageConfig{
+			BudgetAction: usage.ActionWarn,
+			Prices:       map[string]usage.Price{},
+		},
+		ClipboardBackend:      clipboard.BackendSystem,
+		SecretBackend:         secrets.BackendFile,
+		OpenAIAPIKeySecret:    SecretOpenAIAPIKey,
+		WorkflowyAPIKeySecret: SecretWorkflowyAPIKey,
 	}
 }
 
-// Load reads the configuration from file and environment variables
-func Load() (*Config, error) {
-	dataDir, err := GetDataDir()
+// Load builds the configuration from all layers: defaults, the config
+// file, environment variables and command-line flags, each overriding the
+// previous one. It also returns which layer supplied every key.
+func Load(args []string) (*Config, Provenance, error) {
+	configFile, err := Path()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	configFile := filepath.Join(dataDir, "config.toml")
 	data, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	if data != nil {
+		if data, err = migrateFile(configFile, data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return load(data, os.LookupEnv, args)
+}
+
+// load applies the configuration layers on top of the defaults
+func load(data []byte, lookupEnv func(string) (string, bool), args []string) (*Config, Provenance, error) {
+	config := GetDefault()
+	provenance := make(Provenance)
+	for _, field := range configFields() {
+		provenance[field.Key] = LayerDefault
+	}
+
+	if data != nil {
+		if err := applyFile(&config, provenance, data); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := applyEnv(&config, provenance, lookupEnv); err != nil {
+		return nil, nil, err
+	}
+	if err := applyFlags(&config, provenance, args); err != nil {
+		return nil, nil, err
+	}
+
+	return &config, provenance, nil
+}
+
+// applyFile decodes the config file over the current values
+func applyFile(config *Config, provenance Provenance, data []byte) error {
+	meta, err := toml.Decode(string(data), config)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// Return default config if file doesn't exist
-			defaultConfig := GetDefault()
-			return &defaultConfig, nil
+		return err
+	}
+	warnUndecoded(meta)
+
+	for _, field := range configFields() {
+		if meta.IsDefined(strings.Split(field.Key, ".")...) {
+			provenance[field.Key] = LayerFile
 		}
-		return nil, err
 	}
 
-	var config Config
-	if err := toml.Unmarshal(data, &config); err != nil {
-		return nil, err
+	// Remember plaintext keys so ResolveSecrets can move them into the store
+	var legacy legacySecretFields
+	if _, err := toml.Decode(string(data), &legacy); err == nil {
+		config.legacySecrets = make(map[string]string)
+		if legacy.OpenAIAPIKey != "" {
+			config.legacySecrets[config.OpenAIAPIKeySecret] = legacy.OpenAIAPIKey
+		}
+		if legacy.WorkflowyAPIKey != "" {
+			config.legacySecrets[config.WorkflowyAPIKeySecret] = legacy.WorkflowyAPIKey
+		}
 	}
 
-	// Override with environment variables if they exist
-	// Only override if the config value is empty/default to avoid overriding user settings
-	if envAPIKey := os.Getenv("OPENAI_API_KEY"); envAPIKey != "" && config.OpenAIAPIKey == "" {
-		config.OpenAIAPIKey = envAPIKey
+	return nil
+}
+
+// ResolveSecrets fills the API key fields from the secret store.
+// Plaintext keys left in config.toml by older versions are moved into the
+// store and the file is rewritten without them. Environment variables are
+// used for keys that are not in the store.
+// store may be nil when it could not be opened, in which case the keys come
+// from config.toml, if it still has them, or the environment variables.
+func ResolveSecrets(config *Config, store secrets.SecretStore) error {
+	if store == nil {
+		config.OpenAIAPIKey = config.legacySecrets[config.OpenAIAPIKeySecret]
+		if config.OpenAIAPIKey == "" {
+			config.OpenAIAPIKey = os.Getenv("OPENAI_API_KEY")
+		}
+		config.WorkflowyAPIKey = config.legacySecrets[config.WorkflowyAPIKeySecret]
+		if config.WorkflowyAPIKey == "" {
+			config.WorkflowyAPIKey = os.Getenv("WORKFLOWY_API_KEY")
+		}
+		return nil
 	}
-	if envBaseURL := os.Getenv("OPENAI_BASE_URL"); envBaseURL != "" && config.OpenAIBaseURL == "" {
-		config.OpenAIBaseURL = envBaseURL
+
+	if len(config.legacySecrets) > 0 {
+		for name, value := range config.legacySecrets {
+			if err := store.Set(name, value); err != nil {
+				return fmt.Errorf("failed to migrate secret %s: %w", name, err)
+			}
+		}
+		if err := removeFileKeys(legacySecretKeys); err != nil {
+			return fmt.Errorf("failed to rewrite config without plaintext secrets: %w", err)
+		}
+		config.legacySecrets = nil
+	}
+
+	var err error
+	config.OpenAIAPIKey, err = resolveSecret(store, config.OpenAIAPIKeySecret, "OPENAI_API_KEY")
+	if err != nil {
+		return err
 	}
-	if envWorkflowyAPIKey := os.Getenv("WORKFLOWY_API_KEY"); envWorkflowyAPIKey != "" && config.WorkflowyAPIKey == "" {
-		config.WorkflowyAPIKey = envWorkflowyAPIKey
+	config.WorkflowyAPIKey, err = resolveSecret(store, config.WorkflowyAPIKeySecret, "WORKFLOWY_API_KEY")
+	if err != nil {
+		return err
 	}
+	return nil
+}
 
-	return &config, nil
+// resolveSecret reads a secret from the store, falling back to an environment variable
+func resolveSecret(store secrets.SecretStore, name, envName string) (string, error) {
+	value, err := store.Get(name)
+	if err == nil {
+		return value, nil
+	}
+	if !errors.Is(err, secrets.ErrNotFound) {
+		return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return os.Getenv(envName), nil
 }
 
-// Save writes the configuration to file
-func Save(config Config) error {
-	dataDir, err := GetDataDir()
+// Save writes the configuration to file.
+// base is the effective configuration the changes were made to, with the
+// provenance of its values. Keys whose value did not come from the file and
+// is unchanged from base are left as the file has them, so defaults,
+// environment variables and flags are never written back; a nil base
+// writes every key. Keys in the existing file that Config does not know
+// about are kept, and the previous file is added to the config history.
+func Save(config Config, base *Config, provenance Provenance) error {
+	configFile, err := Path()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(config); err != nil {
+		return err
+	}
+	values := make(map[string]interface{})
+	if err := toml.Unmarshal(buf.Bytes(), &values); err != nil {
+		return err
+	}
+
+	existing, err := readValues(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if base != nil {
+		for _, field := range configFields() {
+			if provenance[field.Key] != LayerFile && formatField(&config, field) == formatField(base, field) {
+				deleteKey(values, field.Key)
+			}
+		}
+	}
+	preserveUnknown(values, existing)
+	removeEmptyTables(values)
+	values[schemaVersionKey] = int64(CurrentSchemaVersion)
+
+	if err := snapshot(configFile); err != nil {
+		return err
+	}
+	return writeValues(configFile, values)
+}
+
+// deleteKey removes a dotted key such as "ocr.model" from raw values
+func deleteKey(values map[string]interface{}, key string) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		table, ok := values[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		values = table
+	}
+	delete(values, parts[len(parts)-1])
+}
+
+// removeEmptyTables removes the tables left without keys by deleteKey
+func removeEmptyTables(values map[string]interface{}) {
+	for key, value := range values {
+		if table, ok := value.(map[string]interface{}); ok {
+			removeEmptyTables(table)
+			if len(table) == 0 {
+				delete(values, key)
+			}
+		}
+	}
+}
+
+// removeFileKeys rewrites config.toml without the given top-level keys,
+// leaving every other value exactly as the user wrote it
+func removeFileKeys(keys map[string]bool) error {
+	configFile, err := Path()
 	if err != nil {
 		return err
 	}
 
-	configFile := filepath.Join(dataDir, "config.toml")
-	file, err := os.Create(configFile)
+	values, err := readValues(configFile)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	for key := range keys {
+		delete(values, key)
+	}
+
+	return writeValues(configFile, values)
+}
+
+// Path returns the path of config.toml in the data directory
+func Path() (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "config.toml"), nil
+}
 
-	encoder := toml.NewEncoder(file)
-	return encoder.Encode(config)
+// readValues reads config.toml as raw, untyped values
+func readValues(configFile string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// writeValues atomically writes raw values to config.toml
+func writeValues(configFile string, values map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+		return err
+	}
+	return writeFileAtomic(configFile, buf.Bytes(), 0644)
 }
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// HistoryLimit is the number of previous config versions kept in config-history
+const HistoryLimit = 20
+
+// historyTimeFormat names history files so they sort chronologically
+const historyTimeFormat = "20060102T150405.000000000Z"
+
+// HistoryEntry describes a previous version of config.toml
+type HistoryEntry struct {
+	ID      string    `json:"id"`
+	SavedAt time.Time `json:"savedAt"`
+	Size    int64     `json:"size"`
+}
+
+// historyDir returns <dataDir>/config-history, creating it if needed
+func historyDir() (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(dataDir, "config-history")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// snapshot copies the current config.toml into the history directory and
+// prunes old versions. A missing config file is not an error.
+func snapshot(configFile string) error {
+	data, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dir, err := historyDir()
+	if err != nil {
+		return err
+	}
+
+	id := time.Now().UTC().Format(historyTimeFormat)
+	if err := writeFileAtomic(filepath.Join(dir, id+".toml"), data, 0644); err != nil {
+		return fmt.Errorf("failed to save config history: %w", err)
+	}
+
+	return pruneHistory(dir)
+}
+
+// pruneHistory removes the oldest history files beyond HistoryLimit
+func pruneHistory(dir string) error {
+	entries, err := ListHistory()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries[min(len(entries), HistoryLimit):] {
+		if err := os.Remove(filepath.Join(dir, entry.ID+".toml")); err != nil {
+			return fmt.Errorf("failed to prune config history: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListHistory returns the saved config versions, newest first
+func ListHistory() ([]HistoryEntry, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+	for _, file := range files {
+		id, ok := strings.CutSuffix(file.Name(), ".toml")
+		if file.IsDir() || !ok {
+			continue
+		}
+		savedAt, err := time.Parse(historyTimeFormat, id)
+		if err != nil {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, HistoryEntry{ID: id, SavedAt: savedAt, Size: info.Size()})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].SavedAt.After(entries[j].SavedAt)
+	})
+	return entries, nil
+}
+
+// RestoreHistory replaces config.toml with a previous version.
+// The current file is itself saved to history first, so a restore can be undone.
+func RestoreHistory(id string) error {
+	if _, err := time.Parse(historyTimeFormat, id); err != nil {
+		return fmt.Errorf("invalid config history id %q", id)
+	}
+
+	dir, err := historyDir()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, id+".toml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("config history entry %s not found", id)
+		}
+		return err
+	}
+
+	if err := Replace(data); err != nil {
+		return fmt.Errorf("failed to restore config history entry %s: %w", id, err)
+	}
+	return nil
+}
+
+// Validate checks that data is a config file that would load: valid TOML,
+// of a schema version that can be migrated, with values of the right types
+func Validate(data []byte) error {
+	values := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+	if _, err := migrate(values); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+	noEnv := func(string) (string, bool) { return "", false }
+	if _, _, err := load(buf.Bytes(), noEnv, nil); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+	return nil
+}
+
+// Replace validates data and writes it over config.toml.
+// The current file is saved to history first.
+func Replace(data []byte) error {
+	// Refuse to write a file that would not load
+	if err := Validate(data); err != nil {
+		return err
+	}
+
+	configFile, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := snapshot(configFile); err != nil {
+		return err
+	}
+	return writeFileAtomic(configFile, data, 0644)
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory,
+// syncs it to disk and renames it over path, so readers never see a
+// partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSave_KeepsHistoryAndRestores(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := GetDefault()
+	cfg.Theme = "dark"
+	if err := Save(cfg, nil, nil); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	// First save has nothing to snapshot
+	entries, err := ListHistory()
+	if err != nil {
+		t.Fatalf("Failed to list history: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Expected no history entries, got %d", len(entries))
+	}
+
+	cfg.Theme = "light"
+	if err := Save(cfg, nil, nil); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	entries, err = ListHistory()
+	if err != nil {
+		t.Fatalf("Failed to list history: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 history entry, got %d", len(entries))
+	}
+
+	if err := RestoreHistory(entries[0].ID); err != nil {
+		t.Fatalf("Failed to restore config: %v", err)
+	}
+
+	restored, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Failed to load restored config: %v", err)
+	}
+	if restored.Theme != "dark" {
+		t.Errorf("Expected restored theme 'dark', got %s", restored.Theme)
+	}
+
+	// The restore itself is undoable
+	entries, err = ListHistory()
+	if err != nil {
+		t.Fatalf("Failed to list history: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 history entries after restore, got %d", len(entries))
+	}
+}
+
+func TestSave_PrunesHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := GetDefault()
+	for i := 0; i < HistoryLimit+5; i++ {
+		cfg.MaxTodos = i
+		if err := Save(cfg, nil, nil); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+	}
+
+	entries, err := ListHistory()
+	if err != nil {
+		t.Fatalf("Failed to list history: %v", err)
+	}
+	if len(entries) != HistoryLimit {
+		t.Errorf("Expected %d history entries, got %d", HistoryLimit, len(entries))
+	}
+}
+
+func TestRestoreHistory_RejectsInvalidID(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, id := range []string{"", "../config", "not-a-timestamp"} {
+		if err := RestoreHistory(id); err == nil {
+			t.Errorf("Expected error for id %q", id)
+		}
+	}
+}
+
+func TestWriteFileAtomic_LeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+
+	if err := writeFileAtomic(path, []byte("theme = \"dark\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(files) != 1 || files[0].Name() != "config.toml" {
+		t.Errorf("Expected only config.toml, got %v", files)
+	}
+}
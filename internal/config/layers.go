@@ -0,0 +1,240 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Configuration layers, from lowest to highest precedence
+const (
+	LayerDefault = "default"
+	LayerFile    = "file"
+	LayerEnv     = "env"
+	LayerFlag    = "flag"
+)
+
+// Provenance maps each config key to the layer that supplied its value
+type Provenance map[string]string
+
+// FieldSource describes where the effective value of a config key came from
+type FieldSource struct {
+	Key     string   `json:"key"`
+	Value   string   `json:"value"`
+	Layer   string   `json:"layer"`
+	EnvVars []string `json:"envVars"`
+	Flag    string   `json:"flag"`
+}
+
+// configField describes a persisted Config field
+type configField struct {
+	Key     string
+	EnvVars []string
+	index   []int
+}
+
+// configFields lists every persisted field of Config, in declaration order.
+// Nested tables are flattened into dotted keys such as "ocr.model".
+func configFields() []configField {
+	return collectFields(reflect.TypeOf(Config{}), "", nil)
+}
+
+// collectFields walks a struct type and returns its TOML-mapped fields
+func collectFields(t reflect.Type, prefix string, index []int) []configField {
+	var fields []configField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+		if field.Type.Kind() == reflect.Struct {
+			fields = append(fields, collectFields(field.Type, prefix+name+".", fieldIndex)...)
+			continue
+		}
+		// Tables keyed by name, such as usage.prices, can only be set in the file
+		if field.Type.Kind() == reflect.Map {
+			continue
+		}
+
+		var envVars []string
+		if tag := field.Tag.Get("env"); tag != "" {
+			envVars = strings.Split(tag, ",")
+		}
+		fields = append(fields, configField{
+			Key:     prefix + name,
+			EnvVars: envVars,
+			index:   fieldIndex,
+		})
+	}
+	return fields
+}
+
+// nameKeyedTables returns the dotted keys of the tables keyed by name, such
+// as "usage.prices"
+func nameKeyedTables() map[string]bool {
+	tables := make(map[string]bool)
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("toml"), ",")[0]
+			if !field.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			switch field.Type.Kind() {
+			case reflect.Struct:
+				walk(field.Type, prefix+name+".")
+			case reflect.Map:
+				tables[prefix+name] = true
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return tables
+}
+
+// setField parses raw and assigns it to the field
+func setField(config *Config, field configField, raw string) error {
+	value := reflect.ValueOf(config).Elem().FieldByIndex(field.index)
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean for %s: %q", field.Key, raw)
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer for %s: %q", field.Key, raw)
+		}
+		value.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number for %s: %q", field.Key, raw)
+		}
+		value.SetFloat(f)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type for %s", field.Key)
+		}
+		// Lists are given as comma-separated values
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type for %s", field.Key)
+	}
+	return nil
+}
+
+// formatField returns the field value as a string for reporting
+func formatField(config *Config, field configField) string {
+	value := reflect.ValueOf(config).Elem().FieldByIndex(field.index)
+	if items, ok := value.Interface().([]string); ok {
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// applyEnv overrides fields from their environment variables
+func applyEnv(config *Config, provenance Provenance, lookupEnv func(string) (string, bool)) error {
+	for _, field := range configFields() {
+		for _, name := range field.EnvVars {
+			raw, ok := lookupEnv(name)
+			if !ok || raw == "" {
+				continue
+			}
+			if err := setField(config, field, raw); err != nil {
+				return fmt.Errorf("environment variable %s: %w", name, err)
+			}
+			provenance[field.Key] = LayerEnv
+			break
+		}
+	}
+	return nil
+}
+
+// fieldFlag is a flag.Value that records the raw value of a config flag
+type fieldFlag struct {
+	raw    string
+	isBool bool
+}
+
+func (f *fieldFlag) String() string     { return f.raw }
+func (f *fieldFlag) Set(v string) error { f.raw = v; return nil }
+func (f *fieldFlag) IsBoolFlag() bool   { return f.isBool }
+
+// applyFlags overrides fields from command-line flags named after their keys,
+// e.g. -theme=dark or -ocr.model=gpt-4o
+func applyFlags(config *Config, provenance Provenance, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	fields := configFields()
+	values := make(map[string]*fieldFlag, len(fields))
+	for _, field := range fields {
+		kind := reflect.ValueOf(config).Elem().FieldByIndex(field.index).Kind()
+		value := &fieldFlag{isBool: kind == reflect.Bool}
+		values[field.Key] = value
+		fs.Var(value, field.Key, "")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("invalid command-line flags: %w", err)
+	}
+
+	var setErr error
+	fs.Visit(func(f *flag.Flag) {
+		if setErr != nil {
+			return
+		}
+		for _, field := range fields {
+			if field.Key == f.Name {
+				if err := setField(config, field, values[f.Name].raw); err != nil {
+					setErr = fmt.Errorf("flag -%s: %w", f.Name, err)
+					return
+				}
+				provenance[field.Key] = LayerFlag
+			}
+		}
+	})
+	return setErr
+}
+
+// Explain reports, for every key, the effective value and the layer that supplied it
+func Explain(config *Config, provenance Provenance) []FieldSource {
+	fields := configFields()
+	sources := make([]FieldSource, 0, len(fields))
+	for _, field := range fields {
+		layer := provenance[field.Key]
+		if layer == "" {
+			layer = LayerDefault
+		}
+		sources = append(sources, FieldSource{
+			Key:     field.Key,
+			Value:   formatField(config, field),
+			Layer:   layer,
+			EnvVars: field.EnvVars,
+			Flag:    "-" + field.Key,
+		})
+	}
+	return sources
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+// envMap returns a lookup function backed by a map
+func envMap(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func TestLoad_LayerPrecedence(t *testing.T) {
+	file := []byte(`
+theme = "dark"
+maxTodos = 50
+openAIBaseURL = "https://file.example/v1"
+
+[ocr.command]
+args = ["stdin", "stdout", "-l", "eng"]
+`)
+	env := envMap(map[string]string{
+		"OPENAI_BASE_URL":     "https://env.example/v1",
+		"TALUS_MAX_TODOS":     "75",
+		"TALUS_OCR_PROVIDERS": "ollama, command",
+	})
+	args := []string{"-maxTodos=200", "-debug", "-ocr.ollama.model=llama3.2-vision"}
+
+	cfg, provenance, err := load(file, env, args)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		key   string
+		layer string
+		value string
+	}{
+		{"language", LayerDefault, "en"},
+		{"theme", LayerFile, "dark"},
+		{"openAIBaseURL", LayerEnv, "https://env.example/v1"},
+		{"maxTodos", LayerFlag, "200"},
+		{"debug", LayerFlag, "true"},
+		{"ocr.command.args", LayerFile, "stdin,stdout,-l,eng"},
+		{"ocr.providers", LayerEnv, "ollama,command"},
+		{"ocr.ollama.model", LayerFlag, "llama3.2-vision"},
+	}
+
+	sources := make(map[string]FieldSource)
+	for _, source := range Explain(cfg, provenance) {
+		sources[source.Key] = source
+	}
+
+	for _, tt := range tests {
+		source, ok := sources[tt.key]
+		if !ok {
+			t.Errorf("Expected key %s in Explain output", tt.key)
+			continue
+		}
+		if source.Layer != tt.layer {
+			t.Errorf("%s: expected layer %s, got %s", tt.key, tt.layer, source.Layer)
+		}
+		if source.Value != tt.value {
+			t.Errorf("%s: expected value %s, got %s", tt.key, tt.value, source.Value)
+		}
+	}
+}
+
+func TestLoad_MissingFileStillAppliesEnv(t *testing.T) {
+	env := envMap(map[string]string{"TALUS_THEME": "dark"})
+
+	cfg, provenance, err := load(nil, env, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Theme != "dark" {
+		t.Errorf("Expected theme 'dark', got %s", cfg.Theme)
+	}
+	if provenance["theme"] != LayerEnv {
+		t.Errorf("Expected theme from env, got %s", provenance["theme"])
+	}
+	if cfg.MaxTodos != GetDefault().MaxTodos {
+		t.Errorf("Expected default maxTodos, got %d", cfg.MaxTodos)
+	}
+}
+
+func TestLoad_InvalidValues(t *testing.T) {
+	t.Run("env", func(t *testing.T) {
+		env := envMap(map[string]string{"TALUS_MAX_TODOS": "many"})
+		if _, _, err := load(nil, env, nil); err == nil {
+			t.Error("Expected error for non-numeric env value")
+		}
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		if _, _, err := load(nil, envMap(nil), []string{"-unknown=1"}); err == nil {
+			t.Error("Expected error for unknown flag")
+		}
+	})
+}
+
+func TestConfigFields_HaveEnvVars(t *testing.T) {
+	for _, field := range configFields() {
+		if len(field.EnvVars) == 0 {
+			t.Errorf("Config key %s has no documented environment variable", field.Key)
+		}
+	}
+}
+
+func TestLoad_UsagePricesFromFile(t *testing.T) {
+	file := []byte(`
+[usage]
+monthlyBudget = 5.0
+
+[usage.prices."gpt-4o"]
+prompt = 2.5
+completion = 10.0
+`)
+
+	cfg, provenance, err := load(file, envMap(nil), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	price, ok := cfg.Usage.Prices["gpt-4o"]
+	if !ok || price.Prompt != 2.5 || price.Completion != 10 {
+		t.Errorf("Expected gpt-4o price from file, got %+v", cfg.Usage.Prices)
+	}
+	if provenance["usage.monthlyBudget"] != LayerFile {
+		t.Errorf("Expected monthlyBudget from file, got %s", provenance["usage.monthlyBudget"])
+	}
+	if cfg.Usage.BudgetAction != "warn" {
+		t.Errorf("Expected default budget action, got %s", cfg.Usage.BudgetAction)
+	}
+}
+
+func TestSave_KeepsEnvAndFlagOverrides(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TALUS_MAX_TODOS", "75")
+	args := []string{"-debug"}
+
+	configFile, err := Path()
+	if err != nil {
+		t.Fatalf("Failed to get config path: %v", err)
+	}
+	if err := os.WriteFile(configFile, []byte("schemaVersion = 1\ntheme = \"dark\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	loaded, provenance, err := Load(args)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg := *loaded
+	cfg.Language = "fr"
+	if err := Save(cfg, loaded, provenance); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	reloaded, provenance, err := Load(args)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	tests := []struct {
+		key   string
+		layer string
+		value string
+	}{
+		{"theme", LayerFile, "dark"},
+		{"language", LayerFile, "fr"},
+		{"maxTodos", LayerEnv, "75"},
+		{"debug", LayerFlag, "true"},
+		{"ocr.model", LayerDefault, GetDefault().OCR.Model},
+	}
+	sources := make(map[string]FieldSource)
+	for _, source := range Explain(reloaded, provenance) {
+		sources[source.Key] = source
+	}
+	for _, tt := range tests {
+		if source := sources[tt.key]; source.Layer != tt.layer || source.Value != tt.value {
+			t.Errorf("%s: expected %s from %s, got %s from %s", tt.key, tt.value, tt.layer, source.Value, source.Layer)
+		}
+	}
+
+	// Without the override the file value, not the env value, applies
+	t.Setenv("TALUS_MAX_TODOS", "")
+	plain, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if plain.MaxTodos != GetDefault().MaxTodos || plain.Debug {
+		t.Errorf("Expected the env and flag values not written to the file, got maxTodos %d and debug %v", plain.MaxTodos, plain.Debug)
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CurrentSchemaVersion is the config.toml schema written by this version
+const CurrentSchemaVersion = 1
+
+// schemaVersionKey is the config.toml key holding the schema version
+const schemaVersionKey = "schemaVersion"
+
+// migrator upgrades raw config values from one schema version to the next
+type migrator func(values map[string]interface{}) error
+
+// migrators holds the upgrade from version i to version i+1 at index i
+var migrators = []migrator{
+	migrateV0ToV1,
+}
+
+// migrateV0ToV1 upgrades files written before schemaVersion existed.
+// Their keys are unchanged; plaintext API keys are moved into the secret
+// store separately by ResolveSecrets.
+func migrateV0ToV1(values map[string]interface{}) error {
+	return nil
+}
+
+// schemaVersionOf returns the schema version recorded in raw config values
+func schemaVersionOf(values map[string]interface{}) (int, error) {
+	raw, ok := values[schemaVersionKey]
+	if !ok {
+		return 0, nil
+	}
+	version, ok := raw.(int64)
+	if !ok || version < 0 {
+		return 0, fmt.Errorf("invalid %s: %v", schemaVersionKey, raw)
+	}
+	return int(version), nil
+}
+
+// migrate upgrades raw config values to CurrentSchemaVersion in place and
+// returns the version they started at
+func migrate(values map[string]interface{}) (int, error) {
+	from, err := schemaVersionOf(values)
+	if err != nil {
+		return 0, err
+	}
+	if from > CurrentSchemaVersion {
+		return from, fmt.Errorf("config schema version %d is newer than supported version %d", from, CurrentSchemaVersion)
+	}
+
+	for version := from; version < CurrentSchemaVersion; version++ {
+		if err := migrators[version](values); err != nil {
+			return from, fmt.Errorf("failed to migrate config from version %d to %d: %w", version, version+1, err)
+		}
+	}
+	values[schemaVersionKey] = int64(CurrentSchemaVersion)
+	return from, nil
+}
+
+// migrateFile upgrades config.toml to the current schema if needed.
+// The original file is kept as config.toml.v<N>.bak before it is rewritten,
+// without any plaintext API keys.
+// It returns the (possibly rewritten) file contents.
+func migrateFile(configFile string, data []byte) ([]byte, error) {
+	values := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	from, err := schemaVersionOf(values)
+	if err != nil {
+		return nil, err
+	}
+	if from == CurrentSchemaVersion {
+		return data, nil
+	}
+	if from > CurrentSchemaVersion {
+		// Written by a newer version; load what we understand and keep the rest
+		fmt.Printf("Warning: config schema version %d is newer than supported version %d\n", from, CurrentSchemaVersion)
+		return data, nil
+	}
+
+	if _, err := migrate(values); err != nil {
+		return nil, err
+	}
+
+	backup, err := withoutLegacySecrets(data)
+	if err != nil {
+		return nil, err
+	}
+	backupFile := fmt.Sprintf("%s.v%d.bak", configFile, from)
+	if err := writeFileAtomic(backupFile, backup, 0644); err != nil {
+		return nil, fmt.Errorf("failed to back up config before migration: %w", err)
+	}
+
+	if err := writeValues(configFile, values); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Migrated config from schema version %d to %d (backup: %s)\n", from, CurrentSchemaVersion, backupFile)
+
+	return os.ReadFile(configFile)
+}
+
+// withoutLegacySecrets returns config file data without the plaintext API
+// keys of older versions. ResolveSecrets moves those keys into the secret
+// store and out of config.toml, so a backup must not keep a copy of them.
+func withoutLegacySecrets(data []byte) ([]byte, error) {
+	values := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	found := false
+	for key := range legacySecretKeys {
+		if _, ok := values[key]; ok {
+			delete(values, key)
+			found = true
+		}
+	}
+	if !found {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// warnUndecoded prints a warning for every config.toml key that Config does
+// not know about. Such keys are preserved by Save rather than dropped.
+func warnUndecoded(meta toml.MetaData) []string {
+	var unknown []string
+	for _, key := range meta.Undecoded() {
+		name := key.String()
+		if name == schemaVersionKey || legacySecretKeys[name] {
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+
+	if len(unknown) > 0 {
+		fmt.Printf("Warning: unknown config keys will be preserved but ignored: %s\n", strings.Join(unknown, ", "))
+	}
+	return unknown
+}
+
+// preserveUnknown copies values from existing that are missing in values,
+// recursing into tables, so keys this version does not know survive a Save.
+// Tables keyed by name, such as usage.prices, are written whole, so entries
+// removed from them stay removed.
+func preserveUnknown(values, existing map[string]interface{}) {
+	preserveUnknownIn(values, existing, "", nameKeyedTables())
+}
+
+// preserveUnknownIn preserves the unknown keys of the table at prefix
+func preserveUnknownIn(values, existing map[string]interface{}, prefix string, skip map[string]bool) {
+	for key, old := range existing {
+		current, ok := values[key]
+		if !ok {
+			if !skip[prefix+key] {
+				values[key] = old
+			}
+			continue
+		}
+		oldTable, oldIsTable := old.(map[string]interface{})
+		currentTable, currentIsTable := current.(map[string]interface{})
+		if oldIsTable && currentIsTable && !skip[prefix+key] {
+			preserveUnknownIn(currentTable, oldTable, prefix+key+".", skip)
+		}
+	}
+}
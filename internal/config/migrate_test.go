@@ -0,0 +1,258 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"talus_helper_windows/internal/secrets"
+	"talus_helper_windows/internal/usage"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Run("unversioned file", func(t *testing.T) {
+		values := map[string]interface{}{"theme": "dark"}
+		from, err := migrate(values)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if from != 0 {
+			t.Errorf("Expected to migrate from version 0, got %d", from)
+		}
+		if values[schemaVersionKey] != int64(CurrentSchemaVersion) {
+			t.Errorf("Expected schemaVersion %d, got %v", CurrentSchemaVersion, values[schemaVersionKey])
+		}
+		if values["theme"] != "dark" {
+			t.Errorf("Expected theme to be kept, got %v", values["theme"])
+		}
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		values := map[string]interface{}{schemaVersionKey: int64(CurrentSchemaVersion + 1)}
+		if _, err := migrate(values); err == nil {
+			t.Error("Expected error for newer schema version")
+		}
+	})
+
+	t.Run("every version has a migrator", func(t *testing.T) {
+		if len(migrators) != CurrentSchemaVersion {
+			t.Errorf("Expected %d migrators, got %d", CurrentSchemaVersion, len(migrators))
+		}
+	})
+}
+
+func TestMigrateFile_BacksUpOriginal(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.toml")
+	original := []byte("theme = \"dark\"\n")
+	if err := os.WriteFile(configFile, original, 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	data, err := migrateFile(configFile, original)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(data), "schemaVersion = 1") {
+		t.Errorf("Expected migrated file to contain schemaVersion, got:\n%s", data)
+	}
+
+	backup, err := os.ReadFile(configFile + ".v0.bak")
+	if err != nil {
+		t.Fatalf("Expected backup file, got %v", err)
+	}
+	if string(backup) != string(original) {
+		t.Errorf("Expected backup to match original, got:\n%s", backup)
+	}
+}
+
+func TestMigrateFile_LeavesNoPlaintextKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "")
+
+	configFile, err := Path()
+	if err != nil {
+		t.Fatalf("Failed to get config path: %v", err)
+	}
+	const key = "sk-legacyplaintextkey0123456789"
+	if err := os.WriteFile(configFile, []byte("theme = \"dark\"\nopenAIAPIKey = \""+key+"\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	dir := filepath.Dir(configFile)
+	store, err := secrets.NewFileStore(filepath.Join(dir, "secrets.enc"), "passphrase")
+	if err != nil {
+		t.Fatalf("Failed to open secret store: %v", err)
+	}
+	if err := ResolveSecrets(cfg, store); err != nil {
+		t.Fatalf("Failed to resolve secrets: %v", err)
+	}
+	if cfg.OpenAIAPIKey != key {
+		t.Errorf("Expected the key moved into the store, got %q", cfg.OpenAIAPIKey)
+	}
+
+	backup, err := os.ReadFile(configFile + ".v0.bak")
+	if err != nil {
+		t.Fatalf("Expected backup file, got %v", err)
+	}
+	if !strings.Contains(string(backup), "dark") {
+		t.Errorf("Expected the backup to keep the other settings, got:\n%s", backup)
+	}
+	err = filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(data), key) {
+			t.Errorf("Expected no plaintext key in %s", filepath.Base(path))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk data directory: %v", err)
+	}
+}
+
+func TestResolveSecrets_WithoutStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "sk-from-env")
+	t.Setenv("WORKFLOWY_API_KEY", "wf-from-env")
+
+	configFile, err := Path()
+	if err != nil {
+		t.Fatalf("Failed to get config path: %v", err)
+	}
+	if err := os.WriteFile(configFile, []byte("workflowyAPIKey = \"wf-from-file\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if err := ResolveSecrets(cfg, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.OpenAIAPIKey != "sk-from-env" {
+		t.Errorf("Expected the key from the environment, got %q", cfg.OpenAIAPIKey)
+	}
+	if cfg.WorkflowyAPIKey != "wf-from-file" {
+		t.Errorf("Expected the key still in config.toml, got %q", cfg.WorkflowyAPIKey)
+	}
+}
+
+func TestSave_PreservesUnknownKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	configFile, err := Path()
+	if err != nil {
+		t.Fatalf("Failed to get config path: %v", err)
+	}
+	existing := []byte("schemaVersion = 1\ntheme = \"dark\"\nfutureSetting = \"keep me\"\n")
+	if err := os.WriteFile(configFile, existing, 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := Save(GetDefault(), nil, nil); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+
+	var values map[string]interface{}
+	if err := toml.Unmarshal(data, &values); err != nil {
+		t.Fatalf("Failed to parse saved config: %v", err)
+	}
+	if values["futureSetting"] != "keep me" {
+		t.Errorf("Expected unknown key to be preserved, got %v", values["futureSetting"])
+	}
+	if values["theme"] != "light" {
+		t.Errorf("Expected theme to be overwritten, got %v", values["theme"])
+	}
+}
+
+func TestSave_RemovesNameKeyedEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	configFile, err := Path()
+	if err != nil {
+		t.Fatalf("Failed to get config path: %v", err)
+	}
+	existing := []byte("schemaVersion = 1\n[transform.chains]\nold = [\"trim\"]\n[transform.future]\nkeep = true\n")
+	if err := os.WriteFile(configFile, existing, 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg := GetDefault()
+	cfg.Transform.Chains = map[string][]string{"new": {"upper"}}
+	if err := Save(cfg, nil, nil); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	loaded, _, err := load(data, func(string) (string, bool) { return "", false }, nil)
+	if err != nil {
+		t.Fatalf("Failed to load saved config: %v", err)
+	}
+	if _, ok := loaded.Transform.Chains["old"]; ok || len(loaded.Transform.Chains["new"]) != 1 {
+		t.Errorf("Expected only the saved chain, got %v", loaded.Transform.Chains)
+	}
+
+	var values map[string]interface{}
+	if err := toml.Unmarshal(data, &values); err != nil {
+		t.Fatalf("Failed to parse saved config: %v", err)
+	}
+	if future, _ := values["transform"].(map[string]interface{})["future"].(map[string]interface{}); future["keep"] != true {
+		t.Errorf("Expected the unknown table to be preserved, got %v", values["transform"])
+	}
+}
+
+func TestSave_UsagePricesRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := GetDefault()
+	cfg.Usage.Prices = map[string]usage.Price{
+		"gpt-4o":      {Prompt: 2.5, Completion: 10},
+		"gpt-4o-mini": {Prompt: 0.15, Completion: 0.6},
+	}
+	if err := Save(cfg, nil, nil); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	loaded, provenance, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(loaded.Usage.Prices) != 2 || loaded.Usage.Prices["gpt-4o"].Completion != 10 {
+		t.Fatalf("Expected both prices back, got %+v", loaded.Usage.Prices)
+	}
+
+	// A removed price stays removed after saving over the previous file
+	edited := *loaded
+	edited.Usage.Prices = map[string]usage.Price{"gpt-4o": loaded.Usage.Prices["gpt-4o"]}
+	if err := Save(edited, loaded, provenance); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	reloaded, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if _, ok := reloaded.Usage.Prices["gpt-4o-mini"]; ok || len(reloaded.Usage.Prices) != 1 {
+		t.Errorf("Expected only the gpt-4o price, got %+v", reloaded.Usage.Prices)
+	}
+}
@@ -0,0 +1,279 @@
+// Package httpx builds the HTTP clients used for API calls, with a shared
+// transport per proxy and TLS setting, a user-agent, and optional logging of
+// every request with its latency and credentials redacted.
+package httpx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a whole request when no timeout is given
+const DefaultTimeout = 30 * time.Second
+
+// DefaultConnectTimeout bounds connecting and the TLS handshake when no connect timeout is given
+const DefaultConnectTimeout = 10 * time.Second
+
+// DefaultUserAgent is sent when no user-agent is given
+const DefaultUserAgent = "talus-helper"
+
+// Options configures a client built by NewClient
+type Options struct {
+	// ProxyURL is the proxy for all requests; empty uses the HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY environment variables
+	ProxyURL string
+	// CAFile is a PEM file of certificates trusted in addition to the system ones
+	CAFile string
+	// InsecureSkipVerify disables TLS certificate checks; for development only
+	InsecureSkipVerify bool
+	// Timeout bounds a whole request, including reading the response body
+	Timeout time.Duration
+	// ConnectTimeout bounds connecting and the TLS handshake
+	ConnectTimeout time.Duration
+	UserAgent      string
+	// Log, if set, is called after every request
+	Log func(entry Entry)
+}
+
+// Entry describes a finished request for logging. URL and Header have
+// credentials redacted.
+type Entry struct {
+	Method  string
+	URL     string
+	Header  http.Header
+	Status  int
+	Latency time.Duration
+	Err     error
+}
+
+// String formats the entry as a single log line, with the request headers
+func (e Entry) String() string {
+	result := fmt.Sprintf("%d", e.Status)
+	if e.Err != nil {
+		result = "error: " + e.Err.Error()
+	}
+
+	names := make([]string, 0, len(e.Header))
+	for name := range e.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := make([]string, 0, len(names))
+	for _, name := range names {
+		headers = append(headers, name+": "+strings.Join(e.Header[name], ", "))
+	}
+
+	return fmt.Sprintf("HTTP %s %s -> %s in %s [%s]", e.Method, e.URL, result,
+		e.Latency.Round(time.Millisecond), strings.Join(headers, "; "))
+}
+
+// transportKey identifies the settings a transport is built from. The CA
+// file's modification time and size are part of it, so a replaced file is
+// read again.
+type transportKey struct {
+	proxyURL           string
+	caFile             string
+	caModTime          int64
+	caSize             int64
+	insecureSkipVerify bool
+	connectTimeout     time.Duration
+}
+
+// transports caches one transport per setting, so clients share connections
+var transports sync.Map
+
+// NewClient returns an HTTP client for opts. Clients with the same proxy,
+// TLS and connect timeout settings share a transport, until the CA file
+// changes. An invalid proxy URL or CA file is an error.
+func NewClient(opts Options) (*http.Client, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = DefaultConnectTimeout
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	key := transportKey{
+		proxyURL:           strings.TrimSpace(opts.ProxyURL),
+		caFile:             strings.TrimSpace(opts.CAFile),
+		insecureSkipVerify: opts.InsecureSkipVerify,
+		connectTimeout:     opts.ConnectTimeout,
+	}
+	if key.caFile != "" {
+		info, err := os.Stat(key.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		key.caModTime = info.ModTime().UnixNano()
+		key.caSize = info.Size()
+	}
+	base, err := transport(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &roundTripper{base: base, userAgent: opts.UserAgent, log: opts.Log},
+	}, nil
+}
+
+// DefaultClient returns a client with the default settings and the given timeout
+func DefaultClient(timeout time.Duration) *http.Client {
+	// Without a proxy URL or CA file there is nothing that can fail
+	client, _ := NewClient(Options{Timeout: timeout})
+	return client
+}
+
+// transport returns the cached transport for key, building it on first use
+func transport(key transportKey) (http.RoundTripper, error) {
+	if cached, ok := transports.Load(key); ok {
+		return cached.(http.RoundTripper), nil
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if key.proxyURL != "" {
+		proxyURL, err := url.Parse(key.proxyURL)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", key.proxyURL)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: key.insecureSkipVerify,
+	}
+	if key.caFile != "" {
+		pool, err := certPool(key.caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	dialer := &net.Dialer{Timeout: key.connectTimeout, KeepAlive: 30 * time.Second}
+	t := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   key.connectTimeout,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	cached, _ := transports.LoadOrStore(key, t)
+	return cached.(http.RoundTripper), nil
+}
+
+// certPool returns the system certificates together with those in caFile
+func certPool(caFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA file %s contains no PEM certificates", caFile)
+	}
+	return pool, nil
+}
+
+// roundTripper sets the user-agent and logs requests on top of a shared transport
+type roundTripper struct {
+	base      http.RoundTripper
+	userAgent string
+	log       func(entry Entry)
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// A RoundTripper must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if t.log != nil {
+		entry := Entry{
+			Method:  req.Method,
+			URL:     RedactURL(req.URL),
+			Header:  RedactHeader(req.Header),
+			Latency: time.Since(start),
+			Err:     err,
+		}
+		if resp != nil {
+			entry.Status = resp.StatusCode
+		}
+		t.log(entry)
+	}
+	return resp, err
+}
+
+// redacted replaces secret values in logs
+const redacted = "REDACTED"
+
+// sensitiveHeaders are headers whose values are credentials
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Api-Key"}
+
+// sensitiveParams are query parameters whose values are credentials
+var sensitiveParams = []string{"key", "api_key", "apikey", "access_token", "token"}
+
+// RedactHeader returns a copy of header with credentials replaced
+func RedactHeader(header http.Header) http.Header {
+	clone := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if values := clone.Values(name); len(values) > 0 {
+			clone.Set(name, redacted)
+		}
+	}
+	return clone
+}
+
+// RedactURL returns u as a string with its password and credential query
+// parameters replaced
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	clone := *u
+	if _, ok := clone.User.Password(); ok {
+		clone.User = url.UserPassword(clone.User.Username(), redacted)
+	}
+	if clone.RawQuery != "" {
+		query := clone.Query()
+		changed := false
+		for name := range query {
+			for _, sensitive := range sensitiveParams {
+				if strings.EqualFold(name, sensitive) {
+					query.Set(name, redacted)
+					changed = true
+				}
+			}
+		}
+		if changed {
+			clone.RawQuery = query.Encode()
+		}
+	}
+	return clone.String()
+}
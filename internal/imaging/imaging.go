@@ -0,0 +1,274 @@
+// Package imaging prepares images for vision models: it decodes any format
+// clipboard.Decode reads, crops, downscales, adjusts the image and re-encodes it
+// as PNG or JPEG within a payload size limit.
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+
+	"talus_helper_windows/internal/clipboard"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Output formats
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+)
+
+// minEdge is the smallest long edge an image is shrunk to when enforcing MaxBytes
+const minEdge = 256
+
+// minJPEGQuality is the lowest quality tried when enforcing MaxBytes
+const minJPEGQuality = 50
+
+// Options describes how an image is processed. Zero values disable a step.
+type Options struct {
+	// Format is FormatPNG or FormatJPEG; empty means PNG
+	Format string
+	// MaxEdge is the largest width or height, in pixels
+	MaxEdge int
+	// Grayscale drops the colour channels
+	Grayscale bool
+	// Contrast adjusts contrast from -100 to 100 percent
+	Contrast float64
+	// Crop trims pixels from each edge before any other step
+	Crop Insets
+	// MaxBytes is the largest encoded size. PNG images that are too large are
+	// re-encoded as JPEG, then at lower quality, then at smaller sizes.
+	MaxBytes int
+	// JPEGQuality is the starting JPEG quality; 0 means 85
+	JPEGQuality int
+}
+
+// Insets are the pixels to remove from each edge of an image
+type Insets struct {
+	Left, Top, Right, Bottom int
+}
+
+// IsZero reports whether no pixels are removed
+func (i Insets) IsZero() bool {
+	return i == Insets{}
+}
+
+// Result is a processed image
+type Result struct {
+	Data   []byte
+	Format string
+	Width  int
+	Height int
+}
+
+// Process decodes data and applies opts. The original bytes are returned
+// unchanged when they are already in the output format and no step applies.
+func Process(data []byte, opts Options) (Result, error) {
+	outFormat := opts.Format
+	if outFormat == "" {
+		outFormat = FormatPNG
+	}
+	if outFormat != FormatPNG && outFormat != FormatJPEG {
+		return Result{}, fmt.Errorf("unsupported output format %q", opts.Format)
+	}
+
+	img, format, err := clipboard.Decode(data)
+	if err != nil {
+		return Result{}, err
+	}
+	bounds := img.Bounds()
+
+	untouched := format == outFormat &&
+		opts.Crop.IsZero() && !opts.Grayscale && opts.Contrast == 0 &&
+		(opts.MaxEdge <= 0 || max(bounds.Dx(), bounds.Dy()) <= opts.MaxEdge) &&
+		(opts.MaxBytes <= 0 || len(data) <= opts.MaxBytes)
+	if untouched {
+		return Result{Data: data, Format: format, Width: bounds.Dx(), Height: bounds.Dy()}, nil
+	}
+
+	if !opts.Crop.IsZero() {
+		if img, err = Crop(img, opts.Crop); err != nil {
+			return Result{}, err
+		}
+	}
+	if opts.MaxEdge > 0 {
+		img = Fit(img, opts.MaxEdge)
+	}
+	if opts.Grayscale {
+		img = Grayscale(img)
+	}
+	if opts.Contrast != 0 {
+		img = AdjustContrast(img, opts.Contrast)
+	}
+
+	return encodeWithin(img, outFormat, opts)
+}
+
+// Thumbnail decodes data and returns it scaled down to fit in maxEdge
+// pixels, encoded as PNG
+func Thumbnail(data []byte, maxEdge int) (Result, error) {
+	if maxEdge <= 0 {
+		return Result{}, fmt.Errorf("thumbnail size must be positive")
+	}
+	img, _, err := clipboard.Decode(data)
+	if err != nil {
+		return Result{}, err
+	}
+	img = Fit(img, maxEdge)
+	thumbnail, err := Encode(img, FormatPNG, 0)
+	if err != nil {
+		return Result{}, err
+	}
+	bounds := img.Bounds()
+	return Result{Data: thumbnail, Format: FormatPNG, Width: bounds.Dx(), Height: bounds.Dy()}, nil
+}
+
+// encodeWithin encodes img, lowering quality and size until it fits in opts.MaxBytes
+func encodeWithin(img image.Image, format string, opts Options) (Result, error) {
+	quality := opts.JPEGQuality
+	if quality <= 0 || quality > 100 {
+		quality = 85
+	}
+
+	for {
+		data, err := Encode(img, format, quality)
+		if err != nil {
+			return Result{}, err
+		}
+		if opts.MaxBytes <= 0 || len(data) <= opts.MaxBytes {
+			bounds := img.Bounds()
+			return Result{Data: data, Format: format, Width: bounds.Dx(), Height: bounds.Dy()}, nil
+		}
+
+		switch {
+		case format == FormatPNG:
+			// Photos and screenshots with gradients compress far better as JPEG
+			format = FormatJPEG
+		case quality > minJPEGQuality:
+			quality = max(minJPEGQuality, quality-15)
+		default:
+			bounds := img.Bounds()
+			edge := max(bounds.Dx(), bounds.Dy())
+			if edge <= minEdge {
+				return Result{}, fmt.Errorf("image does not fit in %d bytes (%d bytes at %dx%d)",
+					opts.MaxBytes, len(data), bounds.Dx(), bounds.Dy())
+			}
+			img = Fit(img, max(minEdge, edge*3/4))
+		}
+	}
+}
+
+// Encode encodes img as PNG or JPEG. Transparent areas become white in JPEG.
+func Encode(img image.Image, format string, jpegQuality int) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatPNG:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode PNG: %w", err)
+		}
+	case FormatJPEG:
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// Crop removes insets from the edges of img
+func Crop(img image.Image, insets Insets) (image.Image, error) {
+	bounds := img.Bounds()
+	if insets.Left < 0 || insets.Top < 0 || insets.Right < 0 || insets.Bottom < 0 {
+		return nil, fmt.Errorf("crop insets must not be negative")
+	}
+	// Build the rectangle directly; image.Rect would swap inverted coordinates
+	rect := image.Rectangle{
+		Min: image.Pt(bounds.Min.X+insets.Left, bounds.Min.Y+insets.Top),
+		Max: image.Pt(bounds.Max.X-insets.Right, bounds.Max.Y-insets.Bottom),
+	}
+	if rect.Empty() {
+		return nil, fmt.Errorf("crop %+v leaves nothing of a %dx%d image", insets, bounds.Dx(), bounds.Dy())
+	}
+
+	cropped := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, rect.Min, draw.Src)
+	return cropped, nil
+}
+
+// Fit scales img down, keeping its aspect ratio, so that neither side is
+// longer than maxEdge. Smaller images are returned unchanged.
+func Fit(img image.Image, maxEdge int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if maxEdge <= 0 || (width <= maxEdge && height <= maxEdge) {
+		return img
+	}
+
+	scale := float64(maxEdge) / float64(max(width, height))
+	newWidth := max(1, int(math.Round(float64(width)*scale)))
+	newHeight := max(1, int(math.Round(float64(height)*scale)))
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, newWidth, newHeight))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, xdraw.Src, nil)
+	return scaled
+}
+
+// Grayscale converts img to 8-bit grayscale
+func Grayscale(img image.Image) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray, gray.Bounds(), flatten(img), bounds.Min, draw.Src)
+	return gray
+}
+
+// AdjustContrast scales the distance of every channel from mid-gray.
+// percent ranges from -100 (flat gray) to 100 (maximum contrast).
+func AdjustContrast(img image.Image, percent float64) image.Image {
+	percent = math.Max(-100, math.Min(100, percent))
+	// Standard contrast correction factor, with c from -255 to 255
+	c := percent * 2.55
+	factor := (259 * (c + 255)) / (255 * (259 - c))
+
+	var lut [256]uint8
+	for i := range lut {
+		v := factor*(float64(i)-128) + 128
+		lut[i] = uint8(math.Max(0, math.Min(255, math.Round(v))))
+	}
+
+	if gray, ok := img.(*image.Gray); ok {
+		out := image.NewGray(gray.Bounds())
+		for i, v := range gray.Pix {
+			out.Pix[i] = lut[v]
+		}
+		return out
+	}
+
+	bounds := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Src)
+	for i := 0; i < len(out.Pix); i += 4 {
+		out.Pix[i] = lut[out.Pix[i]]
+		out.Pix[i+1] = lut[out.Pix[i+1]]
+		out.Pix[i+2] = lut[out.Pix[i+2]]
+	}
+	return out
+}
+
+// flatten draws img over a white background, removing transparency
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Over)
+	return out
+}
@@ -0,0 +1,232 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"talus_helper_windows/internal/clipboard"
+
+	"golang.org/x/image/bmp"
+)
+
+// gradient returns a width x height image with a colour gradient
+func gradient(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// noise returns an image of random pixels, which compresses badly
+func noise(width, height int) *image.NRGBA {
+	rng := rand.New(rand.NewPCG(1, 2))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.UintN(256))
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decode(t *testing.T, result Result) image.Image {
+	t.Helper()
+	img, format, err := clipboard.Decode(result.Data)
+	if err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if format != result.Format {
+		t.Errorf("Expected format %s, got %s", result.Format, format)
+	}
+	return img
+}
+
+func TestProcess_Untouched(t *testing.T) {
+	data := encodePNG(t, gradient(100, 50))
+
+	result, err := Process(data, Options{MaxEdge: 200, MaxBytes: len(data)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(result.Data, data) || result.Format != FormatPNG {
+		t.Error("Expected the original PNG to be returned unchanged")
+	}
+	if result.Width != 100 || result.Height != 50 {
+		t.Errorf("Expected 100x50, got %dx%d", result.Width, result.Height)
+	}
+}
+
+func TestProcess_ConvertsToConfiguredFormat(t *testing.T) {
+	data := encodePNG(t, gradient(100, 50))
+
+	result, err := Process(data, Options{Format: FormatJPEG})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Format != FormatJPEG || bytes.Equal(result.Data, data) {
+		t.Errorf("Expected the PNG converted to JPEG, got %s", result.Format)
+	}
+	decode(t, result)
+}
+
+func TestProcess_ConvertsBMP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := bmp.Encode(&buf, gradient(40, 30)); err != nil {
+		t.Fatalf("Failed to encode BMP: %v", err)
+	}
+
+	result, err := Process(buf.Bytes(), Options{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	img := decode(t, result)
+	if result.Format != FormatPNG || img.Bounds().Dx() != 40 || img.Bounds().Dy() != 30 {
+		t.Errorf("Expected a 40x30 PNG, got %s %v", result.Format, img.Bounds())
+	}
+}
+
+func TestProcess_Downscale(t *testing.T) {
+	tests := []struct {
+		name                  string
+		width, height         int
+		maxEdge               int
+		wantWidth, wantHeight int
+	}{
+		{"landscape", 4000, 2000, 1000, 1000, 500},
+		{"portrait", 300, 1200, 600, 150, 600},
+		{"smaller than max", 300, 200, 1000, 300, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(encodePNG(t, gradient(tt.width, tt.height)), Options{MaxEdge: tt.maxEdge, Format: FormatJPEG})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			bounds := decode(t, result).Bounds()
+			if bounds.Dx() != tt.wantWidth || bounds.Dy() != tt.wantHeight {
+				t.Errorf("Expected %dx%d, got %dx%d", tt.wantWidth, tt.wantHeight, bounds.Dx(), bounds.Dy())
+			}
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	result, err := Thumbnail(encodePNG(t, gradient(640, 320)), 160)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	bounds := decode(t, result).Bounds()
+	if result.Format != FormatPNG || bounds.Dx() != 160 || bounds.Dy() != 80 {
+		t.Errorf("Expected a 160x80 PNG, got %s %dx%d", result.Format, bounds.Dx(), bounds.Dy())
+	}
+
+	if _, err := Thumbnail([]byte("not an image"), 160); err == nil {
+		t.Error("Expected an error for invalid image data")
+	}
+}
+
+func TestProcess_CropAndGrayscale(t *testing.T) {
+	result, err := Process(encodePNG(t, gradient(100, 80)), Options{
+		Crop:      Insets{Left: 10, Top: 20, Right: 30, Bottom: 40},
+		Grayscale: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	img := decode(t, result)
+	if img.Bounds().Dx() != 60 || img.Bounds().Dy() != 20 {
+		t.Errorf("Expected 60x20, got %v", img.Bounds())
+	}
+	if _, ok := img.(*image.Gray); !ok {
+		t.Errorf("Expected a grayscale PNG, got %T", img)
+	}
+}
+
+func TestProcess_CropTooLarge(t *testing.T) {
+	_, err := Process(encodePNG(t, gradient(50, 50)), Options{Crop: Insets{Left: 30, Right: 30}})
+	if err == nil || !strings.Contains(err.Error(), "leaves nothing") {
+		t.Errorf("Expected crop error, got %v", err)
+	}
+}
+
+func TestProcess_MaxBytes(t *testing.T) {
+	data := encodePNG(t, noise(800, 600))
+	limit := 60 * 1024
+
+	result, err := Process(data, Options{MaxBytes: limit})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Data) > limit {
+		t.Errorf("Expected at most %d bytes, got %d", limit, len(result.Data))
+	}
+	if result.Format != FormatJPEG {
+		t.Errorf("Expected fallback to JPEG, got %s", result.Format)
+	}
+	decode(t, result)
+}
+
+func TestProcess_MaxBytesUnreachable(t *testing.T) {
+	_, err := Process(encodePNG(t, noise(300, 300)), Options{MaxBytes: 100})
+	if err == nil || !strings.Contains(err.Error(), "does not fit") {
+		t.Errorf("Expected size error, got %v", err)
+	}
+}
+
+func TestProcess_InvalidInput(t *testing.T) {
+	if _, err := Process([]byte("not an image"), Options{}); err == nil {
+		t.Error("Expected decode error")
+	}
+	if _, err := Process(encodePNG(t, gradient(10, 10)), Options{Format: "gif"}); err == nil {
+		t.Error("Expected unsupported format error")
+	}
+}
+
+func TestAdjustContrast(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.Pix = []uint8{100, 160}
+
+	more := AdjustContrast(img, 50).(*image.Gray)
+	if more.Pix[0] >= 100 || more.Pix[1] <= 160 {
+		t.Errorf("Expected values pushed away from mid-gray, got %v", more.Pix)
+	}
+
+	flat := AdjustContrast(img, -100).(*image.Gray)
+	if flat.Pix[0] != 128 || flat.Pix[1] != 128 {
+		t.Errorf("Expected flat gray, got %v", flat.Pix)
+	}
+}
+
+func TestEncode_JPEGFlattensTransparency(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+
+	data, err := Encode(img, FormatJPEG, 90)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	decoded, _, err := clipboard.Decode(data)
+	if err != nil {
+		t.Fatalf("Failed to decode JPEG: %v", err)
+	}
+	r, g, b, _ := decoded.At(4, 4).RGBA()
+	if r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("Expected white, got %d,%d,%d", r>>8, g>>8, b>>8)
+	}
+}
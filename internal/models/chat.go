@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Conversation represents a chat with the assistant
+type Conversation struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ChatMessage represents one message of a conversation. Image holds an
+// attached image, if any, in ImageFormat.
+type ChatMessage struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversationId"`
+	Role           string    `json:"role"`
+	Content        string    `json:"content"`
+	Image          []byte    `json:"image,omitempty"`
+	ImageFormat    string    `json:"imageFormat,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Tool invocation statuses
+const (
+	ToolStatusOK       = "ok"
+	ToolStatusFailed   = "failed"
+	ToolStatusDeclined = "declined"
+)
+
+// ToolInvocation records a tool called by the assistant in a conversation,
+// with its JSON arguments and the result returned to the model
+type ToolInvocation struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversationId"`
+	Name           string    `json:"name"`
+	Arguments      string    `json:"arguments"`
+	Result         string    `json:"result"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
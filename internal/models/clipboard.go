@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ClipboardEntry represents text or an image kept in the clipboard history.
+// Image holds the full image and is only loaded when the entry is copied
+// again; lists carry the Thumbnail instead. OCRText is the text read from
+// the image, once OCR has run on it. Sensitive lists the kinds of sensitive
+// data, such as "password", found in the text or OCR text.
+type ClipboardEntry struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Text      string    `json:"text"`
+	OCRText   string    `json:"ocrText"`
+	Image     []byte    `json:"-"`
+	Format    string    `json:"format"`
+	Thumbnail []byte    `json:"thumbnail"`
+	Hash      string    `json:"hash"`
+	Size      int       `json:"size"`
+	Pinned    bool      `json:"pinned"`
+	Sensitive []string  `json:"sensitive"`
+	CreatedAt time.Time `json:"createdAt"`
+	// CopiedAt is the last time the content was put on the clipboard
+	CopiedAt time.Time `json:"copiedAt"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// OCRResult represents a stored OCR result. Translation and Language are
+// set when the text was also translated.
+type OCRResult struct {
+	ID          string    `json:"id"`
+	Mode        string    `json:"mode"`
+	Text        string    `json:"text"`
+	Translation string    `json:"translation"`
+	Language    string    `json:"language"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Sources of semantic search documents
+const (
+	SourceTodo      = "todo"
+	SourceOCR       = "ocr"
+	SourceWorkflowy = "workflowy"
+)
+
+// Embedding is the stored vector of a document, such as a todo. Hash
+// identifies the model and text it was computed from, so a vector is only
+// recomputed when either changes. Text is kept for showing search results.
+type Embedding struct {
+	Source    string    `json:"source"`
+	SourceID  string    `json:"sourceId"`
+	Model     string    `json:"model"`
+	Hash      string    `json:"hash"`
+	Text      string    `json:"text"`
+	Vector    []float32 `json:"-"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SearchResult is a document matching a semantic search, with its cosine
+// similarity to the query
+type SearchResult struct {
+	Source   string  `json:"source"`
+	SourceID string  `json:"sourceId"`
+	Text     string  `json:"text"`
+	Score    float64 `json:"score"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// UsageRecord represents one LLM call for usage and cost accounting
+type UsageRecord struct {
+	ID               string    `json:"id"`
+	Feature          string    `json:"feature"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"promptTokens"`
+	CompletionTokens int       `json:"completionTokens"`
+	LatencyMs        int64     `json:"latencyMs"`
+	Cost             float64   `json:"cost"`
+	Error            string    `json:"error"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
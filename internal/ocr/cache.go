@@ -0,0 +1,185 @@
+package ocr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores OCR results on disk, one file per key. Entries expire after
+// the TTL, and the least recently used ones are evicted once the cache
+// grows beyond its size limit.
+type Cache struct {
+	dir      string
+	ttl      time.Duration
+	maxBytes int64
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// cacheEntry is the JSON content of a cache file
+type cacheEntry struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewCache returns a cache in dir. A ttl or maxBytes of 0 disables that limit.
+func NewCache(dir string, ttl time.Duration, maxBytes int64) *Cache {
+	return &Cache{
+		dir:      dir,
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		now:      time.Now,
+	}
+}
+
+// CacheKey returns the SHA-256 of the image and the parts that affect the
+// result, such as the provider, model and prompts
+func CacheKey(image []byte, parts ...string) string {
+	hash := sha256.New()
+	hash.Write(image)
+	for _, part := range parts {
+		// Separate the parts so that ("ab", "c") and ("a", "bc") differ
+		hash.Write([]byte{0})
+		hash.Write([]byte(part))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the cached text for key, if present and not expired
+func (c *Cache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || c.expired(entry.CreatedAt) {
+		os.Remove(path)
+		return "", false
+	}
+
+	// Mark the entry as recently used for eviction
+	now := c.now()
+	os.Chtimes(path, now, now)
+	return entry.Text, true
+}
+
+// Put stores text under key and evicts old entries
+func (c *Cache) Put(key, text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(cacheEntry{Text: text, CreatedAt: c.now()})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	// Write through a temporary file so readers never see a partial entry
+	path := c.path(key)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	now := c.now()
+	os.Chtimes(path, now, now)
+
+	return c.evict(path)
+}
+
+// Clear removes every cache entry
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
+
+// evict removes expired entries, then the least recently used ones until
+// the cache fits in maxBytes. It works from file info alone: an entry is
+// only touched when used, so one unused for longer than the TTL is expired,
+// and Get catches the rest. The entry at keep, just written, is never removed.
+func (c *Cache) evict(keep string) error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	type file struct {
+		path   string
+		size   int64
+		usedAt time.Time
+	}
+	var files []file
+	var total int64
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), ".json") {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.dir, dirEntry.Name())
+		total += info.Size()
+		if path == keep {
+			continue
+		}
+
+		if c.expired(info.ModTime()) {
+			os.Remove(path)
+			total -= info.Size()
+			continue
+		}
+		files = append(files, file{path: path, size: info.Size(), usedAt: info.ModTime()})
+	}
+
+	if c.maxBytes <= 0 || total <= c.maxBytes {
+		return nil
+	}
+
+	// Least recently used first
+	sort.Slice(files, func(i, j int) bool { return files[i].usedAt.Before(files[j].usedAt) })
+	for _, f := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			return fmt.Errorf("failed to evict cache entry: %w", err)
+		}
+		total -= f.size
+	}
+	return nil
+}
+
+// expired reports whether an entry created at createdAt is past the TTL
+func (c *Cache) expired(createdAt time.Time) bool {
+	return c.ttl > 0 && c.now().Sub(createdAt) > c.ttl
+}
+
+// path returns the file holding key
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
@@ -0,0 +1,141 @@
+package ocr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a clock function and a way to move it forward
+func fakeClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	return func() time.Time { return now }, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCacheKey(t *testing.T) {
+	image := []byte("image")
+	key := CacheKey(image, "openai", "gpt-4o", "prompt")
+	if len(key) != 64 {
+		t.Errorf("Expected a hex SHA-256, got %q", key)
+	}
+	if key != CacheKey(image, "openai", "gpt-4o", "prompt") {
+		t.Error("Expected the same key for the same inputs")
+	}
+	if key == CacheKey(image, "openai", "gpt-4o-mini", "prompt") {
+		t.Error("Expected the model to change the key")
+	}
+	if CacheKey(image, "ab", "c") == CacheKey(image, "a", "bc") {
+		t.Error("Expected parts to be separated")
+	}
+	if key == CacheKey([]byte("other"), "openai", "gpt-4o", "prompt") {
+		t.Error("Expected the image to change the key")
+	}
+}
+
+func TestCache_GetPut(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "ocr-cache"), 0, 0)
+
+	if _, ok := cache.Get("missing"); ok {
+		t.Error("Expected a miss on an empty cache")
+	}
+	if err := cache.Put("key", "hello"); err != nil {
+		t.Fatalf("Failed to put: %v", err)
+	}
+	if text, ok := cache.Get("key"); !ok || text != "hello" {
+		t.Errorf("Expected cached 'hello', got %q, %v", text, ok)
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Failed to clear: %v", err)
+	}
+	if _, ok := cache.Get("key"); ok {
+		t.Error("Expected a miss after clear")
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	now, advance := fakeClock()
+	cache := NewCache(t.TempDir(), time.Hour, 0)
+	cache.now = now
+
+	cache.Put("key", "hello")
+	advance(30 * time.Minute)
+	if _, ok := cache.Get("key"); !ok {
+		t.Error("Expected a hit within the TTL")
+	}
+
+	advance(time.Hour)
+	if _, ok := cache.Get("key"); ok {
+		t.Error("Expected a miss after the TTL")
+	}
+	if _, err := os.Stat(cache.path("key")); !os.IsNotExist(err) {
+		t.Error("Expected the expired entry to be removed")
+	}
+}
+
+func TestCache_SizeEviction(t *testing.T) {
+	now, advance := fakeClock()
+	dir := t.TempDir()
+	text := strings.Repeat("x", 100)
+
+	// Room for two entries but not three
+	cache := NewCache(dir, 0, 300)
+	cache.now = now
+
+	cache.Put("a", text)
+	advance(time.Minute)
+	cache.Put("b", text)
+	advance(time.Minute)
+	// Using "a" makes "b" the least recently used
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("Expected a hit for a")
+	}
+	advance(time.Minute)
+	cache.Put("c", text)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Expected %s to be kept", key)
+		}
+	}
+}
+
+func TestCache_PutEvictsExpired(t *testing.T) {
+	now, advance := fakeClock()
+	cache := NewCache(t.TempDir(), time.Hour, 0)
+	cache.now = now
+
+	cache.Put("old", "hello")
+	advance(2 * time.Hour)
+	cache.Put("new", "hello")
+
+	if _, err := os.Stat(cache.path("old")); !os.IsNotExist(err) {
+		t.Error("Expected the expired entry to be removed on put")
+	}
+	if _, ok := cache.Get("new"); !ok {
+		t.Error("Expected the new entry to be kept")
+	}
+}
+
+func TestCache_PutKeepsItsEntry(t *testing.T) {
+	now, advance := fakeClock()
+	// Smaller than any single entry
+	cache := NewCache(t.TempDir(), time.Hour, 10)
+	cache.now = now
+
+	cache.Put("a", "hello")
+	advance(time.Minute)
+	cache.Put("b", "hello")
+
+	if text, ok := cache.Get("b"); !ok || text != "hello" {
+		t.Errorf("Expected the entry just written to be kept, got %q, %v", text, ok)
+	}
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Expected the older entry to be evicted")
+	}
+}
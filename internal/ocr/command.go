@@ -0,0 +1,78 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CommandProvider extracts text by running an executable that reads the image
+// on stdin and writes the text to stdout, such as "tesseract stdin stdout"
+type CommandProvider struct {
+	Path string
+	Args []string
+	// Timeout kills the command when it runs longer; 0 means no limit
+	Timeout time.Duration
+}
+
+// NewCommandProvider creates a provider that runs path with args
+func NewCommandProvider(path string, args []string) *CommandProvider {
+	return &CommandProvider{
+		Path: path,
+		Args: args,
+	}
+}
+
+// Name returns "command"
+func (p *CommandProvider) Name() string {
+	return "command"
+}
+
+// ExtractText pipes the image through the command. Cancelling ctx kills it,
+// as does running longer than Timeout.
+func (p *CommandProvider) ExtractText(ctx context.Context, req Request) (string, error) {
+	if req.Schema != nil {
+		return "", ErrSchemaUnsupported
+	}
+	if p.Path == "" {
+		return "", fmt.Errorf("command path is required")
+	}
+
+	runCtx := ctx
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(runCtx, p.Path, p.Args...)
+	// Children of a killed command may keep its output open; stop waiting for them
+	cmd.WaitDelay = time.Second
+	cmd.Stdin = bytes.NewReader(req.Image)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	hideWindow(cmd)
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if runCtx.Err() != nil {
+			return "", fmt.Errorf("%s did not finish within %s: %w", p.Path, p.Timeout, runCtx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s failed: %w: %s", p.Path, err, msg)
+		}
+		return "", fmt.Errorf("%s failed: %w", p.Path, err)
+	}
+
+	text := strings.TrimSpace(stdout.String())
+	if text == "" {
+		return "", fmt.Errorf("%s returned no text", p.Path)
+	}
+	return text, nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package ocr
+
+import "os/exec"
+
+// hideWindow is a no-op outside Windows
+func hideWindow(cmd *exec.Cmd) {}
@@ -0,0 +1,86 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess is not a real test; CommandProvider tests run the test
+// binary itself as the external command so they work on every platform
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv("OCR_HELPER_MODE")
+	if mode == "" {
+		return
+	}
+
+	input, _ := io.ReadAll(os.Stdin)
+	switch mode {
+	case "echo":
+		fmt.Printf("  text from %s\n", input)
+		os.Exit(0)
+	case "fail":
+		fmt.Fprint(os.Stderr, "cannot read image")
+		os.Exit(2)
+	case "empty":
+		os.Exit(0)
+	case "hang":
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+}
+
+// helperCommand returns a provider that runs TestHelperProcess in the given mode
+func helperCommand(t *testing.T, mode string) *CommandProvider {
+	t.Setenv("OCR_HELPER_MODE", mode)
+	return NewCommandProvider(os.Args[0], []string{"-test.run=^TestHelperProcess$"})
+}
+
+func TestCommandProvider_ExtractText(t *testing.T) {
+	text, err := helperCommand(t, "echo").ExtractText(context.Background(), Request{Image: []byte("image bytes")})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "text from image bytes" {
+		t.Errorf("Expected trimmed stdout, got %q", text)
+	}
+}
+
+func TestCommandProvider_Failure(t *testing.T) {
+	_, err := helperCommand(t, "fail").ExtractText(context.Background(), Request{Image: []byte("x")})
+	if err == nil || !strings.Contains(err.Error(), "cannot read image") {
+		t.Errorf("Expected error with stderr, got %v", err)
+	}
+}
+
+func TestCommandProvider_EmptyOutput(t *testing.T) {
+	if _, err := helperCommand(t, "empty").ExtractText(context.Background(), Request{Image: []byte("x")}); err == nil {
+		t.Error("Expected error for empty output")
+	}
+}
+
+func TestCommandProvider_MissingExecutable(t *testing.T) {
+	provider := NewCommandProvider("definitely-not-an-ocr-tool", nil)
+	if _, err := provider.ExtractText(context.Background(), Request{Image: []byte("x")}); err == nil {
+		t.Error("Expected error for missing executable")
+	}
+}
+
+func TestCommandProvider_Timeout(t *testing.T) {
+	provider := helperCommand(t, "hang")
+	provider.Timeout = 200 * time.Millisecond
+
+	start := time.Now()
+	_, err := provider.ExtractText(context.Background(), Request{Image: []byte("x")})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the command to be killed at the timeout, took %s", elapsed)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package ocr
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// createNoWindow is the CREATE_NO_WINDOW process creation flag
+const createNoWindow = 0x08000000
+
+// hideWindow stops console programs from flashing a window when run from the GUI
+func hideWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: createNoWindow,
+	}
+}
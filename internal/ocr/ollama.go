@@ -0,0 +1,183 @@
+package ocr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"talus_helper_windows/internal/httpx"
+	"talus_helper_windows/internal/openai"
+)
+
+// OllamaProvider extracts text with a vision model served by a local
+// Ollama-compatible /api/generate endpoint, so images never leave the machine
+type OllamaProvider struct {
+	BaseURL      string
+	Model        string
+	SystemPrompt string
+	UserPrompt   string
+	Temperature  float64
+	Stream       bool
+	HTTPClient   *http.Client
+	// Observe, if set, is called after every request with its token usage and latency
+	Observe func(ctx context.Context, call openai.Call)
+}
+
+// NewOllamaProvider creates a provider for the server at baseURL
+func NewOllamaProvider(baseURL, model string) *OllamaProvider {
+	return &OllamaProvider{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Model:      model,
+		HTTPClient: httpx.DefaultClient(120 * time.Second),
+	}
+}
+
+// ollamaRequest is the body of an /api/generate request
+type ollamaRequest struct {
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	System  string                 `json:"system,omitempty"`
+	Images  []string               `json:"images"`
+	Stream  bool                   `json:"stream"`
+	Format  json.RawMessage        `json:"format,omitempty"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// ollamaResponse is a full /api/generate response or one line of a streamed one
+type ollamaResponse struct {
+	Response        string `json:"response"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// Name returns "ollama"
+func (p *OllamaProvider) Name() string {
+	return "ollama"
+}
+
+// ExtractText sends the image to the local model
+func (p *OllamaProvider) ExtractText(ctx context.Context, req Request) (string, error) {
+	if p.Model == "" {
+		return "", fmt.Errorf("model is required")
+	}
+
+	start := time.Now()
+	var usage openai.Usage
+	text, err := p.generate(ctx, req, &usage)
+	if p.Observe != nil {
+		p.Observe(ctx, openai.Call{Model: p.Model, Usage: usage, Latency: time.Since(start), Err: err})
+	}
+	return text, err
+}
+
+// generate makes the /api/generate request, storing the reported token counts in usage
+func (p *OllamaProvider) generate(ctx context.Context, req Request, usage *openai.Usage) (string, error) {
+	prompt := p.UserPrompt
+	if prompt == "" {
+		prompt = "Extract all text from this image. Return only the text content."
+	}
+	stream := p.Stream && req.OnDelta != nil
+	var format json.RawMessage
+	if req.Schema != nil {
+		// Ollama constrains the output to a JSON schema passed as the format
+		prompt = req.Schema.Prompt
+		format = req.Schema.JSON
+		stream = false
+	}
+
+	requestBody, err := json.Marshal(ollamaRequest{
+		Model:   p.Model,
+		Prompt:  prompt,
+		System:  p.SystemPrompt,
+		Images:  []string{base64.StdEncoding.EncodeToString(req.Image)},
+		Stream:  stream,
+		Format:  format,
+		Options: map[string]interface{}{"temperature": p.Temperature},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/api/generate", bytes.NewReader(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.HTTPClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		var errorResp ollamaResponse
+		if json.Unmarshal(body, &errorResp) == nil && errorResp.Error != "" {
+			return "", fmt.Errorf("ollama error: %s", errorResp.Error)
+		}
+		return "", fmt.Errorf("ollama request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if !stream {
+		var result ollamaResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return "", fmt.Errorf("failed to parse response: %w", err)
+		}
+		if result.Error != "" {
+			return "", fmt.Errorf("ollama error: %s", result.Error)
+		}
+		setUsage(usage, result)
+		return result.Response, nil
+	}
+
+	// Streamed responses are newline-delimited JSON objects
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return text.String(), fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return text.String(), fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if chunk.Response != "" {
+			text.WriteString(chunk.Response)
+			req.OnDelta(chunk.Response, text.String())
+		}
+		if chunk.Done {
+			setUsage(usage, chunk)
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return text.String(), ctx.Err()
+		}
+		return text.String(), fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return text.String(), nil
+}
+
+// setUsage copies the token counts of a final response into usage
+func setUsage(usage *openai.Usage, resp ollamaResponse) {
+	usage.PromptTokens = resp.PromptEvalCount
+	usage.CompletionTokens = resp.EvalCount
+	usage.TotalTokens = resp.PromptEvalCount + resp.EvalCount
+}
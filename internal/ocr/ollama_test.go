@@ -0,0 +1,89 @@
+package ocr
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"talus_helper_windows/internal/openai"
+)
+
+func TestOllamaProvider_ExtractText(t *testing.T) {
+	var got ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("Expected /api/generate, got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"model":"llava","response":"hello","done":true,"prompt_eval_count":12,"eval_count":2}`))
+	}))
+	defer server.Close()
+
+	var observed openai.Call
+	provider := NewOllamaProvider(server.URL+"/", "llava")
+	provider.SystemPrompt = "system"
+	provider.Observe = func(ctx context.Context, call openai.Call) { observed = call }
+
+	text, err := provider.ExtractText(context.Background(), Request{Image: []byte("img"), Format: "png"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "hello" {
+		t.Errorf("Expected 'hello', got %q", text)
+	}
+	if got.Model != "llava" || got.System != "system" || got.Stream {
+		t.Errorf("Unexpected request: %+v", got)
+	}
+	if observed.Model != "llava" || observed.Usage.PromptTokens != 12 || observed.Usage.CompletionTokens != 2 {
+		t.Errorf("Expected observed usage, got %+v", observed)
+	}
+	if len(got.Images) != 1 || got.Images[0] != base64.StdEncoding.EncodeToString([]byte("img")) {
+		t.Errorf("Expected base64 image, got %v", got.Images)
+	}
+}
+
+func TestOllamaProvider_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Join([]string{
+			`{"response":"Hel","done":false}`,
+			`{"response":"lo","done":false}`,
+			`{"response":"","done":true}`,
+		}, "\n")))
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider(server.URL, "llava")
+	provider.Stream = true
+
+	var progress []string
+	text, err := provider.ExtractText(context.Background(), Request{
+		Image:   []byte("img"),
+		OnDelta: func(delta, text string) { progress = append(progress, text) },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "Hello" {
+		t.Errorf("Expected 'Hello', got %q", text)
+	}
+	if strings.Join(progress, "|") != "Hel|Hello" {
+		t.Errorf("Unexpected progress: %q", progress)
+	}
+}
+
+func TestOllamaProvider_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model 'llava' not found"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOllamaProvider(server.URL, "llava").ExtractText(context.Background(), Request{Image: []byte("img")})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected model not found error, got %v", err)
+	}
+}
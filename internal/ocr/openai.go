@@ -0,0 +1,49 @@
+package ocr
+
+import (
+	"context"
+
+	"talus_helper_windows/internal/openai"
+)
+
+// OpenAIProvider extracts text with an OpenAI-compatible vision endpoint
+type OpenAIProvider struct {
+	Client  *openai.Client
+	Options openai.VisionOptions
+	// Stream requests a streamed response when the request has an OnDelta callback
+	Stream bool
+}
+
+// NewOpenAIProvider creates a provider backed by client
+func NewOpenAIProvider(client *openai.Client, opts openai.VisionOptions, stream bool) *OpenAIProvider {
+	return &OpenAIProvider{
+		Client:  client,
+		Options: opts,
+		Stream:  stream,
+	}
+}
+
+// Name returns "openai"
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+// ExtractText sends the image to the vision endpoint. Requests with a schema
+// use a JSON-schema response format and are never streamed.
+func (p *OpenAIProvider) ExtractText(ctx context.Context, req Request) (string, error) {
+	if req.Schema != nil {
+		opts := p.Options
+		opts.UserPrompt = req.Schema.Prompt
+		opts.ResponseFormat = openai.JSONSchemaFormat(req.Schema.Name, req.Schema.JSON)
+		return p.Client.ExtractTextFromImage(ctx, req.Image, req.Format, opts)
+	}
+	if !p.Stream || req.OnDelta == nil {
+		return p.Client.ExtractTextFromImage(ctx, req.Image, req.Format, p.Options)
+	}
+
+	var text string
+	return p.Client.StreamTextFromImage(ctx, req.Image, req.Format, p.Options, func(delta string) {
+		text += delta
+		req.OnDelta(delta, text)
+	})
+}
@@ -0,0 +1,79 @@
+package ocr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrSchemaUnsupported is returned by providers that cannot produce JSON
+// matching a schema
+var ErrSchemaUnsupported = errors.New("structured output is not supported")
+
+// Request describes an image to extract text from
+type Request struct {
+	Image  []byte
+	Format string
+	// OnDelta, if set, is called as text is recognized with the new piece and
+	// everything recognized so far. Providers that cannot stream never call it.
+	OnDelta func(delta, text string)
+	// Schema, if set, asks for JSON matching a schema instead of plain text
+	Schema *Schema
+}
+
+// Schema describes the JSON a provider should return
+type Schema struct {
+	Name string
+	// Prompt replaces the provider's user prompt
+	Prompt string
+	JSON   json.RawMessage
+}
+
+// OCRProvider extracts text from images
+type OCRProvider interface {
+	// Name identifies the provider in errors and settings
+	Name() string
+	ExtractText(ctx context.Context, req Request) (string, error)
+}
+
+// Fallback tries each provider in order and returns the first successful result
+type Fallback []OCRProvider
+
+// Name lists the providers in the order they are tried
+func (f Fallback) Name() string {
+	name := ""
+	for i, provider := range f {
+		if i > 0 {
+			name += ","
+		}
+		name += provider.Name()
+	}
+	return name
+}
+
+// ExtractText returns the text from the first provider that succeeds.
+// If every provider fails, the returned error joins all of their errors.
+func (f Fallback) ExtractText(ctx context.Context, req Request) (string, error) {
+	if len(f) == 0 {
+		return "", fmt.Errorf("no OCR provider is configured")
+	}
+
+	var errs []error
+	for _, provider := range f {
+		text, err := provider.ExtractText(ctx, req)
+		if err == nil {
+			return text, nil
+		}
+		// Do not move on to the next provider once the caller gave up
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+
+	if len(errs) == 1 {
+		return "", errs[0]
+	}
+	return "", errors.Join(errs...)
+}
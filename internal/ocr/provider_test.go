@@ -0,0 +1,80 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubProvider returns a fixed result and counts its calls
+type stubProvider struct {
+	name  string
+	text  string
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) ExtractText(ctx context.Context, req Request) (string, error) {
+	p.calls++
+	return p.text, p.err
+}
+
+func TestFallback_FirstSuccessWins(t *testing.T) {
+	first := &stubProvider{name: "first", err: errors.New("offline")}
+	second := &stubProvider{name: "second", text: "hello"}
+	third := &stubProvider{name: "third", text: "unused"}
+
+	text, err := Fallback{first, second, third}.ExtractText(context.Background(), Request{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "hello" {
+		t.Errorf("Expected 'hello', got %q", text)
+	}
+	if first.calls != 1 || second.calls != 1 || third.calls != 0 {
+		t.Errorf("Unexpected calls: %d %d %d", first.calls, second.calls, third.calls)
+	}
+}
+
+func TestFallback_AllFail(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	chain := Fallback{
+		&stubProvider{name: "a", err: errA},
+		&stubProvider{name: "b", err: errB},
+	}
+
+	_, err := chain.ExtractText(context.Background(), Request{})
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Expected both errors, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "a: a failed") {
+		t.Errorf("Expected errors to name their provider, got %v", err)
+	}
+	if chain.Name() != "a,b" {
+		t.Errorf("Expected name 'a,b', got %q", chain.Name())
+	}
+}
+
+func TestFallback_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	second := &stubProvider{name: "second", text: "hello"}
+	_, err := Fallback{&stubProvider{name: "first", err: context.Canceled}, second}.ExtractText(ctx, Request{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if second.calls != 0 {
+		t.Error("Expected no fallback after cancellation")
+	}
+}
+
+func TestFallback_Empty(t *testing.T) {
+	if _, err := (Fallback{}).ExtractText(context.Background(), Request{}); err == nil {
+		t.Error("Expected error with no providers")
+	}
+}
@@ -0,0 +1,431 @@
+package ocr
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OCR modes. ModeText returns plain text and ModeTranslate plain text that
+// the caller then translates; the others return validated, structured results.
+const (
+	ModeText      = "text"
+	ModeTranslate = "translate"
+	ModeTable     = "table"
+	ModeCode      = "code"
+	ModeKeyValue  = "keyvalue"
+)
+
+// Table is a table read from an image
+type Table struct {
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+}
+
+// Code is source code read from an image
+type Code struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+// KeyValue is one labelled field read from a form or similar image
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// KeyValues are the fields read from an image, in reading order
+type KeyValues struct {
+	Fields []KeyValue `json:"fields"`
+}
+
+// StructuredResult is a parsed structured OCR result in the forms the UI shows
+type StructuredResult struct {
+	Mode string `json:"mode"`
+	// Text is the main rendering: a Markdown table, the code or a JSON object
+	Text     string     `json:"text"`
+	Table    *Table     `json:"table,omitempty"`
+	CSV      string     `json:"csv,omitempty"`
+	Markdown string     `json:"markdown,omitempty"`
+	Code     *Code      `json:"code,omitempty"`
+	Fields   []KeyValue `json:"fields,omitempty"`
+	JSON     string     `json:"json,omitempty"`
+}
+
+// structuredSchemas are the JSON schemas and prompts of the structured modes.
+// The schemas follow the strict subset: every property is required and no
+// other properties are allowed.
+var structuredSchemas = map[string]Schema{
+	ModeTable: {
+		Name: "ocr_table",
+		Prompt: "Extract the table in this image. Return the column headers and every row as cells of text, " +
+			"in reading order, with each cell's text exactly as shown. Use an empty string for empty cells.",
+		JSON: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"headers": {"type": "array", "items": {"type": "string"}},
+				"rows": {"type": "array", "items": {"type": "array", "items": {"type": "string"}}}
+			},
+			"required": ["headers", "rows"],
+			"additionalProperties": false
+		}`),
+	},
+	ModeCode: {
+		Name: "ocr_code",
+		Prompt: "Extract the source code in this image exactly, preserving indentation, blank lines and symbols. " +
+			"Set language to its programming language as a lowercase name such as python, go or javascript, " +
+			"or an empty string if unsure.",
+		JSON: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"language": {"type": "string"},
+				"code": {"type": "string"}
+			},
+			"required": ["language", "code"],
+			"additionalProperties": false
+		}`),
+	},
+	ModeKeyValue: {
+		Name: "ocr_key_values",
+		Prompt: "Extract the labelled fields in this image, such as a form, receipt or settings page, " +
+			"as key and value pairs in reading order, with each value exactly as shown.",
+		JSON: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"fields": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"key": {"type": "string"},
+							"value": {"type": "string"}
+						},
+						"required": ["key", "value"],
+						"additionalProperties": false
+					}
+				}
+			},
+			"required": ["fields"],
+			"additionalProperties": false
+		}`),
+	},
+}
+
+// SchemaFor returns the schema of a structured mode
+func SchemaFor(mode string) (*Schema, error) {
+	schema, ok := structuredSchemas[mode]
+	if !ok {
+		return nil, fmt.Errorf("unknown OCR mode %q", mode)
+	}
+	return &schema, nil
+}
+
+// ParseStructured decodes and validates a JSON response for mode
+func ParseStructured(mode, raw string) (StructuredResult, error) {
+	result := StructuredResult{Mode: mode}
+	data := []byte(stripCodeFence(raw))
+
+	switch mode {
+	case ModeTable:
+		var table Table
+		if err := decodeStrict(data, &table); err != nil {
+			return result, err
+		}
+		if err := table.normalize(); err != nil {
+			return result, err
+		}
+		csvText, err := table.CSV()
+		if err != nil {
+			return result, err
+		}
+		result.Table = &table
+		result.CSV = csvText
+		result.Markdown = table.Markdown()
+		result.Text = result.Markdown
+
+	case ModeCode:
+		var code Code
+		if err := decodeStrict(data, &code); err != nil {
+			return result, err
+		}
+		if err := code.normalize(); err != nil {
+			return result, err
+		}
+		result.Code = &code
+		result.Text = code.Code
+
+	case ModeKeyValue:
+		var kv KeyValues
+		if err := decodeStrict(data, &kv); err != nil {
+			return result, err
+		}
+		if err := kv.normalize(); err != nil {
+			return result, err
+		}
+		jsonText, err := kv.JSON()
+		if err != nil {
+			return result, err
+		}
+		result.Fields = kv.Fields
+		result.JSON = jsonText
+		result.Text = jsonText
+
+	default:
+		return result, fmt.Errorf("unknown OCR mode %q", mode)
+	}
+
+	return result, nil
+}
+
+// decodeStrict decodes a single JSON value, rejecting unknown fields
+func decodeStrict(data []byte, out interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("invalid structured response: %w", err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("invalid structured response: unexpected data after JSON value")
+	}
+	return nil
+}
+
+// codeFence matches a response wrapped in a Markdown code block
+var codeFence = regexp.MustCompile("(?s)^```[a-zA-Z]*\\s*\\n(.*)\\n```$")
+
+// stripCodeFence removes a Markdown code block around a JSON response, which
+// some models add even when asked for JSON
+func stripCodeFence(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if match := codeFence.FindStringSubmatch(raw); match != nil {
+		return match[1]
+	}
+	return raw
+}
+
+// normalize pads every row and the headers to the same number of columns
+func (t *Table) normalize() error {
+	columns := len(t.Headers)
+	for _, row := range t.Rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return fmt.Errorf("no table found in image")
+	}
+
+	t.Headers = pad(t.Headers, columns)
+	for i := range t.Rows {
+		t.Rows[i] = pad(t.Rows[i], columns)
+	}
+	return nil
+}
+
+// pad extends cells with empty strings to n cells
+func pad(cells []string, n int) []string {
+	for len(cells) < n {
+		cells = append(cells, "")
+	}
+	return cells
+}
+
+// CSV renders the table as CSV with a header row
+func (t Table) CSV() (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(t.Headers); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	if err := writer.WriteAll(t.Rows); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Markdown renders the table as a GitHub-flavoured Markdown table
+func (t Table) Markdown() string {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			b.WriteString(" ")
+			b.WriteString(markdownCell(cell))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(t.Headers)
+	b.WriteString("|")
+	for range t.Headers {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, row := range t.Rows {
+		writeRow(row)
+	}
+	return b.String()
+}
+
+// markdownCell escapes text so it stays inside one Markdown table cell
+func markdownCell(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\r\n", "<br>")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// normalize trims blank lines around the code, keeping indentation, and
+// settles the language
+func (c *Code) normalize() error {
+	lines := strings.Split(strings.ReplaceAll(c.Code, "\r\n", "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("no code found in image")
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	c.Code = strings.Join(lines, "\n")
+
+	c.Language = normalizeLanguage(c.Language)
+	if c.Language == "" {
+		c.Language = DetectLanguage(c.Code)
+	}
+	return nil
+}
+
+// languageAliases maps common alternative names to one canonical name
+var languageAliases = map[string]string{
+	"golang":     "go",
+	"js":         "javascript",
+	"jsx":        "javascript",
+	"ts":         "typescript",
+	"tsx":        "typescript",
+	"py":         "python",
+	"python3":    "python",
+	"c++":        "cpp",
+	"c#":         "csharp",
+	"cs":         "csharp",
+	"sh":         "bash",
+	"shell":      "bash",
+	"ps1":        "powershell",
+	"yml":        "yaml",
+	"rb":         "ruby",
+	"rs":         "rust",
+	"kt":         "kotlin",
+	"plaintext":  "",
+	"text":       "",
+	"unknown":    "",
+	"none":       "",
+	"plain text": "",
+}
+
+// normalizeLanguage lowercases a language name and resolves aliases; it
+// returns "" for names that mean no language
+func normalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if alias, ok := languageAliases[language]; ok {
+		return alias
+	}
+	return language
+}
+
+// languageHints are patterns typical of a language, checked in order
+var languageHints = []struct {
+	language string
+	pattern  *regexp.Regexp
+}{
+	{"go", regexp.MustCompile(`(?m)^package \w+$|\bfunc \w*\(|:= `)},
+	{"python", regexp.MustCompile(`(?m)^\s*(def \w+\(.*\):|class \w+.*:|from \w+(\.\w+)* import |import \w+$)`)},
+	{"rust", regexp.MustCompile(`\bfn \w+\(|\blet mut\b|\bimpl\b.*\{`)},
+	{"typescript", regexp.MustCompile(`\binterface \w+ \{|: (string|number|boolean)\b|\bimport .* from ['"]`)},
+	{"javascript", regexp.MustCompile(`\bconst \w+ = |\bfunction \w*\(|=> |console\.log\(`)},
+	{"java", regexp.MustCompile(`\bpublic (static )?(class|void)\b|System\.out\.`)},
+	{"csharp", regexp.MustCompile(`\busing System\b|\bnamespace \w+|Console\.Write`)},
+	{"cpp", regexp.MustCompile(`#include <\w+>|std::`)},
+	{"sql", regexp.MustCompile(`(?i)\b(select .+ from|insert into|create table|update \w+ set)\b`)},
+	{"html", regexp.MustCompile(`(?i)<(!doctype html|html|div|body|span)\b`)},
+	{"bash", regexp.MustCompile(`(?m)^#!/bin/(ba)?sh|^\s*(echo|export|sudo|cd) `)},
+	{"json", regexp.MustCompile(`^\s*[\{\[]\s*"`)},
+}
+
+// DetectLanguage guesses the programming language of code from typical
+// patterns, returning "text" when nothing matches
+func DetectLanguage(code string) string {
+	for _, hint := range languageHints {
+		if hint.pattern.MatchString(code) {
+			return hint.language
+		}
+	}
+	return "text"
+}
+
+// normalize trims keys and values and drops fields without a key
+func (kv *KeyValues) normalize() error {
+	fields := kv.Fields[:0]
+	for _, field := range kv.Fields {
+		field.Key = strings.TrimSpace(field.Key)
+		field.Value = strings.TrimSpace(field.Value)
+		if field.Key != "" {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("no fields found in image")
+	}
+	kv.Fields = fields
+	return nil
+}
+
+// JSON renders the fields as an indented JSON object in reading order.
+// Repeated keys get a numbered suffix so no value is lost.
+func (kv KeyValues) JSON() (string, error) {
+	seen := map[string]int{}
+	var b strings.Builder
+	b.WriteString("{\n")
+	for i, field := range kv.Fields {
+		key := field.Key
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s (%d)", key, n)
+		}
+
+		keyJSON, err := jsonString(key)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode key: %w", err)
+		}
+		valueJSON, err := jsonString(field.Value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode value: %w", err)
+		}
+		b.WriteString("  ")
+		b.WriteString(keyJSON)
+		b.WriteString(": ")
+		b.WriteString(valueJSON)
+		if i < len(kv.Fields)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// jsonString encodes s as a JSON string without escaping <, > and &
+func jsonString(s string) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,201 @@
+package ocr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"talus_helper_windows/internal/openai"
+)
+
+func TestParseStructured_Table(t *testing.T) {
+	raw := `{"headers":["Name","Notes"],"rows":[["Ann","a|b"],["Bob, Jr.","two\nlines","extra"]]}`
+
+	result, err := ParseStructured(ModeTable, raw)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Table.Headers) != 3 || len(result.Table.Rows[0]) != 3 {
+		t.Errorf("Expected rows padded to 3 columns, got %+v", result.Table)
+	}
+	wantCSV := "Name,Notes,\nAnn,a|b,\n\"Bob, Jr.\",\"two\nlines\",extra\n"
+	if result.CSV != wantCSV {
+		t.Errorf("Unexpected CSV:\n%s", result.CSV)
+	}
+	wantMarkdown := "| Name | Notes |  |\n| --- | --- | --- |\n| Ann | a\\|b |  |\n| Bob, Jr. | two<br>lines | extra |\n"
+	if result.Markdown != wantMarkdown || result.Text != wantMarkdown {
+		t.Errorf("Unexpected Markdown:\n%s", result.Markdown)
+	}
+}
+
+func TestParseStructured_Code(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		wantLanguage string
+		wantCode     string
+	}{
+		{
+			name:         "keeps indentation",
+			raw:          `{"language":"Python","code":"\n\ndef f(x):\n    if x:  \n\treturn 1\n\n"}`,
+			wantLanguage: "python",
+			wantCode:     "def f(x):\n    if x:\n\treturn 1",
+		},
+		{
+			name:         "resolves alias",
+			raw:          `{"language":"golang","code":"x := 1"}`,
+			wantLanguage: "go",
+			wantCode:     "x := 1",
+		},
+		{
+			name:         "detects missing language",
+			raw:          `{"language":"","code":"const x = () => console.log(1)"}`,
+			wantLanguage: "javascript",
+			wantCode:     "const x = () => console.log(1)",
+		},
+		{
+			name:         "strips code fence",
+			raw:          "```json\n{\"language\":\"unknown\",\"code\":\"SELECT id FROM users\"}\n```",
+			wantLanguage: "sql",
+			wantCode:     "SELECT id FROM users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseStructured(ModeCode, tt.raw)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.Code.Language != tt.wantLanguage {
+				t.Errorf("Expected language %q, got %q", tt.wantLanguage, result.Code.Language)
+			}
+			if result.Code.Code != tt.wantCode || result.Text != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, result.Code.Code)
+			}
+		})
+	}
+}
+
+func TestParseStructured_KeyValue(t *testing.T) {
+	raw := `{"fields":[{"key":" Name ","value":"Ann <ann@example.com>"},{"key":"","value":"dropped"},{"key":"Name","value":"Bob"}]}`
+
+	result, err := ParseStructured(ModeKeyValue, raw)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Fields) != 2 {
+		t.Errorf("Expected 2 fields, got %+v", result.Fields)
+	}
+
+	want := "{\n  \"Name\": \"Ann <ann@example.com>\",\n  \"Name (2)\": \"Bob\"\n}"
+	if result.JSON != want {
+		t.Errorf("Unexpected JSON:\n%s", result.JSON)
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(result.JSON), &decoded); err != nil || decoded["Name (2)"] != "Bob" {
+		t.Errorf("Expected valid JSON object, got %v (%v)", decoded, err)
+	}
+}
+
+func TestParseStructured_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		raw  string
+		want string
+	}{
+		{"not JSON", ModeTable, "Name | Notes", "invalid structured response"},
+		{"unknown field", ModeCode, `{"language":"go","code":"x","extra":1}`, "unknown field"},
+		{"wrong type", ModeKeyValue, `{"fields":"a=b"}`, "invalid structured response"},
+		{"trailing data", ModeCode, `{"language":"go","code":"x"} {}`, "unexpected data"},
+		{"empty table", ModeTable, `{"headers":[],"rows":[]}`, "no table"},
+		{"empty code", ModeCode, `{"language":"go","code":"  \n "}`, "no code"},
+		{"no keys", ModeKeyValue, `{"fields":[{"key":" ","value":"x"}]}`, "no fields"},
+		{"unknown mode", "poem", `{}`, "unknown OCR mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStructured(tt.mode, tt.raw)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSchemaFor(t *testing.T) {
+	for _, mode := range []string{ModeTable, ModeCode, ModeKeyValue} {
+		schema, err := SchemaFor(mode)
+		if err != nil {
+			t.Fatalf("Expected schema for %s, got %v", mode, err)
+		}
+		if !json.Valid(schema.JSON) || schema.Name == "" || schema.Prompt == "" {
+			t.Errorf("Incomplete schema for %s: %+v", mode, schema)
+		}
+	}
+	if _, err := SchemaFor(ModeText); err == nil {
+		t.Error("Expected no schema for text mode")
+	}
+}
+
+func TestOpenAIProvider_Schema(t *testing.T) {
+	var got openai.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"language\":\"go\",\"code\":\"x\"}"}}]}`))
+	}))
+	defer server.Close()
+
+	schema, _ := SchemaFor(ModeCode)
+	provider := NewOpenAIProvider(openai.NewClient(server.URL, "key"), openai.VisionOptions{Model: "m", UserPrompt: "plain"}, true)
+
+	text, err := provider.ExtractText(context.Background(), Request{
+		Image:   []byte("img"),
+		Schema:  schema,
+		OnDelta: func(delta, text string) { t.Error("Structured requests must not stream") },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != `{"language":"go","code":"x"}` {
+		t.Errorf("Unexpected text %q", text)
+	}
+	if got.Stream || got.ResponseFormat == nil || got.ResponseFormat.Type != "json_schema" ||
+		got.ResponseFormat.JSONSchema.Name != "ocr_code" || !got.ResponseFormat.JSONSchema.Strict {
+		t.Errorf("Expected strict JSON schema request, got %+v", got)
+	}
+}
+
+func TestOllamaProvider_Schema(t *testing.T) {
+	var got ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"response":"{}","done":true}`))
+	}))
+	defer server.Close()
+
+	schema, _ := SchemaFor(ModeTable)
+	provider := NewOllamaProvider(server.URL, "llava")
+	provider.Stream = true
+	if _, err := provider.ExtractText(context.Background(), Request{Image: []byte("img"), Schema: schema, OnDelta: func(string, string) {}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Stream || got.Prompt != schema.Prompt || !strings.Contains(string(got.Format), `"headers"`) {
+		t.Errorf("Expected schema request, got %+v", got)
+	}
+}
+
+func TestCommandProvider_SchemaUnsupported(t *testing.T) {
+	schema, _ := SchemaFor(ModeTable)
+	_, err := NewCommandProvider("tesseract", nil).ExtractText(context.Background(), Request{Schema: schema})
+	if !errors.Is(err, ErrSchemaUnsupported) {
+		t.Errorf("Expected ErrSchemaUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ChatOptions holds the model and sampling parameters of a chat completion
+type ChatOptions struct {
+	Model       string
+	Temperature float64
+	MaxTokens   int
+	// ResponseFormat, if set, asks for JSON instead of plain text
+	ResponseFormat *ResponseFormat
+	// Tools, if set, are the functions the model may call
+	Tools []Tool
+	// BeforeToolRound, if set, is called by CompleteWithTools before it sends
+	// tool results back; an error stops the conversation
+	BeforeToolRound func() error
+}
+
+// TextMessage returns a message with plain text content
+func TextMessage(role, text string) Message {
+	return Message{Role: role, Content: text}
+}
+
+// PartsMessage returns a message made of content parts, such as TextPart and
+// ImagePart, for requests that mix text and images
+func PartsMessage(role string, parts ...interface{}) Message {
+	return Message{Role: role, Content: parts}
+}
+
+// TextPart returns a text content part
+func TextPart(text string) TextContent {
+	return TextContent{Type: "text", Text: text}
+}
+
+// ImagePart returns an image content part holding data inline as a data URL.
+// format is the image format, such as "png" or "jpeg".
+func ImagePart(data []byte, format string) ImageContent {
+	return imageURLPart(encodeToBase64(data), format)
+}
+
+// imageURLPart returns an image content part for base64-encoded image data
+func imageURLPart(base64Image, format string) ImageContent {
+	return ImageContent{
+		Type: "image_url",
+		ImageURL: ImageURL{
+			URL: fmt.Sprintf("data:%s;base64,%s", imageMIMEType(format), base64Image),
+		},
+	}
+}
+
+// imageMIMEType returns the MIME type of an image format; unknown formats are sent as PNG
+func imageMIMEType(format string) string {
+	switch format {
+	case "jpeg", "jpg":
+		return "image/jpeg"
+	case "bmp":
+		return "image/bmp"
+	case "gif":
+		return "image/gif"
+	case "webp":
+		return "image/webp"
+	}
+	return "image/png"
+}
+
+// Complete sends messages to the chat completions endpoint and returns the
+// text of the first choice
+func (c *Client) Complete(ctx context.Context, messages []Message, opts ChatOptions) (string, error) {
+	choice, err := c.complete(ctx, messages, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.ResponseFormat != nil && choice.FinishReason == "length" {
+		// Cut-off JSON cannot be parsed; report why rather than a syntax error
+		return "", fmt.Errorf("response was truncated at the token limit")
+	}
+	if textContent, ok := choice.Message.Content.(string); ok {
+		return textContent, nil
+	}
+
+	return "", fmt.Errorf("unexpected response format")
+}
+
+// complete sends messages to the chat completions endpoint and returns the first choice
+func (c *Client) complete(ctx context.Context, messages []Message, opts ChatOptions) (Choice, error) {
+	if err := c.checkRequest(opts); err != nil {
+		return Choice{}, err
+	}
+
+	start := time.Now()
+	var chatResp ChatResponse
+	err := c.doJSON(ctx, "POST", "/chat/completions", buildChatRequest(messages, opts), &chatResp)
+	c.observe(ctx, Call{Model: opts.Model, Usage: chatResp.Usage, Latency: time.Since(start), Err: err})
+	if err != nil {
+		return Choice{}, err
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return Choice{}, fmt.Errorf("no choices in response")
+	}
+	return chatResp.Choices[0], nil
+}
+
+// CompleteStream is Complete with a streamed response. onDelta is called
+// with each piece of text as it arrives; the full text is returned once the
+// stream ends. Cancelling ctx aborts the request.
+func (c *Client) CompleteStream(ctx context.Context, messages []Message, opts ChatOptions, onDelta func(delta string)) (string, error) {
+	if err := c.checkRequest(opts); err != nil {
+		return "", err
+	}
+
+	request := buildChatRequest(messages, opts)
+	request.Stream = true
+	request.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	start := time.Now()
+	var usage Usage
+	resp, err := c.send(ctx, "POST", "/chat/completions", request)
+	if err != nil {
+		c.observe(ctx, Call{Model: opts.Model, Latency: time.Since(start), Err: err})
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	err = readStream(resp.Body, func(chunk ChatCompletionChunk) error {
+		// The usage chunk, when sent, comes last and has no choices
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		delta := chunk.Choices[0].Delta.Content
+		if delta == "" {
+			return nil
+		}
+		text.WriteString(delta)
+		if onDelta != nil {
+			onDelta(delta)
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	c.observe(ctx, Call{Model: opts.Model, Usage: usage, Latency: time.Since(start), Err: err})
+	return text.String(), err
+}
+
+// checkRequest reports missing settings before a completion request is sent
+func (c *Client) checkRequest(opts ChatOptions) error {
+	if c.APIKey == "" {
+		return fmt.Errorf("API key is required")
+	}
+	if opts.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+	return nil
+}
+
+// buildChatRequest builds a chat completions request body
+func buildChatRequest(messages []Message, opts ChatOptions) *ChatRequest {
+	return &ChatRequest{
+		Model:          opts.Model,
+		Messages:       messages,
+		Temperature:    opts.Temperature,
+		MaxTokens:      opts.MaxTokens,
+		ResponseFormat: opts.ResponseFormat,
+		Tools:          opts.Tools,
+	}
+}
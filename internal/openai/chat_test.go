@@ -0,0 +1,67 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Complete(t *testing.T) {
+	var got struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Hallo"}}],"usage":{"prompt_tokens":5,"completion_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	var observed Call
+	client := NewClient(server.URL, "test-key")
+	client.Observe = func(ctx context.Context, call Call) { observed = call }
+
+	text, err := client.Complete(context.Background(), []Message{
+		TextMessage("system", "Translate into German."),
+		TextMessage("user", "Hello"),
+	}, ChatOptions{Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "Hallo" {
+		t.Errorf("Expected 'Hallo', got %q", text)
+	}
+	if got.Model != "gpt-4o-mini" || len(got.Messages) != 2 || got.Messages[1].Content != "Hello" {
+		t.Errorf("Unexpected request: %+v", got)
+	}
+	if observed.Usage.PromptTokens != 5 {
+		t.Errorf("Expected observed usage, got %+v", observed)
+	}
+}
+
+func TestClient_Complete_RequiresModel(t *testing.T) {
+	if _, err := NewClient("http://unused", "key").Complete(context.Background(), nil, ChatOptions{}); err == nil {
+		t.Error("Expected an error without a model")
+	}
+}
+
+func TestPartsMessage(t *testing.T) {
+	message := PartsMessage("user", TextPart("What is this?"), ImagePart([]byte("img"), "jpg"))
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	want := `{"role":"user","content":[{"type":"text","text":"What is this?"},` +
+		`{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,aW1n"}}]}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
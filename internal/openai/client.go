@@ -2,12 +2,16 @@ package openai
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
+
+	"talus_helper_windows/internal/httpx"
 )
 
 // Client represents the OpenAI API client
@@ -15,87 +19,182 @@ type Client struct {
 	BaseURL    string
 	APIKey     string
 	HTTPClient *http.Client
+	// Retry controls retrying of rate-limited, server and network errors
+	Retry RetryPolicy
+	// Limiter, if set, caps the request rate across all calls on this client
+	Limiter *RateLimiter
+	// Observe, if set, is called after every completion request with its
+	// token usage and latency, whether or not it succeeded
+	Observe func(ctx context.Context, call Call)
+
+	// sleep waits between retries; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// VisionOptions holds the model, prompts and sampling parameters for a vision request
+type VisionOptions struct {
+	Model        string
+	SystemPrompt string
+	UserPrompt   string
+	Temperature  float64
+	MaxTokens    int
+	// ResponseFormat, if set, asks for JSON instead of plain text
+	ResponseFormat *ResponseFormat
+}
+
+// Call describes a finished completion request for usage accounting
+type Call struct {
+	Model   string
+	Usage   Usage
+	Latency time.Duration
+	Err     error
 }
 
 // NewClient creates a new OpenAI client
 func NewClient(baseURL, apiKey string) *Client {
 	return &Client{
-		BaseURL: baseURL,
-		APIKey:  apiKey,
-		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		BaseURL:    baseURL,
+		APIKey:     apiKey,
+		HTTPClient: httpx.DefaultClient(30 * time.Second),
+		Retry:      DefaultRetryPolicy,
+		sleep:      sleep,
 	}
 }
 
 // ExtractTextFromImage extracts text from an image using Vision API
-func (c *Client) ExtractTextFromImage(imageData []byte, imageFormat string) (string, error) {
+func (c *Client) ExtractTextFromImage(ctx context.Context, imageData []byte, imageFormat string, opts VisionOptions) (string, error) {
+	return c.Complete(ctx, visionMessages(encodeToBase64(imageData), imageFormat, opts), opts.chatOptions())
+}
+
+// ListModels returns the IDs of the models offered by the endpoint
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
 	if c.APIKey == "" {
-		return "", fmt.Errorf("API key is required")
+		return nil, fmt.Errorf("API key is required")
 	}
 
-	// Encode image to base64
-	base64Image := encodeToBase64(imageData)
-
-	// Build the request
-	request := buildVisionRequest(base64Image, imageFormat)
-
-	// Marshal request to JSON
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+	var modelsResp ModelsResponse
+	if err := c.doJSON(ctx, "GET", "/models", nil, &modelsResp); err != nil {
+		return nil, err
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", c.BaseURL+"/chat/completions", bytes.NewBuffer(requestBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	models := make([]string, 0, len(modelsResp.Data))
+	for _, model := range modelsResp.Data {
+		models = append(models, model.ID)
 	}
+	return models, nil
+}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+// observe reports a finished call to c.Observe, if set
+func (c *Client) observe(ctx context.Context, call Call) {
+	if c.Observe != nil {
+		c.Observe(ctx, call)
+	}
+}
 
-	// Send request
-	resp, err := c.HTTPClient.Do(req)
+// doJSON sends a request with an optional JSON body and decodes the JSON response into out
+func (c *Client) doJSON(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	resp, err := c.send(ctx, method, path, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
 	// Read response body
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Check for HTTP errors
-	if resp.StatusCode != http.StatusOK {
-		var errorResp ErrorResponse
-		if err := json.Unmarshal(responseBody, &errorResp); err != nil {
-			return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(responseBody))
+	// Parse response
+	if err := json.Unmarshal(responseBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// send sends a request with an optional JSON body and returns the response
+// if it succeeded, retrying transient failures according to c.Retry.
+// The caller must close the response body.
+func (c *Client) send(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var requestBody []byte
+	if body != nil {
+		// Marshal request to JSON
+		var err error
+		requestBody, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
-		return "", fmt.Errorf("API error: %s", errorResp.Error.Message)
 	}
 
-	// Parse response
-	var visionResp VisionResponse
-	if err := json.Unmarshal(responseBody, &visionResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	wait := c.sleep
+	if wait == nil {
+		wait = sleep
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.sendOnce(ctx, method, path, requestBody)
+		if err == nil {
+			return resp, nil
+		}
+		if !IsTransient(err) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		var retryAfter time.Duration
+		var apiErr *Error
+		if errors.As(err, &apiErr) {
+			retryAfter = apiErr.RetryAfter
+		}
+		delay, ok := c.Retry.delay(attempt, retryAfter)
+		if !ok {
+			return nil, err
+		}
+		if err := wait(ctx, delay); err != nil {
+			return nil, err
+		}
 	}
+}
 
-	// Extract text from response
-	if len(visionResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+// sendOnce makes a single attempt at a request
+func (c *Client) sendOnce(ctx context.Context, method, path string, requestBody []byte) (*http.Response, error) {
+	var reqBody io.Reader
+	if requestBody != nil {
+		reqBody = bytes.NewReader(requestBody)
 	}
 
-	// Get the text content from the first choice
-	choice := visionResp.Choices[0]
-	if textContent, ok := choice.Message.Content.(string); ok {
-		return textContent, nil
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return "", fmt.Errorf("unexpected response format")
+	// Set headers
+	if requestBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	// Send request
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	// Check for HTTP errors
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		responseBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, parseError(resp, responseBody)
+	}
+
+	return resp, nil
 }
 
 // encodeToBase64 encodes data to base64 string
@@ -103,46 +202,26 @@ func encodeToBase64(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
 }
 
-// buildVisionRequest builds the Vision API request
-func buildVisionRequest(base64Image, format string) *VisionRequest {
-	// Determine MIME type based on format
-	mimeType := "image/png"
-	switch format {
-	case "jpeg", "jpg":
-		mimeType = "image/jpeg"
-	case "bmp":
-		mimeType = "image/bmp"
-	}
-
-	// Create the data URL
-	dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, base64Image)
-
-	return &VisionRequest{
-		Model: "moonshot-v1-8k-vision-preview",
-		Messages: []Message{
-			{
-				Role: "system",
-				Content: TextContent{
-					Type: "text",
-					Text: "Extract all text from images accurately. Return only the text content, no explanations or additional formatting.",
-				},
-			},
-			{
-				Role: "user",
-				Content: []interface{}{
-					ImageContent{
-						Type: "image_url",
-						ImageURL: ImageURL{
-							URL: dataURL,
-						},
-					},
-					TextContent{
-						Type: "text",
-						Text: "Extract all text from this image. Return only the text content.",
-					},
-				},
-			},
-		},
-		Temperature: 0.3,
+// visionMessages builds the messages of a Vision API request
+func visionMessages(base64Image, format string, opts VisionOptions) []Message {
+	var messages []Message
+	if opts.SystemPrompt != "" {
+		messages = append(messages, Message{Role: "system", Content: TextPart(opts.SystemPrompt)})
+	}
+
+	userContent := []interface{}{imageURLPart(base64Image, format)}
+	if opts.UserPrompt != "" {
+		userContent = append(userContent, TextPart(opts.UserPrompt))
+	}
+	return append(messages, PartsMessage("user", userContent...))
+}
+
+// chatOptions returns the model and sampling parameters of opts
+func (opts VisionOptions) chatOptions() ChatOptions {
+	return ChatOptions{
+		Model:          opts.Model,
+		Temperature:    opts.Temperature,
+		MaxTokens:      opts.MaxTokens,
+		ResponseFormat: opts.ResponseFormat,
 	}
 }
@@ -0,0 +1,141 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_ExtractTextFromImage(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Expected /chat/completions, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Unexpected Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":20,"completion_tokens":1,"total_tokens":21}}`))
+	}))
+	defer server.Close()
+
+	var observed Call
+	client := NewClient(server.URL, "test-key")
+	client.Observe = func(ctx context.Context, call Call) {
+		observed = call
+	}
+	text, err := client.ExtractTextFromImage(context.Background(), []byte{0x89, 'P', 'N', 'G'}, "png", VisionOptions{
+		Model:        "custom-vision",
+		SystemPrompt: "system prompt",
+		UserPrompt:   "user prompt",
+		Temperature:  0.7,
+		MaxTokens:    512,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "hello" {
+		t.Errorf("Expected 'hello', got %s", text)
+	}
+
+	if observed.Model != "custom-vision" || observed.Usage.PromptTokens != 20 || observed.Usage.CompletionTokens != 1 {
+		t.Errorf("Expected observed usage, got %+v", observed)
+	}
+
+	if got["model"] != "custom-vision" {
+		t.Errorf("Expected model 'custom-vision', got %v", got["model"])
+	}
+	if got["temperature"] != 0.7 {
+		t.Errorf("Expected temperature 0.7, got %v", got["temperature"])
+	}
+	if got["max_tokens"] != float64(512) {
+		t.Errorf("Expected max_tokens 512, got %v", got["max_tokens"])
+	}
+
+	messages := got["messages"].([]interface{})
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	system := messages[0].(map[string]interface{})["content"].(map[string]interface{})
+	if system["text"] != "system prompt" {
+		t.Errorf("Expected system prompt, got %v", system["text"])
+	}
+}
+
+func TestClient_ExtractTextFromImage_OmitsEmptyPrompts(t *testing.T) {
+	var got ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	if _, err := client.ExtractTextFromImage(context.Background(), []byte("img"), "png", VisionOptions{Model: "m"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(got.Messages) != 1 || got.Messages[0].Role != "user" {
+		t.Errorf("Expected a single user message, got %+v", got.Messages)
+	}
+}
+
+func TestClient_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/models" {
+			t.Errorf("Expected GET /models, got %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o"},{"id":"moonshot-v1-8k-vision-preview"}]}`))
+	}))
+	defer server.Close()
+
+	models, err := NewClient(server.URL, "test-key").ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(models) != 2 || models[0] != "gpt-4o" {
+		t.Errorf("Unexpected models: %v", models)
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"bad model","type":"invalid_request_error"}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "test-key").ListModels(context.Background())
+	if err == nil || err.Error() != "API error: bad model" {
+		t.Errorf("Expected API error, got %v", err)
+	}
+}
+
+func TestClient_ExtractTextFromImage_TruncatedJSON(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"{\"code\":\"x"},"finish_reason":"length"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "test-key").ExtractTextFromImage(context.Background(), []byte("img"), "png", VisionOptions{
+		Model:          "m",
+		ResponseFormat: JSONSchemaFormat("code", json.RawMessage(`{"type":"object"}`)),
+	})
+	if err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("Expected truncation error, got %v", err)
+	}
+
+	format, _ := got["response_format"].(map[string]interface{})
+	schema, _ := format["json_schema"].(map[string]interface{})
+	if format["type"] != "json_schema" || schema["name"] != "code" || schema["strict"] != true {
+		t.Errorf("Expected response_format in request, got %v", got["response_format"])
+	}
+}
@@ -0,0 +1,44 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Embed returns the embedding vectors of inputs from the embeddings endpoint,
+// in the order of inputs
+func (c *Client) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	if c.APIKey == "" {
+		return nil, fmt.Errorf("API key is required")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	var embedResp EmbeddingResponse
+	err := c.doJSON(ctx, "POST", "/embeddings", &EmbeddingRequest{Model: model, Input: inputs}, &embedResp)
+	c.observe(ctx, Call{Model: model, Usage: embedResp.Usage, Latency: time.Since(start), Err: err})
+	if err != nil {
+		return nil, err
+	}
+
+	// The data is not guaranteed to be in input order
+	vectors := make([][]float32, len(inputs))
+	for _, data := range embedResp.Data {
+		if data.Index < 0 || data.Index >= len(inputs) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("no embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,53 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Embed(t *testing.T) {
+	var got EmbeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("Expected path /embeddings, got %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		// Answer out of order to check the vectors are matched by index
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}],"usage":{"prompt_tokens":4,"total_tokens":4}}`))
+	}))
+	defer server.Close()
+
+	var observed Call
+	client := NewClient(server.URL, "test-key")
+	client.Observe = func(ctx context.Context, call Call) { observed = call }
+
+	vectors, err := client.Embed(context.Background(), "text-embedding-3-small", []string{"bill", "invoice"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Model != "text-embedding-3-small" || len(got.Input) != 2 || got.Input[1] != "invoice" {
+		t.Errorf("Unexpected request: %+v", got)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("Expected vectors in input order, got %v", vectors)
+	}
+	if observed.Usage.PromptTokens != 4 || observed.Model != "text-embedding-3-small" {
+		t.Errorf("Expected observed usage, got %+v", observed)
+	}
+}
+
+func TestClient_Embed_MissingVector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "key").Embed(context.Background(), "m", []string{"a", "b"}); err == nil {
+		t.Error("Expected an error for a missing embedding")
+	}
+}
@@ -0,0 +1,119 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error kinds reported by the API. Use errors.Is to check an error returned by the client.
+var (
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrContextLength = errors.New("context length exceeded")
+	ErrServer        = errors.New("server error")
+	ErrBadRequest    = errors.New("bad request")
+)
+
+// Error is a failed API request, classified from the HTTP status and ErrorResponse body
+type Error struct {
+	StatusCode int
+	Message    string
+	Type       string
+	Code       string
+	// RetryAfter is the delay requested by the Retry-After header, if any
+	RetryAfter time.Duration
+	// Transient is true when retrying the same request may succeed
+	Transient bool
+
+	kind error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("API error: %s", e.Message)
+}
+
+// Unwrap returns the error kind, such as ErrRateLimited
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+// IsTransient reports whether err is worth retrying: rate limits, server
+// errors and network failures, but not cancellation or rejected requests
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Transient
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// parseError builds an *Error from a non-2xx response
+func parseError(resp *http.Response, body []byte) *Error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+
+	var errorResp ErrorResponse
+	if err := json.Unmarshal(body, &errorResp); err == nil && errorResp.Error.Message != "" {
+		apiErr.Message = errorResp.Error.Message
+		apiErr.Type = errorResp.Error.Type
+		apiErr.Code = errorResp.Error.Code
+	} else {
+		apiErr.Message = fmt.Sprintf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden ||
+		apiErr.Code == "invalid_api_key":
+		apiErr.kind = ErrUnauthorized
+	case apiErr.Code == "context_length_exceeded" ||
+		strings.Contains(strings.ToLower(apiErr.Message), "maximum context length"):
+		apiErr.kind = ErrContextLength
+	case resp.StatusCode == http.StatusTooManyRequests:
+		apiErr.kind = ErrRateLimited
+		// An exhausted quota will not recover by waiting
+		apiErr.Transient = apiErr.Code != "insufficient_quota" && apiErr.Type != "insufficient_quota"
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout:
+		apiErr.kind = ErrServer
+		apiErr.Transient = true
+	default:
+		apiErr.kind = ErrBadRequest
+	}
+
+	return apiErr
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package openai
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how transient failures are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt; 0 disables retrying
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry, doubled for each further one
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A Retry-After longer than this is not waited for.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used by NewClient
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+}
+
+// delay returns how long to wait before retry number attempt (starting at 0),
+// and false if the request should not be retried
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if attempt >= p.MaxRetries {
+		return 0, false
+	}
+	if retryAfter > 0 {
+		if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+			return 0, false
+		}
+		return retryAfter, true
+	}
+
+	// Exponential backoff with full jitter
+	backoff := p.BaseDelay << attempt
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0, true
+	}
+	return rand.N(backoff) + 1, true
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RateLimiter spaces requests evenly to stay under a requests-per-minute limit.
+// A nil RateLimiter does not limit.
+type RateLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRateLimiter returns a limiter allowing requestsPerMinute requests per minute,
+// or nil if requestsPerMinute is not positive
+func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		limit:    requestsPerMinute,
+		interval: time.Minute / time.Duration(requestsPerMinute),
+	}
+}
+
+// Limit returns the configured requests per minute, or 0 if unlimited
+func (l *RateLimiter) Limit() int {
+	if l == nil {
+		return 0
+	}
+	return l.limit
+}
+
+// Wait blocks until the next request may be sent or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait := slot.Sub(now); wait > 0 {
+		return sleep(ctx, wait)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordSleeps replaces the client's retry sleep with one that records the delays
+func recordSleeps(c *Client) *[]time.Duration {
+	var delays []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return &delays
+}
+
+func TestClient_RetriesTransientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"slow down","type":"requests","code":"rate_limit_exceeded"}}`))
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"data":[{"id":"m"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	delays := recordSleeps(client)
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(models) != 1 {
+		t.Errorf("Unexpected models: %v", models)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+	if len(*delays) != 2 || (*delays)[0] != 2*time.Second {
+		t.Errorf("Expected Retry-After to be respected, got delays %v", *delays)
+	}
+	if d := (*delays)[1]; d <= 0 || d > 2*DefaultRetryPolicy.BaseDelay {
+		t.Errorf("Expected jittered backoff up to %v, got %v", 2*DefaultRetryPolicy.BaseDelay, d)
+	}
+}
+
+func TestClient_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":{"message":"overloaded"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	client.Retry.MaxRetries = 2
+	recordSleeps(client)
+
+	_, err := client.ListModels(context.Background())
+	if !errors.Is(err, ErrServer) || !IsTransient(err) {
+		t.Errorf("Expected transient server error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestClient_ErrorClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		kind      error
+		transient bool
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"error":{"message":"bad key","code":"invalid_api_key"}}`, ErrUnauthorized, false},
+		{"forbidden", http.StatusForbidden, `forbidden`, ErrUnauthorized, false},
+		{"rate limited", http.StatusTooManyRequests, `{"error":{"message":"slow down"}}`, ErrRateLimited, true},
+		{"quota exhausted", http.StatusTooManyRequests, `{"error":{"message":"no credit","type":"insufficient_quota"}}`, ErrRateLimited, false},
+		{"context length", http.StatusBadRequest, `{"error":{"message":"too long","code":"context_length_exceeded"}}`, ErrContextLength, false},
+		{"context length message", http.StatusBadRequest, `{"error":{"message":"This model's maximum context length is 8192 tokens"}}`, ErrContextLength, false},
+		{"server", http.StatusInternalServerError, `oops`, ErrServer, true},
+		{"bad request", http.StatusBadRequest, `{"error":{"message":"bad model"}}`, ErrBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "test-key")
+			client.Retry.MaxRetries = 0
+
+			_, err := client.ListModels(context.Background())
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("Expected %v, got %v", tt.kind, err)
+			}
+			if IsTransient(err) != tt.transient {
+				t.Errorf("Expected transient=%v for %v", tt.transient, err)
+			}
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("Expected *Error with status %d, got %#v", tt.status, err)
+			}
+		})
+	}
+}
+
+func TestClient_DoesNotRetryLongRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	recordSleeps(client)
+
+	_, err := client.ListModels(context.Background())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Hour {
+		t.Errorf("Expected rate limit error with RetryAfter 1h, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single attempt, got %d", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 0; attempt < 5; attempt++ {
+		limit := min(p.BaseDelay<<attempt, p.MaxDelay)
+		for i := 0; i < 20; i++ {
+			d, ok := p.delay(attempt, 0)
+			if !ok || d <= 0 || d > limit {
+				t.Fatalf("attempt %d: delay %v outside (0, %v]", attempt, d, limit)
+			}
+		}
+	}
+	if _, ok := p.delay(5, 0); ok {
+		t.Error("Expected no retry after MaxRetries")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	if err := NewRateLimiter(0).Wait(context.Background()); err != nil {
+		t.Errorf("Expected nil limiter not to block, got %v", err)
+	}
+
+	// 1200 requests per minute is one every 50ms
+	limiter := NewRateLimiter(1200)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected requests to be spaced out, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter.Wait(ctx)
+	if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
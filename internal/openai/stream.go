@@ -0,0 +1,62 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StreamTextFromImage extracts text from an image using a streamed Vision
+// API request. onDelta is called with each piece of text as it arrives; the
+// full text is returned once the stream ends. Cancelling ctx aborts the request.
+func (c *Client) StreamTextFromImage(ctx context.Context, imageData []byte, imageFormat string, opts VisionOptions, onDelta func(delta string)) (string, error) {
+	return c.CompleteStream(ctx, visionMessages(encodeToBase64(imageData), imageFormat, opts), opts.chatOptions(), onDelta)
+}
+
+// readStream parses a server-sent events body of chat completion chunks,
+// calling onChunk for each one until the [DONE] marker or end of stream
+func readStream(body io.Reader, onChunk func(ChatCompletionChunk) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Blank lines separate events; lines starting with ':' are comments
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			// Ignore other fields such as event: or id:
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return nil
+		}
+
+		// Some providers report errors in-band
+		var errorResp ErrorResponse
+		if err := json.Unmarshal([]byte(data), &errorResp); err == nil && errorResp.Error.Message != "" {
+			return fmt.Errorf("API error: %s", errorResp.Error.Message)
+		}
+
+		var chunk ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if err := onChunk(chunk); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil
+}
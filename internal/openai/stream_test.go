@@ -0,0 +1,140 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseServer returns a server that streams the given data payloads as server-sent events
+func sseServer(t *testing.T, payloads []string, delay time.Duration) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Error("Expected stream to be requested")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, payload := range payloads {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			flusher.Flush()
+		}
+	}))
+}
+
+// deltaChunk returns a chunk payload carrying a single content delta
+func deltaChunk(content string) string {
+	data, _ := json.Marshal(ChatCompletionChunk{
+		Choices: []ChunkChoice{{Delta: Delta{Content: content}}},
+	})
+	return string(data)
+}
+
+func TestClient_StreamTextFromImage(t *testing.T) {
+	server := sseServer(t, []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant"}}]}`,
+		deltaChunk("Hello"),
+		deltaChunk(", "),
+		deltaChunk("world"),
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":3,"total_tokens":13}}`,
+		"[DONE]",
+	}, 0)
+	defer server.Close()
+
+	var deltas []string
+	var calls []Call
+	client := NewClient(server.URL, "test-key")
+	client.Observe = func(ctx context.Context, call Call) {
+		calls = append(calls, call)
+	}
+	text, err := client.StreamTextFromImage(context.Background(), []byte("img"), "png", VisionOptions{Model: "m"}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "Hello, world" {
+		t.Errorf("Expected 'Hello, world', got %q", text)
+	}
+	if strings.Join(deltas, "|") != "Hello|, |world" {
+		t.Errorf("Unexpected deltas: %q", deltas)
+	}
+	if len(calls) != 1 || calls[0].Model != "m" || calls[0].Usage.TotalTokens != 13 || calls[0].Err != nil {
+		t.Errorf("Expected one observed call with usage, got %+v", calls)
+	}
+}
+
+func TestClient_StreamTextFromImage_Cancel(t *testing.T) {
+	payloads := make([]string, 50)
+	for i := range payloads {
+		payloads[i] = deltaChunk("x")
+	}
+	server := sseServer(t, payloads, 20*time.Millisecond)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewClient(server.URL, "test-key")
+
+	received := 0
+	_, err := client.StreamTextFromImage(ctx, []byte("img"), "png", VisionOptions{Model: "m"}, func(string) {
+		received++
+		if received == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if received >= len(payloads) {
+		t.Errorf("Expected the stream to stop early, received %d chunks", received)
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	t.Run("ignores comments and other fields", func(t *testing.T) {
+		body := ": keep-alive\nevent: message\nid: 1\ndata: " + deltaChunk("a") + "\n\ndata:" + deltaChunk("b") + "\n\n"
+		var got string
+		err := readStream(strings.NewReader(body), func(chunk ChatCompletionChunk) error {
+			got += chunk.Choices[0].Delta.Content
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got != "ab" {
+			t.Errorf("Expected 'ab', got %q", got)
+		}
+	})
+
+	t.Run("in-band error", func(t *testing.T) {
+		body := `data: {"error":{"message":"overloaded"}}` + "\n\n"
+		err := readStream(strings.NewReader(body), func(ChatCompletionChunk) error { return nil })
+		if err == nil || !strings.Contains(err.Error(), "overloaded") {
+			t.Errorf("Expected in-band API error, got %v", err)
+		}
+	})
+
+	t.Run("malformed chunk", func(t *testing.T) {
+		err := readStream(strings.NewReader("data: {not json}\n\n"), func(ChatCompletionChunk) error { return nil })
+		if err == nil {
+			t.Error("Expected error for malformed chunk")
+		}
+	})
+}
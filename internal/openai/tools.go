@@ -0,0 +1,82 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxToolRounds is the number of tool call rounds CompleteWithTools
+// allows when no limit is given
+const DefaultMaxToolRounds = 10
+
+// ErrToolLimit is returned when the model still calls tools after the last allowed round
+var ErrToolLimit = errors.New("too many rounds of tool calls")
+
+// ToolHandler runs a tool call and returns its result for the model.
+// Failures the model should know about belong in the result; an error aborts
+// the completion.
+type ToolHandler func(ctx context.Context, call ToolCall) (string, error)
+
+// FunctionTool returns a tool definition for a function taking parameters,
+// a JSON schema object
+func FunctionTool(name, description string, parameters json.RawMessage) Tool {
+	return Tool{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	}
+}
+
+// ToolMessage returns the message answering a tool call with its result
+func ToolMessage(callID, result string) Message {
+	return Message{Role: "tool", Content: result, ToolCallID: callID}
+}
+
+// CompleteWithTools is Complete for a model that may call opts.Tools. Each
+// round of tool calls the model requests is run with handle, in order, and the
+// results are sent back until the model answers with text, which is returned.
+// At most maxRounds rounds are run, or DefaultMaxToolRounds if maxRounds is 0;
+// a model that keeps calling tools gets ErrToolLimit. opts.BeforeToolRound,
+// if set, may stop the conversation before each follow-up request.
+func (c *Client) CompleteWithTools(ctx context.Context, messages []Message, opts ChatOptions, maxRounds int, handle ToolHandler) (string, error) {
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxToolRounds
+	}
+	// Keep the caller's messages intact while the conversation grows
+	messages = append([]Message(nil), messages...)
+
+	for round := 0; ; round++ {
+		if round > 0 && opts.BeforeToolRound != nil {
+			if err := opts.BeforeToolRound(); err != nil {
+				return "", err
+			}
+		}
+		choice, err := c.complete(ctx, messages, opts)
+		if err != nil {
+			return "", err
+		}
+
+		calls := choice.Message.ToolCalls
+		if len(calls) == 0 {
+			text, _ := choice.Message.Content.(string)
+			return text, nil
+		}
+		if round == maxRounds {
+			return "", fmt.Errorf("%w (limit %d)", ErrToolLimit, maxRounds)
+		}
+
+		messages = append(messages, choice.Message)
+		for _, call := range calls {
+			result, err := handle(ctx, call)
+			if err != nil {
+				return "", err
+			}
+			messages = append(messages, ToolMessage(call.ID, result))
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// toolCallResponse is a completion that calls get_weather
+const toolCallResponse = `{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[` +
+	`{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Oslo\"}"}}]},` +
+	`"finish_reason":"tool_calls"}]}`
+
+func TestClient_CompleteWithTools(t *testing.T) {
+	var requests []ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+		if len(requests) == 1 {
+			w.Write([]byte(toolCallResponse))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"It is sunny in Oslo."},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	tool := FunctionTool("get_weather", "Get the weather", json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`))
+	var calls []ToolCall
+	text, err := NewClient(server.URL, "test-key").CompleteWithTools(context.Background(),
+		[]Message{TextMessage("user", "Weather in Oslo?")},
+		ChatOptions{Model: "gpt-4o", Tools: []Tool{tool}}, 0,
+		func(ctx context.Context, call ToolCall) (string, error) {
+			calls = append(calls, call)
+			return `{"forecast":"sunny"}`, nil
+		})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "It is sunny in Oslo." {
+		t.Errorf("Unexpected text %q", text)
+	}
+
+	if len(calls) != 1 || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Oslo"}` {
+		t.Fatalf("Unexpected tool calls %+v", calls)
+	}
+	if len(requests) != 2 || len(requests[0].Tools) != 1 || requests[0].Tools[0].Function.Name != "get_weather" {
+		t.Fatalf("Expected tools in every request, got %+v", requests)
+	}
+
+	// The second request carries the assistant's call and the tool result
+	followUp := requests[1].Messages
+	if len(followUp) != 3 {
+		t.Fatalf("Expected 3 messages in follow-up, got %+v", followUp)
+	}
+	if len(followUp[1].ToolCalls) != 1 || followUp[1].ToolCalls[0].ID != "call_1" {
+		t.Errorf("Expected assistant tool call echoed, got %+v", followUp[1])
+	}
+	if followUp[2].Role != "tool" || followUp[2].ToolCallID != "call_1" || followUp[2].Content != `{"forecast":"sunny"}` {
+		t.Errorf("Expected tool result message, got %+v", followUp[2])
+	}
+}
+
+func TestClient_CompleteWithTools_Limit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(toolCallResponse))
+	}))
+	defer server.Close()
+
+	handled := 0
+	_, err := NewClient(server.URL, "test-key").CompleteWithTools(context.Background(),
+		[]Message{TextMessage("user", "Loop")}, ChatOptions{Model: "gpt-4o"}, 2,
+		func(ctx context.Context, call ToolCall) (string, error) {
+			handled++
+			return "again", nil
+		})
+	if !errors.Is(err, ErrToolLimit) {
+		t.Fatalf("Expected ErrToolLimit, got %v", err)
+	}
+	if handled != 2 || requests != 3 {
+		t.Errorf("Expected 2 rounds and 3 requests, got %d rounds and %d requests", handled, requests)
+	}
+}
+
+func TestClient_CompleteWithTools_HandlerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(toolCallResponse))
+	}))
+	defer server.Close()
+
+	abort := errors.New("cancelled by user")
+	_, err := NewClient(server.URL, "test-key").CompleteWithTools(context.Background(),
+		[]Message{TextMessage("user", "Weather?")}, ChatOptions{Model: "gpt-4o"}, 0,
+		func(ctx context.Context, call ToolCall) (string, error) { return "", abort })
+	if !errors.Is(err, abort) {
+		t.Errorf("Expected handler error, got %v", err)
+	}
+}
+
+func TestClient_CompleteWithTools_BeforeToolRound(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(toolCallResponse))
+	}))
+	defer server.Close()
+
+	// Allow the first follow-up, stop the second
+	stop := errors.New("budget exceeded")
+	checks := 0
+	opts := ChatOptions{Model: "gpt-4o", BeforeToolRound: func() error {
+		checks++
+		if checks == 2 {
+			return stop
+		}
+		return nil
+	}}
+	_, err := NewClient(server.URL, "test-key").CompleteWithTools(context.Background(),
+		[]Message{TextMessage("user", "Loop")}, opts, 5,
+		func(ctx context.Context, call ToolCall) (string, error) { return "again", nil })
+	if !errors.Is(err, stop) {
+		t.Fatalf("Expected the check's error, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+}
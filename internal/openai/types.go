@@ -1,16 +1,83 @@
 package openai
 
-// VisionRequest represents the request structure for Moonshot Vision API
-type VisionRequest struct {
+import "encoding/json"
+
+// ChatRequest represents the request body of the chat completions endpoint,
+// used for both text and vision requests
+type ChatRequest struct {
 	Model       string    `json:"model"`
 	Messages    []Message `json:"messages"`
 	Temperature float64   `json:"temperature"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+	// StreamOptions asks for token usage in the final chunk of a streamed response
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	// ResponseFormat, if set, constrains the completion to JSON
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// Tools are the functions the model may call
+	Tools []Tool `json:"tools,omitempty"`
+}
+
+// Tool describes a function the model may call
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition names a function and describes its JSON schema parameters
+type FunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// ToolCall is a function call requested by the model
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall holds the name of the called function and its arguments as a JSON string
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ResponseFormat constrains the form of a completion
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is a named JSON schema that a completion must match
+type JSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"`
+}
+
+// JSONSchemaFormat returns a response format that requires strict JSON matching schema
+func JSONSchemaFormat(name string, schema json.RawMessage) *ResponseFormat {
+	return &ResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &JSONSchema{Name: name, Schema: schema, Strict: true},
+	}
+}
+
+// StreamOptions configures a streamed response
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // Message represents a message in the conversation
 type Message struct {
 	Role    string      `json:"role"`
 	Content interface{} `json:"content"`
+	// ToolCalls are the function calls requested by an assistant message
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID is the call that a tool message answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
 }
 
 // TextContent represents text content in a message
@@ -21,8 +88,8 @@ type TextContent struct {
 
 // ImageContent represents image content in a message
 type ImageContent struct {
-	Type     string    `json:"type"`
-	ImageURL ImageURL  `json:"image_url"`
+	Type     string   `json:"type"`
+	ImageURL ImageURL `json:"image_url"`
 }
 
 // ImageURL represents the image URL structure
@@ -30,8 +97,8 @@ type ImageURL struct {
 	URL string `json:"url"`
 }
 
-// VisionResponse represents the response from Moonshot Vision API
-type VisionResponse struct {
+// ChatResponse represents the response from the chat completions endpoint
+type ChatResponse struct {
 	ID      string   `json:"id"`
 	Object  string   `json:"object"`
 	Created int64    `json:"created"`
@@ -42,9 +109,9 @@ type VisionResponse struct {
 
 // Choice represents a choice in the response
 type Choice struct {
-	Index   int     `json:"index"`
-	Message Message `json:"message"`
-	FinishReason string `json:"finish_reason"`
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
 }
 
 // Usage represents token usage information
@@ -54,6 +121,42 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+// ChatCompletionChunk represents one server-sent event of a streamed response
+type ChatCompletionChunk struct {
+	ID      string        `json:"id"`
+	Object  string        `json:"object"`
+	Created int64         `json:"created"`
+	Model   string        `json:"model"`
+	Choices []ChunkChoice `json:"choices"`
+	Usage   *Usage        `json:"usage,omitempty"`
+}
+
+// ChunkChoice represents a choice in a streamed response chunk
+type ChunkChoice struct {
+	Index        int     `json:"index"`
+	Delta        Delta   `json:"delta"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+// Delta represents the incremental message content of a chunk
+type Delta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// ModelsResponse represents the response from the models endpoint
+type ModelsResponse struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
+
+// Model represents a model offered by the API
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	OwnedBy string `json:"owned_by"`
+}
+
 // ErrorResponse represents an error response from the API
 type ErrorResponse struct {
 	Error APIError `json:"error"`
@@ -65,3 +168,24 @@ type APIError struct {
 	Type    string `json:"type"`
 	Code    string `json:"code"`
 }
+
+// EmbeddingRequest represents a request to the embeddings endpoint
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse represents the response from the embeddings endpoint
+type EmbeddingResponse struct {
+	Object string      `json:"object"`
+	Data   []Embedding `json:"data"`
+	Model  string      `json:"model"`
+	Usage  Usage       `json:"usage"`
+}
+
+// Embedding is the vector of one input, identified by its position in the request
+type Embedding struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
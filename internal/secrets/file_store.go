@@ -0,0 +1,308 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseEnv is the environment variable that overrides the generated passphrase
+const PassphraseEnv = "TALUS_SECRETS_PASSPHRASE"
+
+// scrypt parameters used to derive the file encryption key
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	saltSize     = 16
+	fileVersion  = 1
+	keyFileBytes = 32
+)
+
+// encryptedFile is the on-disk layout of the secrets file
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileStore implements SecretStore using a single encrypted file.
+// The encryption key is derived from a passphrase with scrypt and the
+// contents are sealed with XChaCha20-Poly1305.
+type FileStore struct {
+	path string
+	salt []byte
+	key  []byte
+	mu   sync.Mutex
+}
+
+// NewFileStore opens (or prepares to create) the encrypted secrets file at path
+func NewFileStore(path, passphrase string) (*FileStore, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+
+	store := &FileStore{path: path}
+
+	file, err := store.readFile()
+	switch {
+	case err == nil:
+		store.salt = file.Salt
+	case os.IsNotExist(err):
+		store.salt = make([]byte, saltSize)
+		if _, err := rand.Read(store.salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+	default:
+		return nil, err
+	}
+
+	store.key, err = scrypt.Key([]byte(passphrase), store.salt, scryptN, scryptR, scryptP, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	// Fail early on a wrong passphrase rather than on first use
+	if _, err := store.load(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Get returns the value of the named secret
+func (s *FileStore) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.loadOrEmpty()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Set stores the named secret, replacing any existing value
+func (s *FileStore) Set(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("secret name is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.loadOrEmpty()
+	if err != nil {
+		return err
+	}
+
+	values[name] = value
+	return s.save(values)
+}
+
+// Delete removes the named secret
+func (s *FileStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.loadOrEmpty()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := values[name]; !ok {
+		return ErrNotFound
+	}
+
+	delete(values, name)
+	return s.save(values)
+}
+
+// List returns the names of all stored secrets in sorted order
+func (s *FileStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.loadOrEmpty()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// readFile reads the encrypted envelope from disk
+func (s *FileStore) readFile() (*encryptedFile, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+	if file.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported secrets file version %d", file.Version)
+	}
+	return &file, nil
+}
+
+// load decrypts the secrets file
+func (s *FileStore) load() (map[string]string, error) {
+	file, err := s.readFile()
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets file (wrong passphrase?): %w", err)
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets: %w", err)
+	}
+	return values, nil
+}
+
+// loadOrEmpty decrypts the secrets file, treating a missing file as empty
+func (s *FileStore) loadOrEmpty() (map[string]string, error) {
+	values, err := s.load()
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	return values, err
+}
+
+// save encrypts and writes all secrets back to disk
+func (s *FileStore) save(values map[string]string) error {
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(s.key)
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.Marshal(encryptedFile{
+		Version:    fileVersion,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace secrets file: %w", err)
+	}
+	return nil
+}
+
+// protectedKeyPrefix marks a key file whose passphrase is encrypted with DPAPI
+const protectedKeyPrefix = "dpapi:"
+
+// LoadPassphrase returns the passphrase used to unlock the file store.
+// It is taken from TALUS_SECRETS_PASSPHRASE when set; otherwise a random
+// passphrase is generated once and kept in <dataDir>/secrets.key. On Windows
+// the key file is encrypted with DPAPI for the current user, and a plain
+// key file from an earlier version is encrypted when it is read. Elsewhere
+// the passphrase is stored in plain text, readable only by the current
+// user, so anyone who can read both secrets.key and secrets.enc can decrypt
+// the store; set TALUS_SECRETS_PASSPHRASE to keep it off the disk.
+func LoadPassphrase(dataDir string) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	keyFile := filepath.Join(dataDir, "secrets.key")
+	data, err := os.ReadFile(keyFile)
+	if err == nil {
+		content := strings.TrimSpace(string(data))
+		if content == "" {
+			return "", fmt.Errorf("secrets key file %s is empty", keyFile)
+		}
+		if encoded, ok := strings.CutPrefix(content, protectedKeyPrefix); ok {
+			return unprotectKey(encoded)
+		}
+		if keyProtected {
+			// The plain passphrase still works if it cannot be rewritten
+			writeKeyFile(keyFile, content)
+		}
+		return content, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read secrets key file: %w", err)
+	}
+	raw := make([]byte, keyFileBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate passphrase: %w", err)
+	}
+	passphrase := base64.RawURLEncoding.EncodeToString(raw)
+	if err := writeKeyFile(keyFile, passphrase); err != nil {
+		return "", err
+	}
+	return passphrase, nil
+}
+
+// writeKeyFile stores the passphrase in keyFile, protected where the
+// platform allows. It writes through a temporary file, since losing the key
+// file loses every secret.
+func writeKeyFile(keyFile, passphrase string) error {
+	content, err := protectKey(passphrase)
+	if err != nil {
+		return err
+	}
+	tmpPath := keyFile + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write secrets key file: %w", err)
+	}
+	if err := os.Rename(tmpPath, keyFile); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write secrets key file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFileStoreCompliance verifies that FileStore implements SecretStore
+func TestFileStoreCompliance(t *testing.T) {
+	var _ SecretStore = (*FileStore)(nil)
+}
+
+func TestFileStore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+
+	store, err := NewFileStore(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if _, err := store.Get("openai-api-key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.Set("openai-api-key", "sk-test-123456"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if err := store.Set("workflowy-api-key", "wf-987"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	// The value must not be written to disk in plaintext
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read secrets file: %v", err)
+	}
+	if strings.Contains(string(data), "sk-test-123456") {
+		t.Error("Secret was stored in plaintext")
+	}
+
+	// A fresh store with the same passphrase can read the values back
+	reopened, err := NewFileStore(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+
+	value, err := reopened.Get("openai-api-key")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if value != "sk-test-123456" {
+		t.Errorf("Expected 'sk-test-123456', got %s", value)
+	}
+
+	names, err := reopened.List()
+	if err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	if len(names) != 2 || names[0] != "openai-api-key" || names[1] != "workflowy-api-key" {
+		t.Errorf("Unexpected secret names: %v", names)
+	}
+
+	if err := reopened.Delete("workflowy-api-key"); err != nil {
+		t.Fatalf("Failed to delete secret: %v", err)
+	}
+	if err := reopened.Delete("workflowy-api-key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+func TestFileStore_WrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+
+	store, err := NewFileStore(path, "right")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := store.Set("name", "value"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	if _, err := NewFileStore(path, "wrong"); err == nil {
+		t.Error("Expected error when opening with the wrong passphrase")
+	}
+}
+
+func TestOpen_WrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(PassphraseEnv, "right")
+	store, err := Open(BackendFile, dir)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	if err := store.Set("name", "value"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	t.Setenv(PassphraseEnv, "wrong")
+	store, err = Open(BackendFile, dir)
+	if err == nil {
+		t.Error("Expected error when opening with the wrong passphrase")
+	}
+	if store != nil {
+		t.Errorf("Expected a nil store on failure, got %#v", store)
+	}
+}
+
+func TestLoadPassphrase(t *testing.T) {
+	t.Run("from environment", func(t *testing.T) {
+		t.Setenv(PassphraseEnv, "from-env")
+		passphrase, err := LoadPassphrase(t.TempDir())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if passphrase != "from-env" {
+			t.Errorf("Expected 'from-env', got %s", passphrase)
+		}
+	})
+
+	t.Run("generated once", func(t *testing.T) {
+		t.Setenv(PassphraseEnv, "")
+		dir := t.TempDir()
+
+		first, err := LoadPassphrase(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		second, err := LoadPassphrase(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if first == "" || first != second {
+			t.Errorf("Expected a stable generated passphrase, got %q and %q", first, second)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "secrets.key"))
+		if err != nil {
+			t.Fatalf("Failed to read key file: %v", err)
+		}
+		if protected := !strings.Contains(string(data), first); protected != keyProtected {
+			t.Errorf("Expected the key file protected: %v, got %v", keyProtected, protected)
+		}
+	})
+
+	t.Run("plain key file", func(t *testing.T) {
+		t.Setenv(PassphraseEnv, "")
+		dir := t.TempDir()
+		keyFile := filepath.Join(dir, "secrets.key")
+		if err := os.WriteFile(keyFile, []byte("plain-passphrase\n"), 0600); err != nil {
+			t.Fatalf("Failed to write key file: %v", err)
+		}
+
+		passphrase, err := LoadPassphrase(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if passphrase != "plain-passphrase" {
+			t.Errorf("Expected 'plain-passphrase', got %q", passphrase)
+		}
+		// Reading it again gives the same passphrase, protected or not
+		if again, err := LoadPassphrase(dir); err != nil || again != passphrase {
+			t.Errorf("Expected the same passphrase again, got %q, %v", again, err)
+		}
+	})
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"short", "********"},
+		{"sk-abcdefghijkl", "********ijkl"},
+	}
+
+	for _, tt := range tests {
+		if got := Mask(tt.value); got != tt.want {
+			t.Errorf("Mask(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
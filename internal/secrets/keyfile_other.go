@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package secrets
+
+import "fmt"
+
+// keyProtected reports whether the key file is encrypted on this platform
+const keyProtected = false
+
+// protectKey leaves the passphrase as it is outside Windows, where the key
+// file is only guarded by its permissions
+func protectKey(passphrase string) (string, error) {
+	return passphrase, nil
+}
+
+// unprotectKey fails outside Windows, where DPAPI is not available
+func unprotectKey(encoded string) (string, error) {
+	return "", fmt.Errorf("secrets key file is protected with DPAPI, which is only available on Windows")
+}
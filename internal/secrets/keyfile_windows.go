@@ -0,0 +1,55 @@
+//go:build windows
+// +build windows
+
+package secrets
+
+import (
+	"encoding/base64"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// keyProtected reports whether the key file is encrypted on this platform
+const keyProtected = true
+
+// protectKey encrypts the passphrase with DPAPI, so that only the current
+// Windows user can read it back
+func protectKey(passphrase string) (string, error) {
+	blob, err := dpapi([]byte(passphrase), true)
+	if err != nil {
+		return "", fmt.Errorf("failed to protect passphrase: %w", err)
+	}
+	return protectedKeyPrefix + base64.StdEncoding.EncodeToString(blob), nil
+}
+
+// unprotectKey decrypts a passphrase encrypted by protectKey
+func unprotectKey(encoded string) (string, error) {
+	blob, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(blob) == 0 {
+		return "", fmt.Errorf("secrets key file is corrupted")
+	}
+	passphrase, err := dpapi(blob, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to unprotect secrets key file (another user?): %w", err)
+	}
+	return string(passphrase), nil
+}
+
+// dpapi runs CryptProtectData or CryptUnprotectData on data for the current user
+func dpapi(data []byte, protect bool) ([]byte, error) {
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	var err error
+	if protect {
+		err = windows.CryptProtectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	} else {
+		err = windows.CryptUnprotectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	return append([]byte(nil), unsafe.Slice(out.Data, out.Size)...), nil
+}
@@ -0,0 +1,55 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// Supported secret store backends
+const (
+	BackendFile = "file"
+)
+
+// ErrNotFound is returned when a secret does not exist in the store
+var ErrNotFound = errors.New("secret not found")
+
+// SecretStore defines methods for storing named secrets such as API keys
+type SecretStore interface {
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+	List() ([]string, error)
+}
+
+// Open creates the secret store for the given backend inside dataDir.
+// An empty backend selects the encrypted-file store.
+func Open(backend, dataDir string) (SecretStore, error) {
+	switch backend {
+	case "", BackendFile:
+		passphrase, err := LoadPassphrase(dataDir)
+		if err != nil {
+			return nil, err
+		}
+		// A failed *FileStore must not become a non-nil SecretStore
+		store, err := NewFileStore(filepath.Join(dataDir, "secrets.enc"), passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unsupported secret backend %q", backend)
+	}
+}
+
+// Mask returns a display-safe version of a secret, keeping only the last
+// four characters of values long enough to not give the secret away.
+func Mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return "********"
+	}
+	return "********" + value[len(value)-4:]
+}
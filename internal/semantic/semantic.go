@@ -0,0 +1,165 @@
+// Package semantic keeps embedding vectors of documents, such as todos and
+// OCR results, up to date and ranks them by cosine similarity to a query.
+// The search is brute force over all stored vectors of the current model.
+package semantic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"talus_helper_windows/internal/models"
+)
+
+// DefaultBatchSize is the number of texts embedded per request when no batch size is given
+const DefaultBatchSize = 64
+
+// Document is a text to be found by semantic search
+type Document struct {
+	Source string
+	ID     string
+	Text   string
+}
+
+// Embedder returns the embedding vectors of texts, in order
+type Embedder func(ctx context.Context, texts []string) ([][]float32, error)
+
+// Store keeps the embedding vectors of documents
+type Store interface {
+	GetEmbeddings(ctx context.Context, source string) ([]models.Embedding, error)
+	SaveEmbedding(ctx context.Context, embedding *models.Embedding) error
+	DeleteEmbedding(ctx context.Context, source, sourceID string) error
+}
+
+// Hash identifies the vector of text computed by model
+func Hash(model, text string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sync brings the stored vectors of source in line with docs: documents that
+// are new or whose text or model changed are embedded, in batches of
+// batchSize, and vectors of documents no longer in docs are deleted.
+// Documents without text are treated as removed. It returns the number of
+// documents embedded.
+func Sync(ctx context.Context, store Store, embed Embedder, model, source string, docs []Document, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	stored, err := store.GetEmbeddings(ctx, source)
+	if err != nil {
+		return 0, err
+	}
+	hashes := make(map[string]string, len(stored))
+	for _, embedding := range stored {
+		hashes[embedding.SourceID] = embedding.Hash
+	}
+
+	var changed []Document
+	current := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		doc.Text = strings.TrimSpace(doc.Text)
+		if doc.Text == "" || current[doc.ID] {
+			continue
+		}
+		current[doc.ID] = true
+		if hashes[doc.ID] != Hash(model, doc.Text) {
+			changed = append(changed, doc)
+		}
+	}
+
+	for id := range hashes {
+		if !current[id] {
+			if err := store.DeleteEmbedding(ctx, source, id); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	embedded := 0
+	for start := 0; start < len(changed); start += batchSize {
+		batch := changed[start:min(start+batchSize, len(changed))]
+		texts := make([]string, len(batch))
+		for i, doc := range batch {
+			texts[i] = doc.Text
+		}
+		vectors, err := embed(ctx, texts)
+		if err != nil {
+			return embedded, fmt.Errorf("failed to embed %s documents: %w", source, err)
+		}
+		if len(vectors) != len(batch) {
+			return embedded, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(vectors))
+		}
+
+		now := time.Now()
+		for i, doc := range batch {
+			embedding := models.Embedding{
+				Source:    source,
+				SourceID:  doc.ID,
+				Model:     model,
+				Hash:      Hash(model, doc.Text),
+				Text:      doc.Text,
+				Vector:    vectors[i],
+				UpdatedAt: now,
+			}
+			if err := store.SaveEmbedding(ctx, &embedding); err != nil {
+				return embedded, err
+			}
+			embedded++
+		}
+	}
+	return embedded, nil
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if their
+// lengths differ or either is zero
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Rank returns the embeddings of model most similar to query, best first,
+// with a score of at least minScore. At most limit results are returned, or
+// all of them if limit is 0.
+func Rank(query []float32, embeddings []models.Embedding, model string, limit int, minScore float64) []models.SearchResult {
+	results := []models.SearchResult{}
+	for _, embedding := range embeddings {
+		if embedding.Model != model {
+			continue
+		}
+		score := Cosine(query, embedding.Vector)
+		if score < minScore || score == 0 {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			Source:   embedding.Source,
+			SourceID: embedding.SourceID,
+			Text:     embedding.Text,
+			Score:    score,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
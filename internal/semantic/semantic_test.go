@@ -0,0 +1,168 @@
+package semantic
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"talus_helper_windows/internal/models"
+)
+
+// memoryStore is an in-memory Store
+type memoryStore struct {
+	embeddings map[string]models.Embedding
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{embeddings: make(map[string]models.Embedding)}
+}
+
+func (s *memoryStore) GetEmbeddings(ctx context.Context, source string) ([]models.Embedding, error) {
+	var embeddings []models.Embedding
+	for _, embedding := range s.embeddings {
+		if source == "" || embedding.Source == source {
+			embeddings = append(embeddings, embedding)
+		}
+	}
+	return embeddings, nil
+}
+
+func (s *memoryStore) SaveEmbedding(ctx context.Context, embedding *models.Embedding) error {
+	s.embeddings[embedding.Source+"/"+embedding.SourceID] = *embedding
+	return nil
+}
+
+func (s *memoryStore) DeleteEmbedding(ctx context.Context, source, sourceID string) error {
+	delete(s.embeddings, source+"/"+sourceID)
+	return nil
+}
+
+// lengthEmbedder embeds a text as its length and records every call
+type lengthEmbedder struct {
+	calls [][]string
+}
+
+func (e *lengthEmbedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls = append(e.calls, texts)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text)), 1}
+	}
+	return vectors, nil
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	embedder := &lengthEmbedder{}
+
+	docs := []Document{
+		{Source: models.SourceTodo, ID: "1", Text: "Pay the bill"},
+		{Source: models.SourceTodo, ID: "2", Text: "Call Bob"},
+		{Source: models.SourceTodo, ID: "3", Text: "Book a room"},
+		{Source: models.SourceTodo, ID: "4", Text: "  "},
+	}
+	embedded, err := Sync(ctx, store, embedder.embed, "m1", models.SourceTodo, docs, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if embedded != 3 || len(embedder.calls) != 2 {
+		t.Errorf("Expected 3 documents embedded in 2 batches, got %d in %v", embedded, embedder.calls)
+	}
+
+	// Unchanged documents are not embedded again
+	embedder.calls = nil
+	if embedded, _ := Sync(ctx, store, embedder.embed, "m1", models.SourceTodo, docs, 2); embedded != 0 || len(embedder.calls) != 0 {
+		t.Errorf("Expected nothing embedded, got %d in %v", embedded, embedder.calls)
+	}
+
+	// A changed text is embedded again and a removed document is deleted
+	docs = []Document{
+		{Source: models.SourceTodo, ID: "1", Text: "Pay the invoice"},
+		{Source: models.SourceTodo, ID: "2", Text: "Call Bob"},
+	}
+	embedder.calls = nil
+	if _, err := Sync(ctx, store, embedder.embed, "m1", models.SourceTodo, docs, 2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(embedder.calls) != 1 || len(embedder.calls[0]) != 1 || embedder.calls[0][0] != "Pay the invoice" {
+		t.Errorf("Expected only the changed text embedded, got %v", embedder.calls)
+	}
+	if len(store.embeddings) != 2 {
+		t.Errorf("Expected 2 embeddings, got %d", len(store.embeddings))
+	}
+	if got := store.embeddings["todo/1"]; got.Text != "Pay the invoice" || got.Vector[0] != 15 {
+		t.Errorf("Expected the new vector stored, got %+v", got)
+	}
+
+	// Another model embeds everything again
+	embedder.calls = nil
+	if embedded, _ := Sync(ctx, store, embedder.embed, "m2", models.SourceTodo, docs, 0); embedded != 2 {
+		t.Errorf("Expected 2 documents embedded for a new model, got %d", embedded)
+	}
+}
+
+func TestSync_EmbedError(t *testing.T) {
+	store := newMemoryStore()
+	failing := func(ctx context.Context, texts []string) ([][]float32, error) {
+		return nil, errors.New("rate limited")
+	}
+
+	_, err := Sync(context.Background(), store, failing, "m", models.SourceOCR, []Document{{ID: "1", Text: "x"}}, 0)
+	if err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("Expected embed error, got %v", err)
+	}
+	if len(store.embeddings) != 0 {
+		t.Errorf("Expected nothing stored, got %+v", store.embeddings)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 1},
+		{"scaled", []float32{1, 2}, []float32{2, 4}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"opposite", []float32{1, 0}, []float32{-1, 0}, -1},
+		{"different lengths", []float32{1, 0}, []float32{1, 0, 0}, 0},
+		{"zero vector", []float32{0, 0}, []float32{1, 0}, 0},
+		{"empty", nil, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	embeddings := []models.Embedding{
+		{Source: models.SourceTodo, SourceID: "bill", Model: "m", Text: "Pay the bill", Vector: []float32{0.9, 0.1}},
+		{Source: models.SourceOCR, SourceID: "invoice", Model: "m", Text: "Invoice", Vector: []float32{1, 0}},
+		{Source: models.SourceTodo, SourceID: "bob", Model: "m", Text: "Call Bob", Vector: []float32{0, 1}},
+		{Source: models.SourceTodo, SourceID: "old", Model: "old-model", Text: "Old", Vector: []float32{1, 0}},
+	}
+
+	results := Rank([]float32{1, 0}, embeddings, "m", 0, 0.5)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results above the minimum score, got %+v", results)
+	}
+	if results[0].SourceID != "invoice" || results[1].SourceID != "bill" {
+		t.Errorf("Expected results best first, got %+v", results)
+	}
+	if results[0].Text != "Invoice" || results[0].Source != models.SourceOCR {
+		t.Errorf("Expected document details in result, got %+v", results[0])
+	}
+
+	if limited := Rank([]float32{1, 0}, embeddings, "m", 1, 0); len(limited) != 1 || limited[0].SourceID != "invoice" {
+		t.Errorf("Expected only the best result, got %+v", limited)
+	}
+}
@@ -0,0 +1,246 @@
+// Package sensitive finds passwords, API keys, private keys, card numbers,
+// email addresses and other secrets in text, with regular expressions for
+// well-known formats and an entropy check for random-looking tokens, and
+// redacts them.
+package sensitive
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kinds of sensitive data
+const (
+	KindPassword   = "password"
+	KindAPIKey     = "api-key"
+	KindPrivateKey = "private-key"
+	KindCardNumber = "card-number"
+	KindEmail      = "email"
+	KindSecret     = "secret"
+)
+
+// Actions taken when sensitive data is found
+const (
+	ActionWarn   = "warn"
+	ActionRedact = "redact"
+	ActionBlock  = "block"
+)
+
+// Defaults of the entropy check
+const (
+	DefaultMinEntropy      = 4.0
+	DefaultMinSecretLength = 20
+)
+
+// AllKinds returns every kind of sensitive data Detect looks for
+func AllKinds() []string {
+	return []string{KindPassword, KindAPIKey, KindPrivateKey, KindCardNumber, KindEmail, KindSecret}
+}
+
+// Finding is sensitive data found in a text, at the byte offsets Start to
+// End. Preview shows only its first and last characters, so that it can be
+// reported without leaking it.
+type Finding struct {
+	Kind    string `json:"kind"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Preview string `json:"preview"`
+}
+
+// Options selects the detectors run by Detect
+type Options struct {
+	// Kinds lists the kinds to look for; empty means all
+	Kinds []string
+	// MinEntropy is the bits per character from which a token is a secret; 0 means DefaultMinEntropy
+	MinEntropy float64
+	// MinSecretLength is the shortest token checked for entropy; 0 means DefaultMinSecretLength
+	MinSecretLength int
+}
+
+// pattern finds one kind of sensitive data. When the expression has a
+// group, the group is the sensitive part; valid, if set, rejects matches.
+type pattern struct {
+	kind  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+var patterns = []pattern{
+	{kind: KindPrivateKey, re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----(?:[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----)?`)},
+	{kind: KindAPIKey, re: regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}|glpat-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{35}|xox[abposr]-[A-Za-z0-9-]{10,})`)},
+	{kind: KindAPIKey, re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`)},
+	{kind: KindAPIKey, re: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`)},
+	{kind: KindPassword, re: regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|passphrase|secret|api[_-]?key|access[_-]?token|auth[_-]?token)["']?\s*[:=]\s*["']?([^\s"',;]{4,})`)},
+	{kind: KindCardNumber, re: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: luhn},
+	{kind: KindEmail, re: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`)},
+}
+
+// token matches the runs of characters checked for entropy
+var token = regexp.MustCompile(`[A-Za-z0-9+/=_-]+`)
+
+// Detect returns the sensitive data in text, ordered by position. Where
+// findings overlap, the one starting first, or the longer one, is kept.
+func Detect(text string, opts Options) []Finding {
+	enabled := make(map[string]bool)
+	for _, kind := range opts.Kinds {
+		enabled[kind] = true
+	}
+	wanted := func(kind string) bool { return len(enabled) == 0 || enabled[kind] }
+
+	var findings []Finding
+	for _, p := range patterns {
+		if !wanted(p.kind) {
+			continue
+		}
+		for _, loc := range p.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if p.valid != nil && !p.valid(text[start:end]) {
+				continue
+			}
+			findings = append(findings, newFinding(p.kind, text, start, end))
+		}
+	}
+	if wanted(KindSecret) {
+		findings = append(findings, detectSecrets(text, opts)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Start != findings[j].Start {
+			return findings[i].Start < findings[j].Start
+		}
+		return findings[i].End > findings[j].End
+	})
+	kept := findings[:0]
+	end := 0
+	for _, finding := range findings {
+		if finding.Start < end {
+			continue
+		}
+		kept = append(kept, finding)
+		end = finding.End
+	}
+	return kept
+}
+
+// detectSecrets finds long tokens that mix letters and digits and look random
+func detectSecrets(text string, opts Options) []Finding {
+	minEntropy := opts.MinEntropy
+	if minEntropy <= 0 {
+		minEntropy = DefaultMinEntropy
+	}
+	minLength := opts.MinSecretLength
+	if minLength <= 0 {
+		minLength = DefaultMinSecretLength
+	}
+
+	var findings []Finding
+	for _, loc := range token.FindAllStringIndex(text, -1) {
+		candidate := text[loc[0]:loc[1]]
+		if len(candidate) < minLength || !strings.ContainsAny(candidate, "0123456789") ||
+			strings.IndexFunc(candidate, isLetter) < 0 {
+			continue
+		}
+		if Entropy(candidate) >= minEntropy {
+			findings = append(findings, newFinding(KindSecret, text, loc[0], loc[1]))
+		}
+	}
+	return findings
+}
+
+// isLetter reports whether r is an ASCII letter
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// Entropy returns the Shannon entropy of s in bits per character
+func Entropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// luhn reports whether the digits of number pass the Luhn check used by
+// payment card numbers
+func luhn(number string) bool {
+	var digits []int
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	for i := range digits {
+		digit := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+// newFinding builds the finding of text[start:end]
+func newFinding(kind, text string, start, end int) Finding {
+	return Finding{Kind: kind, Start: start, End: end, Preview: preview(text[start:end])}
+}
+
+// preview masks all but the first and last two characters of s, or all of
+// it when it is short
+func preview(s string) string {
+	n := utf8.RuneCountInString(s)
+	if n <= 8 {
+		return strings.Repeat("*", n)
+	}
+	runes := []rune(s)
+	return string(runes[:2]) + strings.Repeat("*", min(n-4, 8)) + string(runes[n-2:])
+}
+
+// Redact replaces every finding in text with a placeholder naming its kind.
+// findings must be ordered and must not overlap, as Detect returns them.
+func Redact(text string, findings []Finding) string {
+	var b strings.Builder
+	last := 0
+	for _, finding := range findings {
+		b.WriteString(text[last:finding.Start])
+		b.WriteString("[REDACTED " + finding.Kind + "]")
+		last = finding.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Kinds returns the distinct kinds of findings, sorted
+func Kinds(findings []Finding) []string {
+	seen := make(map[string]bool)
+	var kinds []string
+	for _, finding := range findings {
+		if !seen[finding.Kind] {
+			seen[finding.Kind] = true
+			kinds = append(kinds, finding.Kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"talus_helper_windows/internal/bundle"
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/secrets"
+	"talus_helper_windows/internal/storage"
+)
+
+// ImportResult describes what an ImportBundle call restored
+type ImportResult struct {
+	Manifest        bundle.Manifest `json:"manifest"`
+	SecretsImported int             `json:"secretsImported"`
+	SecretsSkipped  bool            `json:"secretsSkipped"`
+}
+
+// BundleService exports and imports settings-and-data bundles
+type BundleService struct {
+	ctx           context.Context
+	appVersion    string
+	storage       storage.Storage
+	secrets       secrets.SecretStore
+	configService *ConfigService
+}
+
+// NewBundleService creates a new BundleService
+func NewBundleService(ctx context.Context, appVersion string, storage storage.Storage, store secrets.SecretStore, configService *ConfigService) *BundleService {
+	return &BundleService{
+		ctx:           ctx,
+		appVersion:    appVersion,
+		storage:       storage,
+		secrets:       store,
+		configService: configService,
+	}
+}
+
+// ExportBundle writes config.toml and a snapshot of the database to path.
+// Secrets are included only when passphrase is set, encrypted with it.
+func (s *BundleService) ExportBundle(path, passphrase string) error {
+	tmpDir, err := os.MkdirTemp("", "talus-bundle-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	w, err := bundle.Create(path)
+	if err != nil {
+		return err
+	}
+
+	manifest := bundle.Manifest{
+		FormatVersion:         bundle.FormatVersion,
+		AppVersion:            s.appVersion,
+		ConfigSchemaVersion:   config.CurrentSchemaVersion,
+		DatabaseSchemaVersion: storage.SchemaVersion,
+		CreatedAt:             time.Now().UTC(),
+	}
+
+	if err := s.exportContents(w, tmpDir, passphrase, &manifest); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close(manifest)
+}
+
+// exportContents adds the config, database and secrets to the bundle
+func (s *BundleService) exportContents(w *bundle.Writer, tmpDir, passphrase string, manifest *bundle.Manifest) error {
+	configFile, err := config.Path()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(configFile); err == nil {
+		if err := w.AddFile(bundle.ConfigFile, configFile); err != nil {
+			return err
+		}
+	}
+
+	dbSnapshot := filepath.Join(tmpDir, bundle.DatabaseFile)
+	if err := s.storage.Snapshot(s.ctx, dbSnapshot); err != nil {
+		return err
+	}
+	if err := w.AddFile(bundle.DatabaseFile, dbSnapshot); err != nil {
+		return err
+	}
+
+	if passphrase == "" || s.secrets == nil {
+		return nil
+	}
+
+	// Re-encrypt the secrets with the bundle passphrase
+	secretsFile := filepath.Join(tmpDir, bundle.SecretsFile)
+	exported, err := secrets.NewFileStore(secretsFile, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := copySecrets(s.secrets, exported); err != nil {
+		return err
+	}
+	if _, err := os.Stat(secretsFile); err == nil {
+		if err := w.AddFile(bundle.SecretsFile, secretsFile); err != nil {
+			return err
+		}
+		manifest.IncludesSecrets = true
+	}
+	return nil
+}
+
+// ImportBundle restores the config, database and (with the right
+// passphrase) secrets from a bundle, migrating them to the current schema.
+// The whole bundle is checked before anything changes, and if restoring a
+// part fails the parts already restored are put back, so the import either
+// completes or leaves everything as it was.
+func (s *BundleService) ImportBundle(path, passphrase string) (ImportResult, error) {
+	r, err := bundle.Open(path)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	defer r.Close()
+
+	result := ImportResult{Manifest: r.Manifest}
+	if err := r.Manifest.Validate(config.CurrentSchemaVersion, storage.SchemaVersion); err != nil {
+		return result, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "talus-bundle-")
+	if err != nil {
+		return result, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Open the secrets first so a wrong passphrase fails before anything changes
+	var imported secrets.SecretStore
+	if r.Manifest.IncludesSecrets {
+		if passphrase == "" || s.secrets == nil {
+			result.SecretsSkipped = true
+		} else {
+			secretsFile := filepath.Join(tmpDir, bundle.SecretsFile)
+			if err := r.ExtractFile(bundle.SecretsFile, secretsFile); err != nil {
+				return result, fmt.Errorf("failed to extract secrets: %w", err)
+			}
+			store, err := secrets.NewFileStore(secretsFile, passphrase)
+			if err != nil {
+				return result, err
+			}
+			imported = store
+		}
+	}
+
+	var dbFile string
+	if r.Has(bundle.DatabaseFile) {
+		dbFile = filepath.Join(tmpDir, bundle.DatabaseFile)
+		if err := r.ExtractFile(bundle.DatabaseFile, dbFile); err != nil {
+			return result, fmt.Errorf("failed to extract database: %w", err)
+		}
+	}
+
+	var configData []byte
+	if r.Has(bundle.ConfigFile) {
+		if configData, err = r.ReadFile(bundle.ConfigFile); err != nil {
+			return result, fmt.Errorf("failed to read config from bundle: %w", err)
+		}
+		if err := config.Validate(configData); err != nil {
+			return result, err
+		}
+	}
+
+	// undo puts back, newest first, what was restored before a failure
+	var undo []func() error
+	rollback := func(err error) (ImportResult, error) {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](); undoErr != nil {
+				fmt.Printf("Failed to roll back bundle import: %v\n", undoErr)
+			}
+		}
+		return result, err
+	}
+
+	if configData != nil {
+		restoreConfig, err := s.replaceConfig(configData)
+		if err != nil {
+			return result, err
+		}
+		undo = append(undo, restoreConfig)
+	}
+
+	if dbFile != "" {
+		previous := filepath.Join(tmpDir, "previous.db")
+		if err := s.storage.Snapshot(s.ctx, previous); err != nil {
+			return rollback(err)
+		}
+		undo = append(undo, func() error { return s.storage.Restore(s.ctx, previous) })
+		if err := s.storage.Restore(s.ctx, dbFile); err != nil {
+			return rollback(err)
+		}
+	}
+
+	if imported != nil {
+		names, err := imported.List()
+		if err != nil {
+			return rollback(err)
+		}
+		undo = append(undo, s.secretsRestorer(names))
+		if err := copySecrets(imported, s.secrets); err != nil {
+			return rollback(err)
+		}
+		result.SecretsImported = len(names)
+	}
+
+	// Loading the new config runs any schema migrations it needs
+	if err := s.configService.Reload(); err != nil {
+		return rollback(err)
+	}
+	return result, nil
+}
+
+// replaceConfig writes data over config.toml and returns a function that
+// puts the previous file back, or removes the file if there was none
+func (s *BundleService) replaceConfig(data []byte) (func() error, error) {
+	configFile, err := config.Path()
+	if err != nil {
+		return nil, err
+	}
+	previous, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	existed := err == nil
+
+	if err := config.Replace(data); err != nil {
+		return nil, err
+	}
+	return func() error {
+		if !existed {
+			return os.Remove(configFile)
+		}
+		return config.Replace(previous)
+	}, nil
+}
+
+// secretsRestorer records the current values of the named secrets and
+// returns a function that puts them back, removing those that were not set
+func (s *BundleService) secretsRestorer(names []string) func() error {
+	previous := make(map[string]string)
+	for _, name := range names {
+		if value, err := s.secrets.Get(name); err == nil {
+			previous[name] = value
+		}
+	}
+	return func() error {
+		for _, name := range names {
+			value, ok := previous[name]
+			if !ok {
+				if err := s.secrets.Delete(name); err != nil && !errors.Is(err, secrets.ErrNotFound) {
+					return err
+				}
+				continue
+			}
+			if err := s.secrets.Set(name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// copySecrets copies every secret from src to dest
+func copySecrets(src, dest secrets.SecretStore) error {
+	names, err := src.List()
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+	for _, name := range names {
+		value, err := src.Get(name)
+		if err != nil {
+			return fmt.Errorf("failed to read secret %s: %w", name, err)
+		}
+		if err := dest.Set(name, value); err != nil {
+			return fmt.Errorf("failed to write secret %s: %w", name, err)
+		}
+	}
+	return nil
+}
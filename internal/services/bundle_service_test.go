@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"talus_helper_windows/internal/bundle"
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/storage"
+)
+
+// writeTestBundle writes a bundle holding the given config and database file
+func writeTestBundle(t *testing.T, configData []byte, dbFile string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	w, err := bundle.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	if err := w.AddBytes(bundle.ConfigFile, configData); err != nil {
+		t.Fatalf("Failed to add config: %v", err)
+	}
+	if err := w.AddFile(bundle.DatabaseFile, dbFile); err != nil {
+		t.Fatalf("Failed to add database: %v", err)
+	}
+	if err := w.Close(bundle.Manifest{
+		FormatVersion:         bundle.FormatVersion,
+		AppVersion:            "test",
+		ConfigSchemaVersion:   config.CurrentSchemaVersion,
+		DatabaseSchemaVersion: storage.SchemaVersion,
+		CreatedAt:             time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("Failed to close bundle: %v", err)
+	}
+	return path
+}
+
+func TestBundleService_ImportIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStorage(t)
+	todos := NewTodoService(ctx, store)
+
+	cfg := config.GetDefault()
+	cfg.Theme = "dark"
+	if err := config.Save(cfg, nil, nil); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	loaded, provenance, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	service := NewBundleService(ctx, "test", store, nil, NewConfigService(ctx, loaded, provenance, nil, nil))
+
+	if _, err := todos.AddTodo("exported"); err != nil {
+		t.Fatalf("Failed to add todo: %v", err)
+	}
+	dbFile := filepath.Join(t.TempDir(), "todos.db")
+	if err := store.Snapshot(ctx, dbFile); err != nil {
+		t.Fatalf("Failed to snapshot database: %v", err)
+	}
+	if _, err := todos.AddTodo("kept"); err != nil {
+		t.Fatalf("Failed to add todo: %v", err)
+	}
+	garbage := filepath.Join(t.TempDir(), "garbage.db")
+	if err := os.WriteFile(garbage, []byte("not a database"), 0644); err != nil {
+		t.Fatalf("Failed to write database: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		dbFile string
+	}{
+		{"invalid config", "theme = \"light\"\nmaxTodos = \"many\"\n", dbFile},
+		{"invalid database", "theme = \"light\"\n", garbage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.ImportBundle(writeTestBundle(t, []byte(tt.config), tt.dbFile), ""); err == nil {
+				t.Fatal("Expected the import to fail")
+			}
+			if list, _ := todos.GetTodos(); len(list) != 2 {
+				t.Errorf("Expected the database unchanged, got %+v", list)
+			}
+			if current, _, _ := config.Load(nil); current.Theme != "dark" {
+				t.Errorf("Expected the config unchanged, got theme %s", current.Theme)
+			}
+		})
+	}
+
+	if _, err := service.ImportBundle(writeTestBundle(t, []byte("theme = \"light\"\n"), dbFile), ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if list, _ := todos.GetTodos(); len(list) != 1 || list[0].Text != "exported" {
+		t.Errorf("Expected the imported database, got %+v", list)
+	}
+	if loaded.Theme != "light" {
+		t.Errorf("Expected the imported config to be loaded, got theme %s", loaded.Theme)
+	}
+}
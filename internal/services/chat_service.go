@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"talus_helper_windows/internal/clipboard"
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/storage"
+	"talus_helper_windows/internal/tools"
+	"talus_helper_windows/internal/usage"
+
+	"github.com/google/uuid"
+)
+
+// EventChatProgress is emitted with a ChatProgress payload as a streamed reply arrives
+const EventChatProgress = "chat:progress"
+
+// EventToolConfirm is emitted with a ToolConfirmation payload when the
+// assistant wants to run a destructive tool; answer with ConfirmToolCall
+const EventToolConfirm = "chat:confirm"
+
+// toolConfirmTimeout is how long a tool confirmation waits before the call is declined
+const toolConfirmTimeout = 5 * time.Minute
+
+// withheldToolResult replaces a tool result that the sensitive data action blocks
+const withheldToolResult = "Error: the result was withheld because it contains sensitive data"
+
+// chatTitleLength is the length, in characters, of a title taken from the first message
+const chatTitleLength = 60
+
+// ChatProgress reports the partial reply of a conversation while a streamed request is running
+type ChatProgress struct {
+	ConversationID string `json:"conversationId"`
+	Delta          string `json:"delta"`
+	Text           string `json:"text"`
+}
+
+// ToolConfirmation asks the user whether a destructive tool call may run
+type ToolConfirmation struct {
+	ID             string `json:"id"`
+	ConversationID string `json:"conversationId"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Arguments      string `json:"arguments"`
+}
+
+// ChatService handles conversations with the assistant on the configured
+// OpenAI-compatible endpoint
+type ChatService struct {
+	ctx          context.Context
+	config       *config.Config
+	clipboard    clipboard.Clipboard
+	storage      storage.Storage
+	todos        *TodoService
+	usage        *UsageService
+	emit         EventEmitter
+	openaiClient *openai.Client
+
+	workflowy workflowyConn
+
+	// mu guards openaiClient, workflowy and the running chat request
+	mu            sync.Mutex
+	chatRun       int
+	cancelChat    context.CancelFunc
+	confirmations map[string]chan bool
+}
+
+// NewChatService creates a new ChatService.
+// todos may be nil, in which case the assistant gets no tools.
+// usage may be nil, in which case LLM calls are not recorded or budgeted.
+// emit may be nil, in which case replies are not streamed and destructive
+// tool calls are declined.
+func NewChatService(ctx context.Context, cfg *config.Config, clipboard clipboard.Clipboard, storage storage.Storage, todos *TodoService, usage *UsageService, emit EventEmitter) *ChatService {
+	return &ChatService{
+		ctx:           ctx,
+		config:        cfg,
+		clipboard:     clipboard,
+		storage:       storage,
+		todos:         todos,
+		usage:         usage,
+		emit:          emit,
+		confirmations: make(map[string]chan bool),
+	}
+}
+
+// GetConversations returns all conversations, most recently updated first
+func (s *ChatService) GetConversations() ([]models.Conversation, error) {
+	return s.storage.GetConversations(s.ctx)
+}
+
+// CreateConversation starts an empty conversation. An empty title is
+// replaced by the start of the first message.
+func (s *ChatService) CreateConversation(title string) (models.Conversation, error) {
+	now := time.Now()
+	conversation := models.Conversation{
+		ID:        uuid.New().String(),
+		Title:     strings.TrimSpace(title),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.storage.CreateConversation(s.ctx, &conversation); err != nil {
+		return models.Conversation{}, fmt.Errorf("failed to create conversation: %w", err)
+	}
+	return conversation, nil
+}
+
+// GetChatMessages returns the messages of a conversation, oldest first
+func (s *ChatService) GetChatMessages(conversationID string) ([]models.ChatMessage, error) {
+	return s.storage.GetChatMessages(s.ctx, conversationID)
+}
+
+// GetToolInvocations returns the tools called in a conversation, oldest first
+func (s *ChatService) GetToolInvocations(conversationID string) ([]models.ToolInvocation, error) {
+	return s.storage.GetToolInvocations(s.ctx, conversationID)
+}
+
+// DeleteConversation deletes a conversation, its messages and tool invocations
+func (s *ChatService) DeleteConversation(id string) error {
+	if err := s.storage.DeleteConversation(s.ctx, id); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
+
+// SendMessage continues a conversation with text and returns the
+// assistant's reply. If attachImage is set, the clipboard image is sent with
+// the text. The earlier messages of the conversation, up to the configured
+// history length, are sent along as context.
+// The text is screened for sensitive data first and sent, and stored,
+// redacted when the action is "redact"; with the action "block", neither
+// text with sensitive data nor an image marked as holding it is sent.
+// Both messages are stored only once the reply has arrived, so a failed
+// request can simply be sent again.
+// When tools are enabled, the assistant may manage todos and Workflowy nodes;
+// destructive calls wait for ConfirmToolCall. Otherwise, when streaming is
+// enabled, partial replies are emitted as EventChatProgress events.
+// A running request can be aborted with CancelChat.
+func (s *ChatService) SendMessage(conversationID, text string, attachImage bool) (models.ChatMessage, error) {
+	text = strings.TrimSpace(text)
+	if text == "" && !attachImage {
+		return models.ChatMessage{}, fmt.Errorf("message is empty")
+	}
+	text, _, err := screenText(s.config.Sensitive, s.emit, SensitiveSourceChat, text)
+	if err != nil {
+		return models.ChatMessage{}, fmt.Errorf("message was not sent: %w", err)
+	}
+
+	conversation, err := s.storage.GetConversationByID(s.ctx, conversationID)
+	if err != nil {
+		return models.ChatMessage{}, err
+	}
+	history, err := s.storage.GetChatMessages(s.ctx, conversationID)
+	if err != nil {
+		return models.ChatMessage{}, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	userMessage := models.ChatMessage{
+		ID:             uuid.New().String(),
+		ConversationID: conversationID,
+		Role:           "user",
+		Content:        text,
+	}
+	if attachImage {
+		userMessage.Image, userMessage.ImageFormat, err = s.clipboardImage()
+		if err != nil {
+			return models.ChatMessage{}, err
+		}
+	}
+
+	client, err := s.client()
+	if err != nil {
+		return models.ChatMessage{}, err
+	}
+	if s.usage != nil {
+		if err := s.usage.CheckBudget(); err != nil {
+			return models.ChatMessage{}, err
+		}
+	}
+
+	ctx, done := s.beginRun()
+	defer done()
+
+	registry := s.toolRegistry()
+	sentAt := time.Now()
+	messages := s.requestMessages(history, userMessage, registry != nil)
+	opts := openai.ChatOptions{
+		Model:       chatModel(s.config),
+		Temperature: s.config.Chat.Temperature,
+		MaxTokens:   s.config.Chat.MaxTokens,
+	}
+	var reply string
+	if registry != nil {
+		opts.Tools = registry.Definitions()
+		if s.usage != nil {
+			// Every round of tool calls is another paid request
+			opts.BeforeToolRound = s.usage.CheckBudget
+		}
+		handle := s.screenToolResults(registry.Handler(s.confirmTool(conversationID), func(invocation models.ToolInvocation) {
+			s.logToolInvocation(conversationID, invocation)
+		}))
+		reply, err = client.CompleteWithTools(ctx, messages, opts, s.config.Chat.MaxToolRounds, handle)
+	} else if s.config.Chat.Stream && s.emit != nil {
+		var partial strings.Builder
+		reply, err = client.CompleteStream(ctx, messages, opts, func(delta string) {
+			partial.WriteString(delta)
+			s.emit(EventChatProgress, ChatProgress{ConversationID: conversationID, Delta: delta, Text: partial.String()})
+		})
+	} else {
+		reply, err = client.Complete(ctx, messages, opts)
+	}
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return models.ChatMessage{}, fmt.Errorf("chat request was cancelled")
+		}
+		if errors.Is(err, openai.ErrToolLimit) {
+			return models.ChatMessage{}, fmt.Errorf("the assistant stopped after %d rounds of tool calls without an answer", s.config.Chat.MaxToolRounds)
+		}
+		return models.ChatMessage{}, fmt.Errorf("failed to get a reply: %w", describeOpenAIError(err))
+	}
+
+	// The user message keeps its send time so tool invocations sort between it and the reply
+	now := time.Now()
+	userMessage.CreatedAt = sentAt
+	assistantMessage := models.ChatMessage{
+		ID:             uuid.New().String(),
+		ConversationID: conversationID,
+		Role:           "assistant",
+		Content:        reply,
+		CreatedAt:      now,
+	}
+	for _, message := range []*models.ChatMessage{&userMessage, &assistantMessage} {
+		if err := s.storage.CreateChatMessage(s.ctx, message); err != nil {
+			return models.ChatMessage{}, fmt.Errorf("failed to save chat message: %w", err)
+		}
+	}
+
+	if conversation.Title == "" {
+		conversation.Title = conversationTitle(text)
+	}
+	conversation.UpdatedAt = now
+	if err := s.storage.UpdateConversation(s.ctx, conversation); err != nil {
+		fmt.Printf("Failed to update conversation: %v\n", err)
+	}
+
+	return assistantMessage, nil
+}
+
+// CancelChat aborts the running chat request, if any
+func (s *ChatService) CancelChat() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancelChat != nil {
+		s.cancelChat()
+		s.cancelChat = nil
+	}
+}
+
+// ConfirmToolCall answers the EventToolConfirm request with the given ID
+func (s *ChatService) ConfirmToolCall(id string, approved bool) error {
+	s.mu.Lock()
+	answer, ok := s.confirmations[id]
+	delete(s.confirmations, id)
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("tool confirmation with id %s not found", id)
+	}
+	answer <- approved
+	return nil
+}
+
+// toolRegistry returns the tools offered to the assistant, or nil when tools are disabled
+func (s *ChatService) toolRegistry() *tools.Registry {
+	if !s.config.Chat.Tools || s.todos == nil {
+		return nil
+	}
+	registry := tools.NewRegistry(tools.TodoTools(s.todos)...)
+	s.mu.Lock()
+	client := s.workflowy.get(s.config)
+	s.mu.Unlock()
+	if client != nil {
+		registry.Add(tools.WorkflowyTools(client)...)
+	}
+	return registry
+}
+
+// confirmTool returns the confirmation of destructive tool calls in a
+// conversation, which asks the UI and waits for ConfirmToolCall. A call that
+// is not answered in time is declined.
+func (s *ChatService) confirmTool(conversationID string) tools.ConfirmFunc {
+	if s.emit == nil {
+		return nil
+	}
+	return func(ctx context.Context, tool tools.Tool, args json.RawMessage) (bool, error) {
+		id := uuid.New().String()
+		answer := make(chan bool, 1)
+		s.mu.Lock()
+		s.confirmations[id] = answer
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			delete(s.confirmations, id)
+			s.mu.Unlock()
+		}()
+
+		s.emit(EventToolConfirm, ToolConfirmation{
+			ID:             id,
+			ConversationID: conversationID,
+			Name:           tool.Name,
+			Description:    tool.Description,
+			Arguments:      string(args),
+		})
+
+		timer := time.NewTimer(toolConfirmTimeout)
+		defer timer.Stop()
+		select {
+		case approved := <-answer:
+			return approved, nil
+		case <-timer.C:
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// screenToolResults wraps handle so that tool results, such as todo and
+// Workflowy contents, are screened for sensitive data before they go back
+// to the model. A blocked result is replaced by withheldToolResult so the
+// model can still answer without it.
+func (s *ChatService) screenToolResults(handle openai.ToolHandler) openai.ToolHandler {
+	return func(ctx context.Context, call openai.ToolCall) (string, error) {
+		result, err := handle(ctx, call)
+		if err != nil {
+			return "", err
+		}
+		screened, _, err := screenText(s.config.Sensitive, s.emit, SensitiveSourceChat, result)
+		if err != nil {
+			return withheldToolResult, nil
+		}
+		return screened, nil
+	}
+}
+
+// logToolInvocation stores a tool invocation of a conversation
+func (s *ChatService) logToolInvocation(conversationID string, invocation models.ToolInvocation) {
+	invocation.ID = uuid.New().String()
+	invocation.ConversationID = conversationID
+	fmt.Printf("Tool %s(%s): %s\n", invocation.Name, invocation.Arguments, invocation.Status)
+	if err := s.storage.CreateToolInvocation(s.ctx, &invocation); err != nil {
+		fmt.Printf("Failed to save tool invocation: %v\n", err)
+	}
+}
+
+// requestMessages builds the request from the system prompt, the most
+// recent history messages and the new message. With tools, the system
+// prompt also gives today's date so the assistant can handle due dates.
+func (s *ChatService) requestMessages(history []models.ChatMessage, next models.ChatMessage, withTools bool) []openai.Message {
+	if limit := s.config.Chat.HistoryMessages; limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+
+	prompt := s.config.Chat.SystemPrompt
+	if withTools {
+		prompt = strings.TrimSpace(prompt + "\n\nToday is " + time.Now().Format("Monday, 2 January 2006") +
+			". Use the tools to work with the user's todos and Workflowy nodes; look up IDs with the list tools instead of guessing them.")
+	}
+
+	var messages []openai.Message
+	if prompt != "" {
+		messages = append(messages, openai.TextMessage("system", prompt))
+	}
+	for _, message := range append(history, next) {
+		messages = append(messages, chatMessage(message))
+	}
+	return messages
+}
+
+// chatMessage converts a stored message to a request message
+func chatMessage(message models.ChatMessage) openai.Message {
+	if len(message.Image) == 0 {
+		return openai.TextMessage(message.Role, message.Content)
+	}
+
+	var parts []interface{}
+	if message.Content != "" {
+		parts = append(parts, openai.TextPart(message.Content))
+	}
+	parts = append(parts, openai.ImagePart(message.Image, message.ImageFormat))
+	return openai.PartsMessage(message.Role, parts...)
+}
+
+// clipboardImage reads the clipboard image, scaled down and compressed like
+// an OCR image. Images marked as holding sensitive data are refused when the
+// action is "block".
+func (s *ChatService) clipboardImage() ([]byte, string, error) {
+	data, format, err := s.clipboard.ReadImage()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image from clipboard: %w", err)
+	}
+	if err := screenImage(s.ctx, s.config.Sensitive, s.storage, data); err != nil {
+		return nil, "", fmt.Errorf("image was not sent: %w", err)
+	}
+
+	// Only the size limits apply; crops and adjustments are meant for OCR
+	settings := s.config.OCR.Preprocess
+	settings.Grayscale = false
+	settings.Contrast = 0
+	settings.CropLeft, settings.CropTop, settings.CropRight, settings.CropBottom = 0, 0, 0, 0
+
+	data, format, err = preprocessImage(settings, data, format)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to prepare image: %w", err)
+	}
+	return data, format, nil
+}
+
+// conversationTitle returns the first line of text, shortened to chatTitleLength characters
+func conversationTitle(text string) string {
+	title, _, _ := strings.Cut(text, "\n")
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "Image"
+	}
+	if utf8.RuneCountInString(title) > chatTitleLength {
+		title = strings.TrimSpace(string([]rune(title)[:chatTitleLength])) + "…"
+	}
+	return title
+}
+
+// chatModel returns the chat model, falling back to the OCR model
+func chatModel(cfg *config.Config) string {
+	if cfg.Chat.Model != "" {
+		return cfg.Chat.Model
+	}
+	return cfg.OCR.Model
+}
+
+// client returns the OpenAI client, recreating it when the settings changed
+func (s *ChatService) client() (*openai.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, err := configureClient(s.openaiClient, s.config, s.usage, time.Duration(s.config.Chat.TimeoutSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	s.openaiClient = client
+	return client, nil
+}
+
+// beginRun returns a context for a chat request, which CancelChat cancels.
+// Starting a run cancels the previous one; done must be called when the
+// request finishes.
+func (s *ChatService) beginRun() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(usage.WithFeature(s.ctx, usage.FeatureChat))
+	s.mu.Lock()
+	if s.cancelChat != nil {
+		s.cancelChat()
+	}
+	s.chatRun++
+	run := s.chatRun
+	s.cancelChat = cancel
+	s.mu.Unlock()
+
+	return ctx, func() {
+		s.mu.Lock()
+		cancel()
+		// Leave a newer request's cancel func in place
+		if s.chatRun == run {
+			s.cancelChat = nil
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,421 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"talus_helper_windows/internal/clipboard"
+	"talus_helper_windows/internal/cliphistory"
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/sensitive"
+	"talus_helper_windows/internal/usage"
+)
+
+// completion is a chat completion response with the given reply
+func completion(reply string) string {
+	content, _ := json.Marshal(reply)
+	return `{"choices":[{"message":{"role":"assistant","content":` + string(content) + `},"finish_reason":"stop"}]}`
+}
+
+// toolCall is a chat completion response calling one tool
+func toolCall(name, arguments string) string {
+	args, _ := json.Marshal(arguments)
+	return `{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[` +
+		`{"id":"call_1","type":"function","function":{"name":"` + name + `","arguments":` + string(args) + `}}]},` +
+		`"finish_reason":"tool_calls"}]}`
+}
+
+// chatServer is a fake OpenAI endpoint that records the chat requests it
+// receives and answers them with respond
+type chatServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []openai.ChatRequest
+}
+
+// newChatServer starts a chatServer; respond gets the request's number, from 0
+func newChatServer(t *testing.T, respond func(w http.ResponseWriter, r *http.Request, n int)) *chatServer {
+	t.Helper()
+	server := &chatServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		server.mu.Lock()
+		n := len(server.requests)
+		server.requests = append(server.requests, req)
+		server.mu.Unlock()
+		respond(w, r, n)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// request returns the nth request received
+func (s *chatServer) request(n int) openai.ChatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[n]
+}
+
+// count returns the number of requests received
+func (s *chatServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+// newTestChatService returns a ChatService on server with tools and
+// streaming off, and a new conversation
+func newTestChatService(t *testing.T, server *chatServer, emit EventEmitter) (*ChatService, *config.Config, models.Conversation) {
+	t.Helper()
+	cfg := config.GetDefault()
+	cfg.OpenAIBaseURL = server.URL
+	cfg.OpenAIAPIKey = "sk-test"
+	cfg.OpenAIMaxRetries = 0
+	cfg.Chat.Tools = false
+	cfg.Chat.Stream = false
+
+	store := newTestStorage(t)
+	service := NewChatService(context.Background(), &cfg, clipboard.NewFake(), store, NewTodoService(context.Background(), store), nil, emit)
+	conversation, err := service.CreateConversation("")
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	return service, &cfg, conversation
+}
+
+// messageTexts returns the text content of request messages
+func messageTexts(messages []openai.Message) []string {
+	var texts []string
+	for _, message := range messages {
+		texts = append(texts, fmt.Sprint(message.Content))
+	}
+	return texts
+}
+
+func TestChatService_SendMessage_History(t *testing.T) {
+	server := newChatServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		w.Write([]byte(completion(fmt.Sprintf("reply %d", n+1))))
+	})
+	service, cfg, conversation := newTestChatService(t, server, nil)
+	cfg.Chat.HistoryMessages = 2
+
+	for i := 1; i <= 3; i++ {
+		reply, err := service.SendMessage(conversation.ID, fmt.Sprintf("  message %d\nmore  ", i), false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if want := fmt.Sprintf("reply %d", i); reply.Content != want || reply.Role != "assistant" {
+			t.Errorf("Expected %q from the assistant, got %+v", want, reply)
+		}
+	}
+
+	// Only the last two stored messages go along with the system prompt
+	got := messageTexts(server.request(2).Messages)
+	want := []string{cfg.Chat.SystemPrompt, "message 2\nmore", "reply 2", "message 3\nmore"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	messages, err := service.GetChatMessages(conversation.ID)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(messages) != 6 || messages[0].Content != "message 1\nmore" || messages[5].Content != "reply 3" {
+		t.Errorf("Expected both sides of three exchanges stored in order, got %+v", messages)
+	}
+	conversations, _ := service.GetConversations()
+	if len(conversations) != 1 || conversations[0].Title != "message 1" {
+		t.Errorf("Expected the title from the first message, got %+v", conversations)
+	}
+
+	if _, err := service.SendMessage(conversation.ID, "  ", false); err == nil {
+		t.Error("Expected an error for an empty message")
+	}
+}
+
+func TestChatService_SendMessage_FailedRequestIsNotStored(t *testing.T) {
+	server := newChatServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+	})
+	service, _, conversation := newTestChatService(t, server, nil)
+
+	if _, err := service.SendMessage(conversation.ID, "hello", false); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if messages, _ := service.GetChatMessages(conversation.ID); len(messages) != 0 {
+		t.Errorf("Expected nothing stored, got %+v", messages)
+	}
+}
+
+func TestChatService_NewRunCancelsPrevious(t *testing.T) {
+	started := make(chan struct{})
+	server := newChatServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		if n == 0 {
+			// The first request hangs until its client gives up
+			close(started)
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(completion("second")))
+	})
+	service, _, conversation := newTestChatService(t, server, nil)
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := service.SendMessage(conversation.ID, "first", false)
+		first <- err
+	}()
+	<-started
+
+	reply, err := service.SendMessage(conversation.ID, "second", false)
+	if err != nil || reply.Content != "second" {
+		t.Fatalf("Expected the second reply, got %+v and %v", reply, err)
+	}
+	select {
+	case err := <-first:
+		if err == nil || !strings.Contains(err.Error(), "cancelled") {
+			t.Errorf("Expected the first request to be cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the first request to be cancelled")
+	}
+
+	// Finished runs leave the cancel func of the current run in place
+	ctx, done := service.beginRun()
+	defer done()
+	service.CancelChat()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("Expected CancelChat to cancel the running request, got %v", ctx.Err())
+	}
+}
+
+func TestChatService_ConfirmToolCall(t *testing.T) {
+	tests := []struct {
+		name       string
+		approve    bool
+		wantTodos  int
+		wantStatus string
+	}{
+		{"approved", true, 0, models.ToolStatusOK},
+		{"declined", false, 1, models.ToolStatusDeclined},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var service *ChatService
+			var todoID string
+			server := newChatServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+				if n == 0 {
+					w.Write([]byte(toolCall("delete_todo", `{"id":"`+todoID+`"}`)))
+					return
+				}
+				w.Write([]byte(completion("Done.")))
+			})
+			var confirmations []ToolConfirmation
+			service, cfg, conversation := newTestChatService(t, server, func(name string, data ...interface{}) {
+				if name != EventToolConfirm {
+					return
+				}
+				confirmation := data[0].(ToolConfirmation)
+				confirmations = append(confirmations, confirmation)
+				if err := service.ConfirmToolCall(confirmation.ID, tt.approve); err != nil {
+					t.Errorf("Failed to confirm tool call: %v", err)
+				}
+			})
+			cfg.Chat.Tools = true
+
+			todo, err := service.todos.AddTodo("Pay rent")
+			if err != nil {
+				t.Fatalf("Failed to add todo: %v", err)
+			}
+			todoID = todo.ID
+
+			if _, err := service.SendMessage(conversation.ID, "Delete the rent todo", false); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(confirmations) != 1 || confirmations[0].Name != "delete_todo" || confirmations[0].ConversationID != conversation.ID {
+				t.Fatalf("Expected one confirmation for delete_todo, got %+v", confirmations)
+			}
+			if todos, _ := service.todos.GetTodos(); len(todos) != tt.wantTodos {
+				t.Errorf("Expected %d todos, got %+v", tt.wantTodos, todos)
+			}
+
+			invocations, err := service.GetToolInvocations(conversation.ID)
+			if err != nil {
+				t.Fatalf("Failed to get tool invocations: %v", err)
+			}
+			if len(invocations) != 1 || invocations[0].Name != "delete_todo" || invocations[0].Status != tt.wantStatus {
+				t.Errorf("Expected one %s delete_todo invocation stored, got %+v", tt.wantStatus, invocations)
+			}
+
+			// Answered confirmations are forgotten
+			if err := service.ConfirmToolCall(confirmations[0].ID, true); err == nil {
+				t.Error("Expected an error confirming an answered tool call")
+			}
+		})
+	}
+}
+
+func TestChatService_SendMessage_SensitiveData(t *testing.T) {
+	server := newChatServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		w.Write([]byte(completion("Noted.")))
+	})
+	var reports []SensitiveReport
+	service, cfg, conversation := newTestChatService(t, server, func(name string, data ...interface{}) {
+		if name == EventSensitiveData {
+			reports = append(reports, data[0].(SensitiveReport))
+		}
+	})
+	cfg.Sensitive.Action = sensitive.ActionRedact
+
+	// Redacted text is what the model gets and what is stored
+	if _, err := service.SendMessage(conversation.ID, "my password: hunter22", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := "my password: [REDACTED password]"
+	if got := messageTexts(server.request(0).Messages); got[len(got)-1] != want {
+		t.Errorf("Expected %q sent, got %q", want, got)
+	}
+	messages, _ := service.GetChatMessages(conversation.ID)
+	if len(messages) != 2 || messages[0].Content != want {
+		t.Errorf("Expected %q stored, got %+v", want, messages)
+	}
+	if len(reports) != 1 || reports[0].Source != SensitiveSourceChat || reports[0].Action != sensitive.ActionRedact {
+		t.Errorf("Expected a chat report, got %+v", reports)
+	}
+
+	// Blocked text is not sent
+	cfg.Sensitive.Action = sensitive.ActionBlock
+	if _, err := service.SendMessage(conversation.ID, "key sk-abcdefghijklmnopqrstuvwx", false); !errors.Is(err, ErrSensitiveData) {
+		t.Errorf("Expected ErrSensitiveData, got %v", err)
+	}
+
+	// Nor is an image whose OCR text was found to hold sensitive data
+	var screenshot bytes.Buffer
+	png.Encode(&screenshot, image.NewGray(image.Rect(0, 0, 40, 20)))
+	entry := models.ClipboardEntry{
+		ID:        "screenshot",
+		Kind:      clipboard.KindImage,
+		Image:     screenshot.Bytes(),
+		Format:    "png",
+		Hash:      cliphistory.Hash(clipboard.KindImage, screenshot.Bytes()),
+		Sensitive: []string{sensitive.KindCardNumber},
+		CreatedAt: time.Now(),
+		CopiedAt:  time.Now(),
+	}
+	if err := service.storage.SaveClipboardEntry(context.Background(), &entry); err != nil {
+		t.Fatalf("Failed to save clipboard entry: %v", err)
+	}
+	service.clipboard.(*clipboard.Fake).SetImage(screenshot.Bytes())
+	if _, err := service.SendMessage(conversation.ID, "What is this?", true); !errors.Is(err, ErrSensitiveData) {
+		t.Errorf("Expected ErrSensitiveData, got %v", err)
+	}
+
+	if n := server.count(); n != 1 {
+		t.Errorf("Expected no request for blocked messages, got %d requests", n)
+	}
+	if messages, _ := service.GetChatMessages(conversation.ID); len(messages) != 2 {
+		t.Errorf("Expected blocked messages not stored, got %+v", messages)
+	}
+}
+
+func TestChatService_ToolResultsAreScreened(t *testing.T) {
+	tests := []struct {
+		action string
+		want   string
+	}{
+		{sensitive.ActionRedact, "Wifi password: [REDACTED password]"},
+		{sensitive.ActionBlock, withheldToolResult},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			server := newChatServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+				if n == 0 {
+					w.Write([]byte(toolCall("list_todos", "{}")))
+					return
+				}
+				w.Write([]byte(completion("You have one todo.")))
+			})
+			service, cfg, conversation := newTestChatService(t, server, nil)
+			cfg.Chat.Tools = true
+			cfg.Sensitive.Action = tt.action
+
+			if _, err := service.todos.AddTodo("Wifi password: hunter22"); err != nil {
+				t.Fatalf("Failed to add todo: %v", err)
+			}
+			if _, err := service.SendMessage(conversation.ID, "What are my todos?", false); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			messages := server.request(1).Messages
+			result := fmt.Sprint(messages[len(messages)-1].Content)
+			if strings.Contains(result, "hunter22") || !strings.Contains(result, tt.want) {
+				t.Errorf("Expected the tool result screened to %q, got %q", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestChatService_BudgetCheckedEachToolRound(t *testing.T) {
+	var service *ChatService
+	server := newChatServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		// The first round uses up the budget
+		record := models.UsageRecord{ID: fmt.Sprintf("usage-%d", n), Model: "gpt-4o", Cost: 1, CreatedAt: time.Now()}
+		if err := service.storage.CreateUsageRecord(context.Background(), &record); err != nil {
+			t.Errorf("Failed to record usage: %v", err)
+		}
+		w.Write([]byte(toolCall("list_todos", "{}")))
+	})
+	service, cfg, conversation := newTestChatService(t, server, nil)
+	cfg.Chat.Tools = true
+	cfg.Usage.DailyBudget = 0.5
+	cfg.Usage.BudgetAction = usage.ActionBlock
+	service.usage = NewUsageService(context.Background(), cfg, service.storage, nil)
+
+	if _, err := service.SendMessage(conversation.ID, "What are my todos?", false); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Expected ErrBudgetExceeded, got %v", err)
+	}
+	if server.count() != 1 {
+		t.Errorf("Expected no request after the budget was used up, got %d requests", server.count())
+	}
+}
+
+// Run with -race: the Workflowy client is shared by concurrent messages
+func TestChatService_ConcurrentToolRegistry(t *testing.T) {
+	server := newChatServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		w.Write([]byte(completion("Done.")))
+	})
+	service, cfg, _ := newTestChatService(t, server, nil)
+	cfg.Chat.Tools = true
+	cfg.WorkflowyAPIKey = "wf-test"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := service.toolRegistry().Lookup("list_workflowy_nodes"); !ok {
+				t.Error("Expected the Workflowy tools to be offered")
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"talus_helper_windows/internal/clipboard"
+	"talus_helper_windows/internal/cliphistory"
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// clipboardHistoryLimit is the default number of entries returned by GetClipboardHistory
+const clipboardHistoryLimit = 100
+
+// sensitiveExpiryInterval is how often sensitive history entries are checked for expiry
+const sensitiveExpiryInterval = time.Minute
+
+// EventClipboardHistory is emitted with the ClipboardEntry, without its
+// image, each time clipboard content is added to the history or copied again
+const EventClipboardHistory = "clipboard:history"
+
+// ClipboardHistoryService keeps a searchable history of the texts and
+// images put on the clipboard
+type ClipboardHistoryService struct {
+	ctx       context.Context
+	clipboard clipboard.Clipboard
+	storage   storage.Storage
+	emit      EventEmitter
+
+	// config is a snapshot of the configuration for the watcher goroutine,
+	// replaced by SetConfig rather than rewritten in place
+	config atomic.Pointer[config.Config]
+}
+
+// NewClipboardHistoryService creates a new ClipboardHistoryService with a
+// copy of cfg; call SetConfig when the configuration is reloaded.
+// emit may be nil, in which case no events are sent.
+func NewClipboardHistoryService(ctx context.Context, cfg *config.Config, clipboard clipboard.Clipboard, storage storage.Storage, emit EventEmitter) *ClipboardHistoryService {
+	s := &ClipboardHistoryService{
+		ctx:       ctx,
+		clipboard: clipboard,
+		storage:   storage,
+		emit:      emit,
+	}
+	s.SetConfig(*cfg)
+	return s
+}
+
+// SetConfig replaces the configuration the history is recorded and pruned with
+func (s *ClipboardHistoryService) SetConfig(cfg config.Config) {
+	s.config.Store(&cfg)
+}
+
+// Start prunes the history and watches the clipboard in the background
+// until the service's context is done. Changes are recorded only while the
+// history is enabled, so it can be switched on and off without a restart.
+// The history is also pruned every minute, so that sensitive entries expire
+// on time.
+func (s *ClipboardHistoryService) Start() error {
+	if s.storage == nil {
+		return fmt.Errorf("clipboard history is not available")
+	}
+
+	changes, err := s.clipboard.Watch(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to watch clipboard: %w", err)
+	}
+
+	s.prune()
+	go func() {
+		ticker := time.NewTicker(sensitiveExpiryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case change, ok := <-changes:
+				if !ok {
+					return
+				}
+				s.record(change)
+			case <-ticker.C:
+				s.prune()
+			}
+		}
+	}()
+	return nil
+}
+
+// GetClipboardHistory returns up to limit entries whose text or OCR text
+// contains query, pinned entries first and then the most recently copied.
+// An empty query lists all entries; limit 0 returns the default number.
+func (s *ClipboardHistoryService) GetClipboardHistory(query string, limit int) ([]models.ClipboardEntry, error) {
+	if s.storage == nil {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = clipboardHistoryLimit
+	}
+	return s.storage.GetClipboardEntries(s.ctx, query, limit)
+}
+
+// CopyClipboardEntry puts the text or image of a history entry back on the clipboard
+func (s *ClipboardHistoryService) CopyClipboardEntry(id string) error {
+	if s.storage == nil {
+		return fmt.Errorf("clipboard history is not available")
+	}
+
+	entry, err := s.storage.GetClipboardEntry(s.ctx, id)
+	if err != nil {
+		return err
+	}
+	if entry.Kind == clipboard.KindImage {
+		return s.clipboard.WriteImage(entry.Image, entry.Format)
+	}
+	return s.clipboard.WriteText(entry.Text)
+}
+
+// PinClipboardEntry pins or unpins a history entry; pinned entries are
+// listed first and never pruned
+func (s *ClipboardHistoryService) PinClipboardEntry(id string, pinned bool) error {
+	if s.storage == nil {
+		return fmt.Errorf("clipboard history is not available")
+	}
+	return s.storage.SetClipboardEntryPinned(s.ctx, id, pinned)
+}
+
+// DeleteClipboardEntry removes an entry from the history
+func (s *ClipboardHistoryService) DeleteClipboardEntry(id string) error {
+	if s.storage == nil {
+		return fmt.Errorf("clipboard history is not available")
+	}
+	return s.storage.DeleteClipboardEntry(s.ctx, id)
+}
+
+// ClearClipboardHistory removes all unpinned entries from the history
+func (s *ClipboardHistoryService) ClearClipboardHistory() error {
+	if s.storage == nil {
+		return fmt.Errorf("clipboard history is not available")
+	}
+	_, err := s.storage.PruneClipboardHistory(s.ctx, time.Now(), 0)
+	return err
+}
+
+// record stores new clipboard content in the history. Content that is
+// empty or over the size limit is skipped. Text is screened for sensitive
+// data first: it is redacted or skipped as the sensitive data action says,
+// and entries holding it are marked so that they expire early.
+func (s *ClipboardHistoryService) record(change clipboard.Change) {
+	cfg := s.config.Load()
+	settings := cfg.ClipboardHistory
+	if !settings.Enabled {
+		return
+	}
+
+	entry, err := cliphistory.NewEntry(change, cliphistory.Options{
+		MaxBytes:      settings.MaxItemKB * 1024,
+		ThumbnailEdge: settings.ThumbnailSize,
+	}, time.Now())
+	if errors.Is(err, cliphistory.ErrEmpty) || errors.Is(err, cliphistory.ErrTooLarge) {
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed to add clipboard %s to the history: %v\n", change.Kind, err)
+		return
+	}
+
+	if entry.Kind == clipboard.KindText {
+		text, kinds, err := screenText(cfg.Sensitive, s.emit, SensitiveSourceClipboard, entry.Text)
+		if err != nil {
+			return
+		}
+		entry.Text = text
+		entry.Sensitive = kinds
+	}
+
+	entry.ID = uuid.New().String()
+	if err := s.storage.SaveClipboardEntry(s.ctx, &entry); err != nil {
+		fmt.Printf("Failed to save clipboard entry: %v\n", err)
+		return
+	}
+	s.prune()
+
+	if s.emit != nil {
+		entry.Image = nil
+		s.emit(EventClipboardHistory, entry)
+	}
+}
+
+// prune removes unpinned entries past the retention period and over the
+// entry cap, and unpinned sensitive entries past their expiry
+func (s *ClipboardHistoryService) prune() {
+	cfg := s.config.Load()
+	now := time.Now()
+	settings := cfg.ClipboardHistory
+	cutoff := cliphistory.Cutoff(now, settings.RetentionDays)
+	if _, err := s.storage.PruneClipboardHistory(s.ctx, cutoff, settings.MaxEntries); err != nil {
+		fmt.Printf("Failed to prune clipboard history: %v\n", err)
+	}
+
+	if expiry := cfg.Sensitive.HistoryExpiryMinutes; expiry > 0 {
+		before := now.Add(-time.Duration(expiry) * time.Minute)
+		if _, err := s.storage.ExpireSensitiveClipboardEntries(s.ctx, before); err != nil {
+			fmt.Printf("Failed to expire sensitive clipboard entries: %v\n", err)
+		}
+	}
+}
@@ -2,56 +2,618 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"talus_helper_windows/internal/clipboard"
+	"talus_helper_windows/internal/cliphistory"
 	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/imaging"
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/ocr"
 	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/storage"
+	"talus_helper_windows/internal/transform"
+	"talus_helper_windows/internal/usage"
+
+	"github.com/google/uuid"
 )
 
+// ocrHistoryLimit is the default number of results returned by GetOCRHistory
+const ocrHistoryLimit = 50
+
+// ocrCacheDir is the directory in the data dir holding cached OCR results
+const ocrCacheDir = "ocr-cache"
+
+// EventOCRProgress is emitted with an OCRProgress payload as streamed OCR text arrives
+const EventOCRProgress = "ocr:progress"
+
+// OCRProgress reports partial OCR text while a streamed request is running
+type OCRProgress struct {
+	Delta string `json:"delta"`
+	Text  string `json:"text"`
+}
+
+// EventEmitter sends a named event with optional data to the frontend
+type EventEmitter func(name string, data ...interface{})
+
 // ClipboardService handles clipboard and OCR operations
 type ClipboardService struct {
-	ctx           context.Context
-	config        *config.Config
-	clipboard     clipboard.Clipboard
-	openaiClient  *openai.Client
+	ctx          context.Context
+	config       *config.Config
+	clipboard    clipboard.Clipboard
+	storage      storage.Storage
+	usage        *UsageService
+	emit         EventEmitter
+	openaiClient *openai.Client
+
+	// cache holds OCR results; cacheConfig is the setting it was built from
+	cache       *ocr.Cache
+	cacheConfig config.OCRCacheConfig
+
+	// mu guards the fields below and openaiClient, cache and cacheConfig,
+	// which bound methods and the clipboard watcher may use at once
+	mu        sync.Mutex
+	ocrRun    int
+	cancelOCR context.CancelFunc
 }
 
-// NewClipboardService creates a new ClipboardService
-func NewClipboardService(ctx context.Context, cfg *config.Config, clipboard clipboard.Clipboard) *ClipboardService {
+// NewClipboardService creates a new ClipboardService.
+// storage may be nil, in which case OCR results are not kept in the history.
+// usage may be nil, in which case LLM calls are not recorded or budgeted.
+// emit may be nil, in which case no progress events are sent.
+func NewClipboardService(ctx context.Context, cfg *config.Config, clipboard clipboard.Clipboard, storage storage.Storage, usage *UsageService, emit EventEmitter) *ClipboardService {
 	return &ClipboardService{
 		ctx:       ctx,
 		config:    cfg,
 		clipboard: clipboard,
+		storage:   storage,
+		usage:     usage,
+		emit:      emit,
 	}
 }
 
-// OCRFromClipboard extracts text from clipboard image using OpenAI Vision API
-func (s *ClipboardService) OCRFromClipboard() (string, error) {
-	// Validate API key and base URL
-	if s.config.OpenAIAPIKey == "" {
-		return "", fmt.Errorf("OpenAI API key is not configured. Please set it in Settings")
+// OCRFromClipboard extracts text from clipboard image using the configured
+// OCR providers, trying each in order until one succeeds.
+// Results are cached by image and OCR settings; forceRefresh skips the cached
+// result and replaces it.
+// When streaming is enabled, partial text is emitted as EventOCRProgress events.
+// A running request can be aborted with CancelOCR.
+func (s *ClipboardService) OCRFromClipboard(forceRefresh bool) (string, error) {
+	text, err := s.extract(ocr.ModeText, nil, forceRefresh, nil)
+	if err != nil {
+		return "", err
+	}
+	s.saveResult(models.OCRResult{Mode: ocr.ModeText, Text: text})
+	return text, nil
+}
+
+// OCRAndTranslate extracts text from the clipboard image like
+// OCRFromClipboard, then translates it into language, or into the configured
+// language if language is empty. The result holds both versions and is kept
+// in the OCR history.
+func (s *ClipboardService) OCRAndTranslate(language string, forceRefresh bool) (models.OCRResult, error) {
+	if language == "" {
+		language = s.config.Language
+	}
+
+	text, err := s.extract(ocr.ModeText, nil, forceRefresh, nil)
+	if err != nil {
+		return models.OCRResult{}, err
+	}
+	translation, err := s.translate(text, language, forceRefresh)
+	if err != nil {
+		return models.OCRResult{}, err
 	}
-	if s.config.OpenAIBaseURL == "" {
-		return "", fmt.Errorf("OpenAI Base URL is not configured. Please set it in Settings")
+
+	return s.saveResult(models.OCRResult{
+		Mode:        ocr.ModeTranslate,
+		Text:        text,
+		Translation: translation,
+		Language:    language,
+	}), nil
+}
+
+// OCRStructuredFromClipboard reads the clipboard image in a structured mode:
+// ocr.ModeTable, ocr.ModeCode or ocr.ModeKeyValue. The providers are asked
+// for JSON matching the mode's schema, and the response is validated before it
+// is cached or returned. Providers without structured output are skipped.
+func (s *ClipboardService) OCRStructuredFromClipboard(mode string, forceRefresh bool) (ocr.StructuredResult, error) {
+	schema, err := ocr.SchemaFor(mode)
+	if err != nil {
+		return ocr.StructuredResult{}, err
+	}
+
+	var result ocr.StructuredResult
+	_, err = s.extract(mode, schema, forceRefresh, func(raw string) error {
+		var err error
+		result, err = ocr.ParseStructured(mode, raw)
+		return err
+	})
+	if err != nil {
+		return ocr.StructuredResult{}, err
+	}
+	s.saveResult(models.OCRResult{Mode: mode, Text: result.Text})
+	return result, nil
+}
+
+// GetOCRHistory returns up to limit stored OCR results, newest first
+func (s *ClipboardService) GetOCRHistory(limit int) ([]models.OCRResult, error) {
+	if s.storage == nil {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = ocrHistoryLimit
+	}
+	return s.storage.GetOCRResults(s.ctx, limit)
+}
+
+// DeleteOCRResult removes a result from the OCR history
+func (s *ClipboardService) DeleteOCRResult(id string) error {
+	if s.storage == nil {
+		return fmt.Errorf("OCR history is not available")
+	}
+	return s.storage.DeleteOCRResult(s.ctx, id)
+}
+
+// Transform reads the clipboard text, runs it through the named
+// transformers in order and puts the result back on the clipboard. Names
+// are built-in transformers or chains from the transform.chains setting.
+// The clipboard is left unchanged when a transformer fails.
+func (s *ClipboardService) Transform(names ...string) (string, error) {
+	registry, err := s.transformers()
+	if err != nil {
+		return "", err
+	}
+	chain, err := registry.Chain("", names...)
+	if err != nil {
+		return "", err
 	}
 
+	text, err := s.clipboard.ReadText()
+	if err != nil {
+		return "", err
+	}
+	result, err := chain.Apply(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to transform clipboard text: %w", err)
+	}
+	if err := s.clipboard.WriteText(result); err != nil {
+		return "", fmt.Errorf("failed to copy transformed text: %w", err)
+	}
+	return result, nil
+}
+
+// ListTransformers returns the built-in transformers and the configured
+// chains, sorted by name
+func (s *ClipboardService) ListTransformers() ([]transform.Transformer, error) {
+	registry, err := s.transformers()
+	if err != nil {
+		return nil, err
+	}
+	return registry.List(), nil
+}
+
+// transformers returns a registry of the built-in transformers and the
+// chains in the current settings
+func (s *ClipboardService) transformers() (*transform.Registry, error) {
+	registry := transform.NewRegistry(transform.Builtins()...)
+	if err := registry.AddChains(s.config.Transform.Chains); err != nil {
+		return nil, fmt.Errorf("invalid transform chains: %w", err)
+	}
+	return registry, nil
+}
+
+// saveResult stores result in the OCR history, copies it to the clipboard
+// when auto-copy is enabled, and returns it with its ID and time set
+func (s *ClipboardService) saveResult(result models.OCRResult) models.OCRResult {
+	result.ID = uuid.New().String()
+	result.CreatedAt = time.Now()
+	if s.storage != nil {
+		// A result the user already has is more useful than a history error
+		if err := s.storage.CreateOCRResult(s.ctx, &result); err != nil {
+			fmt.Printf("Failed to save OCR result: %v\n", err)
+		}
+	}
+	if s.config.OCR.AutoCopy {
+		s.copyResult(result)
+	}
+	return result
+}
+
+// copyResult puts the text of an OCR result on the clipboard; in translate
+// mode that is the translation
+func (s *ClipboardService) copyResult(result models.OCRResult) {
+	text := result.Text
+	if result.Mode == ocr.ModeTranslate && result.Translation != "" {
+		text = result.Translation
+	}
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	if err := s.clipboard.WriteText(text); err != nil {
+		fmt.Printf("Failed to copy OCR result: %v\n", err)
+	}
+}
+
+// extract runs OCR on the clipboard image. schema is nil for plain text.
+// parse, if set, validates the response; invalid responses are not cached.
+// The text is screened for sensitive data before it is parsed, cached or
+// stored, so that redacted text is all that is kept, and blocked text is
+// not kept at all. With the action "block", images already marked as
+// holding sensitive data are not sent.
+func (s *ClipboardService) extract(mode string, schema *ocr.Schema, forceRefresh bool, parse func(string) error) (string, error) {
 	// Read image from clipboard
 	imageData, format, err := s.clipboard.ReadImage()
 	if err != nil {
 		return "", fmt.Errorf("failed to read image from clipboard: %w", err)
 	}
 
-	// Initialize OpenAI client if not already done
-	if s.openaiClient == nil {
-		s.openaiClient = openai.NewClient(s.config.OpenAIBaseURL, s.config.OpenAIAPIKey)
+	original := imageData
+
+	cache := s.ocrCache()
+	cacheKey := ocr.CacheKey(imageData, append(s.cacheKeyParts(), mode)...)
+	if cache != nil && !forceRefresh {
+		if text, ok := cache.Get(cacheKey); ok && (parse == nil || parse(text) == nil) {
+			// Cached text was redacted when it was stored; screening it
+			// again reports what a "warn" action let through
+			text, kinds, _ := screenText(s.config.Sensitive, s.emit, SensitiveSourceOCR, text)
+			if schema == nil {
+				s.annotateHistory(original, text, kinds)
+			}
+			return text, nil
+		}
+	}
+
+	// An image whose OCR text was blocked before is not sent again
+	if err := screenImage(s.ctx, s.config.Sensitive, s.storage, original); err != nil {
+		return "", fmt.Errorf("image was not sent for OCR: %w", err)
+	}
+
+	provider, err := s.provider()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, done := s.beginRun(usage.FeatureOCR)
+	defer done()
+
+	imageData, format, err = s.preprocess(imageData, format)
+	if err != nil {
+		return "", err
 	}
 
 	// Extract text from image
-	text, err := s.openaiClient.ExtractTextFromImage(imageData, format)
+	req := ocr.Request{Image: imageData, Format: format, Schema: schema}
+	if schema == nil && s.config.OCR.Stream && s.emit != nil {
+		req.OnDelta = func(delta, text string) {
+			s.emit(EventOCRProgress, OCRProgress{Delta: delta, Text: text})
+		}
+	}
+	text, err := provider.ExtractText(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to extract text from image: %w", err)
+		if ctx.Err() == context.Canceled {
+			return "", fmt.Errorf("OCR was cancelled")
+		}
+		return "", fmt.Errorf("failed to extract text from image: %w", describeOpenAIError(err))
+	}
+
+	text, kinds, err := screenText(s.config.Sensitive, s.emit, SensitiveSourceOCR, text)
+	if err != nil {
+		// Mark the image so that its history entry expires like sensitive text
+		s.annotateHistory(original, "", kinds)
+		return "", fmt.Errorf("OCR text was not kept: %w", err)
+	}
+
+	if parse != nil {
+		if err := parse(text); err != nil {
+			return "", fmt.Errorf("OCR returned an unusable %s result: %w", mode, err)
+		}
+	}
+
+	if cache != nil {
+		if err := cache.Put(cacheKey, text); err != nil {
+			fmt.Printf("Failed to cache OCR result: %v\n", err)
+		}
+	}
+	if schema == nil {
+		s.annotateHistory(original, text, kinds)
 	}
 
 	return text, nil
 }
+
+// annotateHistory stores the text read from a clipboard image, and the
+// kinds of sensitive data found in it, with its clipboard history entry, so
+// that the history can be searched by it
+func (s *ClipboardService) annotateHistory(image []byte, text string, sensitive []string) {
+	if s.storage == nil {
+		return
+	}
+	hash := cliphistory.Hash(clipboard.KindImage, image)
+	if err := s.storage.SetClipboardOCRText(s.ctx, hash, text, sensitive); err != nil {
+		fmt.Printf("Failed to add OCR text to the clipboard history: %v\n", err)
+	}
+}
+
+// preprocess converts the clipboard image to the configured format, size and
+// adjustments; the image is returned unchanged when preprocessing is disabled
+func (s *ClipboardService) preprocess(data []byte, format string) ([]byte, string, error) {
+	data, format, err := preprocessImage(s.config.OCR.Preprocess, data, format)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to prepare image for OCR: %w", err)
+	}
+	return data, format, nil
+}
+
+// preprocessImage applies the preprocessing settings to an image before it
+// is sent to a model
+func preprocessImage(settings config.OCRPreprocessConfig, data []byte, format string) ([]byte, string, error) {
+	if !settings.Enabled {
+		return data, format, nil
+	}
+
+	result, err := imaging.Process(data, imaging.Options{
+		Format:      settings.Format,
+		MaxEdge:     settings.MaxEdge,
+		Grayscale:   settings.Grayscale,
+		Contrast:    settings.Contrast,
+		MaxBytes:    settings.MaxSizeKB * 1024,
+		JPEGQuality: settings.JPEGQuality,
+		Crop: imaging.Insets{
+			Left:   settings.CropLeft,
+			Top:    settings.CropTop,
+			Right:  settings.CropRight,
+			Bottom: settings.CropBottom,
+		},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return result.Data, result.Format, nil
+}
+
+// ClearOCRCache removes all cached OCR results
+func (s *ClipboardService) ClearOCRCache() error {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return fmt.Errorf("failed to get data directory: %w", err)
+	}
+	return ocr.NewCache(filepath.Join(dataDir, ocrCacheDir), 0, 0).Clear()
+}
+
+// ocrCache returns the OCR result cache, or nil if caching is disabled
+func (s *ClipboardService) ocrCache() *ocr.Cache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings := s.config.OCR.Cache
+	if !settings.Enabled {
+		return nil
+	}
+	if s.cache != nil && s.cacheConfig == settings {
+		return s.cache
+	}
+
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		fmt.Printf("Failed to get data directory for OCR cache: %v\n", err)
+		return nil
+	}
+	s.cache = ocr.NewCache(
+		filepath.Join(dataDir, ocrCacheDir),
+		time.Duration(settings.TTLHours)*time.Hour,
+		int64(settings.MaxSizeMB)*1024*1024,
+	)
+	s.cacheConfig = settings
+	return s.cache
+}
+
+// cacheKeyParts lists the settings that change the OCR result for an image
+func (s *ClipboardService) cacheKeyParts() []string {
+	ocrConfig := s.config.OCR
+	return []string{
+		strings.Join(ocrConfig.Providers, ","),
+		ocrConfig.Model,
+		ocrConfig.SystemPrompt,
+		ocrConfig.UserPrompt,
+		ocrConfig.Ollama.Model,
+		ocrConfig.Command.Path,
+		strings.Join(ocrConfig.Command.Args, " "),
+		fmt.Sprintf("%+v", ocrConfig.Preprocess),
+		// Redacted text is cached, so the result depends on the sensitive data settings
+		fmt.Sprintf("%+v", s.config.Sensitive),
+	}
+}
+
+// beginRun returns a context for an LLM request attributed to feature, which
+// CancelOCR cancels. Starting a run cancels the previous one; done must be
+// called when the request finishes.
+func (s *ClipboardService) beginRun(feature string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(usage.WithFeature(s.ctx, feature))
+	s.mu.Lock()
+	if s.cancelOCR != nil {
+		s.cancelOCR()
+	}
+	s.ocrRun++
+	run := s.ocrRun
+	s.cancelOCR = cancel
+	s.mu.Unlock()
+
+	return ctx, func() {
+		s.mu.Lock()
+		cancel()
+		// Leave a newer request's cancel func in place
+		if s.ocrRun == run {
+			s.cancelOCR = nil
+		}
+		s.mu.Unlock()
+	}
+}
+
+// CancelOCR aborts the running OCR request, if any
+func (s *ClipboardService) CancelOCR() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancelOCR != nil {
+		s.cancelOCR()
+		s.cancelOCR = nil
+	}
+}
+
+// ListModels returns the models offered by the configured endpoint
+func (s *ClipboardService) ListModels() ([]string, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+
+	models, err := client.ListModels(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", describeOpenAIError(err))
+	}
+	return models, nil
+}
+
+// provider builds the OCR provider chain from the configured provider order.
+// Providers that are not usable, such as OpenAI without an API key, are
+// skipped so that the remaining ones can still be tried.
+func (s *ClipboardService) provider() (ocr.OCRProvider, error) {
+	names := s.config.OCR.Providers
+	if len(names) == 0 {
+		names = []string{config.OCRProviderOpenAI}
+	}
+
+	var chain ocr.Fallback
+	var firstErr error
+	for _, name := range names {
+		switch name {
+		case config.OCRProviderOpenAI:
+			client, err := s.client()
+			if err == nil && s.usage != nil {
+				// A blocked budget falls back to the remaining providers
+				err = s.usage.CheckBudget()
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			chain = append(chain, ocr.NewOpenAIProvider(client, s.visionOptions(), s.config.OCR.Stream))
+		case config.OCRProviderOllama:
+			ollama := ocr.NewOllamaProvider(s.config.OCR.Ollama.BaseURL, s.config.OCR.Ollama.Model)
+			ollama.SystemPrompt = s.config.OCR.SystemPrompt
+			ollama.UserPrompt = s.config.OCR.UserPrompt
+			ollama.Temperature = s.config.OCR.Temperature
+			ollama.Stream = s.config.OCR.Stream
+			if s.usage != nil {
+				ollama.Observe = s.usage.Observe
+			}
+			httpClient, err := newHTTPClient(s.config, time.Duration(s.config.OCR.TimeoutSeconds)*time.Second)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			ollama.HTTPClient = httpClient
+			chain = append(chain, ollama)
+		case config.OCRProviderCommand:
+			command := ocr.NewCommandProvider(s.config.OCR.Command.Path, s.config.OCR.Command.Args)
+			command.Timeout = time.Duration(s.config.OCR.TimeoutSeconds) * time.Second
+			chain = append(chain, command)
+		default:
+			return nil, fmt.Errorf("unknown OCR provider %q. Please check the provider order in Settings", name)
+		}
+	}
+
+	if len(chain) == 0 {
+		return nil, firstErr
+	}
+	if len(chain) == 1 {
+		return chain[0], nil
+	}
+	return chain, nil
+}
+
+// client returns the OpenAI client, recreating it when the settings changed
+func (s *ClipboardService) client() (*openai.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, err := configureClient(s.openaiClient, s.config, s.usage, time.Duration(s.config.OCR.TimeoutSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	s.openaiClient = client
+	return client, nil
+}
+
+// configureClient returns a copy of client with the endpoint, network,
+// retry and rate limit settings applied, or a new client if client is nil or
+// the endpoint changed. client itself is left alone, so requests already
+// using it are not affected; copies share its rate limiter while the rate
+// is unchanged. usage may be nil; a timeout of 0 uses the configured HTTP
+// timeout.
+func configureClient(client *openai.Client, cfg *config.Config, usage *UsageService, timeout time.Duration) (*openai.Client, error) {
+	// Validate API key and base URL
+	if cfg.OpenAIAPIKey == "" {
+		return nil, fmt.Errorf("OpenAI API key is not configured. Please set it in Settings")
+	}
+	if cfg.OpenAIBaseURL == "" {
+		return nil, fmt.Errorf("OpenAI Base URL is not configured. Please set it in Settings")
+	}
+
+	// Initialize OpenAI client if not already done or the settings changed
+	if client == nil || client.BaseURL != cfg.OpenAIBaseURL || client.APIKey != cfg.OpenAIAPIKey {
+		client = openai.NewClient(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey)
+	} else {
+		configured := *client
+		client = &configured
+	}
+	// Clients share their transport, so building one per call is cheap and
+	// picks up changed network settings
+	httpClient, err := newHTTPClient(cfg, timeout)
+	if err != nil {
+		return nil, err
+	}
+	client.HTTPClient = httpClient
+	if usage != nil {
+		client.Observe = usage.Observe
+	}
+	client.Retry.MaxRetries = cfg.OpenAIMaxRetries
+	if client.Limiter.Limit() != cfg.OpenAIRequestsPerMinute {
+		client.Limiter = openai.NewRateLimiter(cfg.OpenAIRequestsPerMinute)
+	}
+
+	return client, nil
+}
+
+// describeOpenAIError adds a hint for errors the user can act on
+func describeOpenAIError(err error) error {
+	switch {
+	case errors.Is(err, openai.ErrUnauthorized):
+		return fmt.Errorf("%w. Please check the API key in Settings", err)
+	case errors.Is(err, openai.ErrRateLimited):
+		return fmt.Errorf("%w. The endpoint is rate limiting requests, please try again later", err)
+	case errors.Is(err, openai.ErrContextLength):
+		return fmt.Errorf("%w. The image or prompt is too large for the model", err)
+	}
+	return err
+}
+
+// visionOptions builds the vision request options from the OCR settings
+func (s *ClipboardService) visionOptions() openai.VisionOptions {
+	return openai.VisionOptions{
+		Model:        s.config.OCR.Model,
+		SystemPrompt: s.config.OCR.SystemPrompt,
+		UserPrompt:   s.config.OCR.UserPrompt,
+		Temperature:  s.config.OCR.Temperature,
+		MaxTokens:    s.config.OCR.MaxTokens,
+	}
+}
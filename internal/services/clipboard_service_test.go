@@ -0,0 +1,339 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"talus_helper_windows/internal/clipboard"
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/sensitive"
+	"talus_helper_windows/internal/storage"
+)
+
+// newTestStorage connects a migrated SQLiteStorage in a temporary home directory
+func newTestStorage(t *testing.T) storage.Storage {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	s := storage.NewSQLiteStorage()
+	if err := s.Connect(context.Background()); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return s
+}
+
+// waitForEntry returns the next clipboard history entry sent on events
+func waitForEntry(t *testing.T, events <-chan models.ClipboardEntry) models.ClipboardEntry {
+	t.Helper()
+	select {
+	case entry := <-events:
+		return entry
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a clipboard history entry")
+		return models.ClipboardEntry{}
+	}
+}
+
+func TestClipboardService_OCRAndHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"llava","response":"Invoice 42","done":true}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := newTestStorage(t)
+
+	cfg := config.GetDefault()
+	cfg.OCR.Providers = []string{config.OCRProviderOllama}
+	cfg.OCR.Ollama.BaseURL = server.URL
+	cfg.OCR.Stream = false
+	cfg.OCR.Cache.Enabled = false
+	cfg.OCR.AutoCopy = true
+
+	fake := clipboard.NewFake()
+	events := make(chan models.ClipboardEntry, 10)
+	history := NewClipboardHistoryService(ctx, &cfg, fake, store, func(name string, data ...interface{}) {
+		if name == EventClipboardHistory {
+			events <- data[0].(models.ClipboardEntry)
+		}
+	})
+	if err := history.Start(); err != nil {
+		t.Fatalf("Failed to start clipboard history: %v", err)
+	}
+
+	var screenshot bytes.Buffer
+	png.Encode(&screenshot, image.NewGray(image.Rect(0, 0, 40, 20)))
+	fake.SetImage(screenshot.Bytes())
+	imageEntry := waitForEntry(t, events)
+	if imageEntry.Kind != clipboard.KindImage || len(imageEntry.Thumbnail) == 0 {
+		t.Fatalf("Expected an image entry with a thumbnail, got %+v", imageEntry)
+	}
+
+	service := NewClipboardService(ctx, &cfg, fake, store, nil, nil)
+	text, err := service.OCRFromClipboard(false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "Invoice 42" {
+		t.Errorf("Expected 'Invoice 42', got %q", text)
+	}
+
+	// Auto-copy puts the result on the clipboard, which adds it to the history
+	if textEntry := waitForEntry(t, events); textEntry.Kind != clipboard.KindText || textEntry.Text != "Invoice 42" {
+		t.Errorf("Expected the copied OCR text in the history, got %+v", textEntry)
+	}
+
+	found, err := history.GetClipboardHistory("invoice", 0)
+	if err != nil {
+		t.Fatalf("Failed to search clipboard history: %v", err)
+	}
+	if len(found) != 2 || found[1].ID != imageEntry.ID || found[1].OCRText != "Invoice 42" {
+		t.Fatalf("Expected the copied text and the image found by its OCR text, got %+v", found)
+	}
+
+	// Copying the image again updates its entry instead of adding one
+	if err := history.CopyClipboardEntry(imageEntry.ID); err != nil {
+		t.Fatalf("Failed to copy clipboard entry: %v", err)
+	}
+	if again := waitForEntry(t, events); again.ID != imageEntry.ID {
+		t.Errorf("Expected the existing image entry, got %+v", again)
+	}
+	if data, _, _ := fake.ReadImage(); !bytes.Equal(data, screenshot.Bytes()) {
+		t.Error("Expected the original image back on the clipboard")
+	}
+}
+
+func TestClipboardService_Transform(t *testing.T) {
+	cfg := config.GetDefault()
+	cfg.Transform.Chains = map[string][]string{"tidy-json": {"trim", "json-pretty"}}
+	fake := clipboard.NewFake()
+	service := NewClipboardService(context.Background(), &cfg, fake, nil, nil, nil)
+
+	fake.SetText(" {\"a\":1} ")
+	got, err := service.Transform("tidy-json", "upper")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := "{\n  \"A\": 1\n}"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if text, _ := fake.ReadText(); text != got {
+		t.Errorf("Expected the result on the clipboard, got %q", text)
+	}
+
+	// A failing transformer leaves the clipboard as it was
+	fake.SetText("not json")
+	if _, err := service.Transform("json-minify"); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+	if text, _ := fake.ReadText(); text != "not json" {
+		t.Errorf("Expected the clipboard unchanged, got %q", text)
+	}
+
+	transformers, err := service.ListTransformers()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	found := false
+	for _, transformer := range transformers {
+		found = found || (transformer.Name == "tidy-json" && len(transformer.Steps) == 2)
+	}
+	if !found {
+		t.Errorf("Expected the configured chain to be listed, got %+v", transformers)
+	}
+}
+
+func TestClipboardService_SensitiveData(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"model":"llava","response":"login ada password: hunter22","done":true}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := newTestStorage(t)
+
+	cfg := config.GetDefault()
+	cfg.OCR.Providers = []string{config.OCRProviderOllama}
+	cfg.OCR.Ollama.BaseURL = server.URL
+	cfg.OCR.Stream = false
+	cfg.OCR.Cache.Enabled = false
+	cfg.Sensitive.Action = sensitive.ActionRedact
+
+	fake := clipboard.NewFake()
+	events := make(chan models.ClipboardEntry, 10)
+	reports := make(chan SensitiveReport, 10)
+	emit := func(name string, data ...interface{}) {
+		switch name {
+		case EventClipboardHistory:
+			events <- data[0].(models.ClipboardEntry)
+		case EventSensitiveData:
+			reports <- data[0].(SensitiveReport)
+		}
+	}
+	history := NewClipboardHistoryService(ctx, &cfg, fake, store, emit)
+	if err := history.Start(); err != nil {
+		t.Fatalf("Failed to start clipboard history: %v", err)
+	}
+
+	var screenshot bytes.Buffer
+	png.Encode(&screenshot, image.NewGray(image.Rect(0, 0, 40, 20)))
+	fake.SetImage(screenshot.Bytes())
+	imageEntry := waitForEntry(t, events)
+
+	// OCR text is redacted before it is returned or stored with the image
+	service := NewClipboardService(ctx, &cfg, fake, store, nil, emit)
+	text, err := service.OCRFromClipboard(false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := "login ada password: [REDACTED password]"; text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+	if report := <-reports; report.Source != SensitiveSourceOCR || report.Action != sensitive.ActionRedact ||
+		len(report.Findings) != 1 || report.Findings[0].Preview != "********" {
+		t.Errorf("Expected a masked OCR report, got %+v", report)
+	}
+	stored, _ := history.storage.GetClipboardEntry(ctx, imageEntry.ID)
+	if stored.OCRText != text || len(stored.Sensitive) != 1 || stored.Sensitive[0] != sensitive.KindPassword {
+		t.Errorf("Expected the redacted OCR text and its kinds with the image, got %+v", stored)
+	}
+
+	// Clipboard text is redacted before it is added to the history
+	fake.SetText("key sk-abcdefghijklmnopqrstuvwx")
+	entry := waitForEntry(t, events)
+	if entry.Text != "key [REDACTED api-key]" || len(entry.Sensitive) != 1 || entry.Sensitive[0] != sensitive.KindAPIKey {
+		t.Errorf("Expected the redacted text marked as sensitive, got %+v", entry)
+	}
+	if report := <-reports; report.Source != SensitiveSourceClipboard {
+		t.Errorf("Expected a clipboard report, got %+v", report)
+	}
+
+	// Blocked text is neither returned nor added to the history, and an
+	// image already marked as sensitive is not sent again
+	cfg.Sensitive.Action = sensitive.ActionBlock
+	history.SetConfig(cfg)
+	fake.SetImage(screenshot.Bytes())
+	waitForEntry(t, events)
+	sent := requests.Load()
+	if _, err := service.OCRFromClipboard(true); !errors.Is(err, ErrSensitiveData) {
+		t.Errorf("Expected ErrSensitiveData, got %v", err)
+	}
+	if requests.Load() != sent {
+		t.Error("Expected the marked image not to be sent for OCR")
+	}
+	fake.SetText("pwd: opensesame1")
+	fake.SetText("just notes")
+	if entry := waitForEntry(t, events); entry.Text != "just notes" || len(entry.Sensitive) != 0 {
+		t.Errorf("Expected the blocked text skipped, got %+v", entry)
+	}
+}
+
+func TestClipboardHistoryService_ConfigReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := newTestStorage(t)
+
+	cfg := config.GetDefault()
+	configs := NewConfigService(ctx, &cfg, nil, nil, nil)
+	fake := clipboard.NewFake()
+	events := make(chan models.ClipboardEntry, 100)
+	history := NewClipboardHistoryService(ctx, &cfg, fake, store, func(name string, data ...interface{}) {
+		if name == EventClipboardHistory {
+			events <- data[0].(models.ClipboardEntry)
+		}
+	})
+	configs.OnReload(history.SetConfig)
+	if err := history.Start(); err != nil {
+		t.Fatalf("Failed to start clipboard history: %v", err)
+	}
+
+	configFile, err := config.Path()
+	if err != nil {
+		t.Fatalf("Failed to get config path: %v", err)
+	}
+	// Run with -race: reloads rewrite the shared config while the watcher records
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if err := configs.Reload(); err != nil {
+				t.Errorf("Failed to reload config: %v", err)
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		fake.SetText(fmt.Sprintf("text %d", i))
+		waitForEntry(t, events)
+	}
+	<-done
+
+	// The watcher follows reloaded settings
+	data := "schemaVersion = 1\n[clipboardHistory]\nmaxItemKB = 1\n"
+	if err := os.WriteFile(configFile, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := configs.Reload(); err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	fake.SetText(strings.Repeat("x", 2048))
+	fake.SetText("small")
+	if entry := waitForEntry(t, events); entry.Text != "small" {
+		t.Errorf("Expected text over the reloaded size limit to be skipped, got %+v", entry)
+	}
+}
+
+func TestClipboardService_ConcurrentClientAndCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.GetDefault()
+	cfg.OpenAIAPIKey = "sk-test"
+	service := NewClipboardService(context.Background(), &cfg, clipboard.NewFake(), nil, nil, nil)
+
+	// Run with -race: the client and cache are created lazily by whichever call comes first
+	var wg sync.WaitGroup
+	clients := make(chan *openai.Client, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := service.client()
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+				return
+			}
+			if service.ocrCache() == nil {
+				t.Error("Expected an OCR cache")
+			}
+			clients <- client
+		}()
+	}
+	wg.Wait()
+	close(clients)
+
+	for client := range clients {
+		if client.Limiter != service.openaiClient.Limiter {
+			t.Error("Expected every client to share the rate limiter")
+		}
+	}
+}
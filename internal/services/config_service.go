@@ -2,25 +2,52 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/secrets"
 )
 
+// SecretStatus describes a configured secret without revealing its value
+type SecretStatus struct {
+	Name   string `json:"name"`
+	Set    bool   `json:"set"`
+	Masked string `json:"masked"`
+}
+
 // ConfigService handles configuration-related operations
 type ConfigService struct {
-	ctx    context.Context
-	config *config.Config
+	ctx        context.Context
+	config     *config.Config
+	provenance config.Provenance
+	args       []string
+	secrets    secrets.SecretStore
+	onReload   []func(config.Config)
 }
 
-// NewConfigService creates a new ConfigService
-func NewConfigService(ctx context.Context, cfg *config.Config) *ConfigService {
+// NewConfigService creates a new ConfigService.
+// provenance records which layer supplied each value and args are the
+// command-line flags re-applied whenever the configuration is reloaded.
+func NewConfigService(ctx context.Context, cfg *config.Config, provenance config.Provenance, args []string, store secrets.SecretStore) *ConfigService {
 	return &ConfigService{
-		ctx:    ctx,
-		config: cfg,
+		ctx:        ctx,
+		config:     cfg,
+		provenance: provenance,
+		args:       args,
+		secrets:    store,
 	}
 }
 
-// GetConfig returns the current configuration
+// OnReload registers fn to be called with the new configuration after each
+// reload. Services that read the configuration from their own goroutines
+// keep a snapshot updated by fn instead of reading the shared struct.
+func (s *ConfigService) OnReload(fn func(config.Config)) {
+	s.onReload = append(s.onReload, fn)
+}
+
+// GetConfig returns the current configuration.
+// API keys are not included; use GetSecrets for their masked status.
 func (s *ConfigService) GetConfig() (config.Config, error) {
 	if s.config == nil {
 		return config.GetDefault(), nil
@@ -28,12 +55,101 @@ func (s *ConfigService) GetConfig() (config.Config, error) {
 	return *s.config, nil
 }
 
-// SaveConfig saves the configuration
+// SaveConfig saves the configuration.
+// Only values set in the file or changed by the caller are written, and the
+// effective configuration is then reloaded, so values overridden by
+// environment variables or flags keep their override.
 func (s *ConfigService) SaveConfig(cfg config.Config) error {
-	if err := config.Save(cfg); err != nil {
+	if err := config.Save(cfg, s.config, s.provenance); err != nil {
+		return err
+	}
+	return s.Reload()
+}
+
+// ListConfigHistory returns the previous versions of the config file, newest first
+func (s *ConfigService) ListConfigHistory() ([]config.HistoryEntry, error) {
+	return config.ListHistory()
+}
+
+// RestoreConfig replaces the config file with a previous version and reloads it
+func (s *ConfigService) RestoreConfig(id string) error {
+	if err := config.RestoreHistory(id); err != nil {
 		return err
 	}
-	// Update the in-memory config
-	s.config = &cfg
+	return s.Reload()
+}
+
+// Reload rebuilds the effective configuration from all layers
+func (s *ConfigService) Reload() error {
+	loaded, provenance, err := config.Load(s.args)
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	cfg := *loaded
+	s.provenance = provenance
+
+	// Keys never round-trip through the frontend, so resolve them again
+	if s.secrets != nil {
+		if err := config.ResolveSecrets(&cfg, s.secrets); err != nil {
+			return err
+		}
+	} else {
+		cfg.OpenAIAPIKey = s.config.OpenAIAPIKey
+		cfg.WorkflowyAPIKey = s.config.WorkflowyAPIKey
+	}
+
+	// Update the in-memory config in place so other services see the change
+	*s.config = cfg
+	for _, fn := range s.onReload {
+		fn(cfg)
+	}
 	return nil
 }
+
+// Explain reports, for every config key, the effective value and the layer
+// (default, file, env or flag) that supplied it
+func (s *ConfigService) Explain() []config.FieldSource {
+	return config.Explain(s.config, s.provenance)
+}
+
+// GetSecrets returns the masked status of the secrets referenced by the configuration
+func (s *ConfigService) GetSecrets() ([]SecretStatus, error) {
+	if s.secrets == nil {
+		return nil, fmt.Errorf("secret store is not available")
+	}
+
+	names := []string{s.config.OpenAIAPIKeySecret, s.config.WorkflowyAPIKeySecret}
+	statuses := make([]SecretStatus, 0, len(names))
+	for _, name := range names {
+		value, err := s.secrets.Get(name)
+		if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+			return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+		}
+		statuses = append(statuses, SecretStatus{
+			Name:   name,
+			Set:    value != "",
+			Masked: secrets.Mask(value),
+		})
+	}
+	return statuses, nil
+}
+
+// SetSecret stores a secret value; an empty value removes the secret
+func (s *ConfigService) SetSecret(name, value string) error {
+	if s.secrets == nil {
+		return fmt.Errorf("secret store is not available")
+	}
+	if name != s.config.OpenAIAPIKeySecret && name != s.config.WorkflowyAPIKeySecret {
+		return fmt.Errorf("unknown secret %s", name)
+	}
+
+	if value == "" {
+		if err := s.secrets.Delete(name); err != nil && !errors.Is(err, secrets.ErrNotFound) {
+			return fmt.Errorf("failed to delete secret %s: %w", name, err)
+		}
+	} else if err := s.secrets.Set(name, value); err != nil {
+		return fmt.Errorf("failed to store secret %s: %w", name, err)
+	}
+
+	return config.ResolveSecrets(s.config, s.secrets)
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/httpx"
+)
+
+// newHTTPClient returns an HTTP client with the network settings of cfg.
+// A positive timeout replaces the configured request timeout.
+func newHTTPClient(cfg *config.Config, timeout time.Duration) (*http.Client, error) {
+	if timeout <= 0 {
+		timeout = time.Duration(cfg.HTTP.TimeoutSeconds) * time.Second
+	}
+	opts := httpx.Options{
+		ProxyURL:           cfg.HTTP.ProxyURL,
+		CAFile:             cfg.HTTP.CAFile,
+		InsecureSkipVerify: cfg.HTTP.InsecureSkipVerify,
+		Timeout:            timeout,
+		ConnectTimeout:     time.Duration(cfg.HTTP.ConnectTimeoutSeconds) * time.Second,
+		UserAgent:          cfg.HTTP.UserAgent,
+	}
+	if cfg.HTTP.LogRequests {
+		opts.Log = func(entry httpx.Entry) {
+			fmt.Println(entry.String())
+		}
+	}
+
+	client, err := httpx.NewClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network settings: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/semantic"
+	"talus_helper_windows/internal/storage"
+	"talus_helper_windows/internal/usage"
+	"talus_helper_windows/internal/workflowy"
+)
+
+// maxWorkflowyRequests caps the ListNodes calls of one Workflowy refresh,
+// since the children of every node take a request of their own
+const maxWorkflowyRequests = 50
+
+// SearchService finds todos, OCR results and Workflowy nodes by meaning
+// rather than keywords, using embedding vectors stored in the database
+type SearchService struct {
+	ctx       context.Context
+	config    *config.Config
+	storage   storage.Storage
+	usage     *UsageService
+	workflowy workflowyConn
+
+	// clientMu guards openaiClient, which concurrent searches reconfigure
+	clientMu     sync.Mutex
+	openaiClient *openai.Client
+
+	// mu serializes index updates
+	mu sync.Mutex
+	// workflowySynced is when the Workflowy nodes were last indexed with workflowyModel
+	workflowySynced time.Time
+	workflowyModel  string
+	// workflowyRefreshing is set while a background refresh fetches the
+	// Workflowy nodes, and workflowyRefresh is done once it finished
+	workflowyRefreshing bool
+	workflowyRefresh    sync.WaitGroup
+}
+
+// NewSearchService creates a new SearchService.
+// usage may be nil, in which case LLM calls are not recorded or budgeted.
+func NewSearchService(ctx context.Context, cfg *config.Config, storage storage.Storage, usage *UsageService) *SearchService {
+	return &SearchService{
+		ctx:     ctx,
+		config:  cfg,
+		storage: storage,
+		usage:   usage,
+	}
+}
+
+// SemanticSearch returns the documents closest in meaning to query, best
+// first, up to limit results or the configured limit if limit is 0.
+// Before searching, documents that were added or changed since the last
+// search are embedded and vectors of removed ones are deleted. Once the
+// refresh interval has passed, Workflowy nodes are fetched again in the
+// background, and later searches find them.
+// The query and the documents are screened for sensitive data before they
+// are sent to be embedded.
+func (s *SearchService) SemanticSearch(query string, limit int) ([]models.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	if limit <= 0 {
+		limit = s.config.Search.Limit
+	}
+	query, _, err := screenText(s.config.Sensitive, nil, "", query)
+	if err != nil {
+		return nil, fmt.Errorf("search query was not sent: %w", err)
+	}
+
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	if s.usage != nil {
+		if err := s.usage.CheckBudget(); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := usage.WithFeature(s.ctx, usage.FeatureSearch)
+	model := s.config.Search.Model
+	embed := func(ctx context.Context, texts []string) ([][]float32, error) {
+		return client.Embed(ctx, model, texts)
+	}
+
+	if err := s.updateIndex(ctx, embed); err != nil {
+		return nil, fmt.Errorf("failed to update search index: %w", describeOpenAIError(err))
+	}
+
+	vectors, err := embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", describeOpenAIError(err))
+	}
+	embeddings, err := s.storage.GetEmbeddings(s.ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return semantic.Rank(vectors[0], embeddings, model, limit, s.config.Search.MinScore), nil
+}
+
+// client returns the OpenAI client, recreating it when the settings changed
+func (s *SearchService) client() (*openai.Client, error) {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+
+	client, err := configureClient(s.openaiClient, s.config, s.usage, time.Duration(s.config.Chat.TimeoutSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	s.openaiClient = client
+	return client, nil
+}
+
+// updateIndex re-embeds the documents of every source that changed
+func (s *SearchService) updateIndex(ctx context.Context, embed semantic.Embedder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	model := s.config.Search.Model
+	batchSize := s.config.Search.BatchSize
+
+	todos, err := s.storage.GetTodos(s.ctx)
+	if err != nil {
+		return err
+	}
+	docs := make([]semantic.Document, 0, len(todos))
+	for _, todo := range todos {
+		docs = append(docs, semantic.Document{Source: models.SourceTodo, ID: todo.ID, Text: todo.Text})
+	}
+	docs = screenDocuments(s.config.Sensitive, docs)
+	if _, err := semantic.Sync(ctx, s.storage, embed, model, models.SourceTodo, docs, batchSize); err != nil {
+		return err
+	}
+
+	var results []models.OCRResult
+	if s.config.Search.OCRResults > 0 {
+		if results, err = s.storage.GetOCRResults(s.ctx, s.config.Search.OCRResults); err != nil {
+			return err
+		}
+	}
+	docs = make([]semantic.Document, 0, len(results))
+	for _, result := range results {
+		docs = append(docs, semantic.Document{Source: models.SourceOCR, ID: result.ID, Text: result.Text})
+	}
+	docs = screenDocuments(s.config.Sensitive, docs)
+	if _, err := semantic.Sync(ctx, s.storage, embed, model, models.SourceOCR, docs, batchSize); err != nil {
+		return err
+	}
+
+	return s.updateWorkflowy(ctx, embed)
+}
+
+// updateWorkflowy starts a background refresh of the Workflowy nodes when
+// the refresh interval has passed or the model changed. Without an API key,
+// the indexed nodes are removed.
+func (s *SearchService) updateWorkflowy(ctx context.Context, embed semantic.Embedder) error {
+	model := s.config.Search.Model
+	client := s.workflowy.get(s.config)
+	if client == nil || s.config.Search.WorkflowyMaxNodes <= 0 {
+		_, err := semantic.Sync(ctx, s.storage, embed, model, models.SourceWorkflowy, nil, s.config.Search.BatchSize)
+		return err
+	}
+
+	refresh := time.Duration(s.config.Search.WorkflowyRefreshMinutes) * time.Minute
+	if s.workflowyRefreshing || (s.workflowyModel == model && time.Since(s.workflowySynced) < refresh) {
+		return nil
+	}
+	s.workflowyRefreshing = true
+	s.workflowyRefresh.Add(1)
+	go s.refreshWorkflowy(ctx, embed, client, s.config.Search, s.config.Sensitive)
+	return nil
+}
+
+// refreshWorkflowy fetches the Workflowy nodes and indexes them. A failed
+// fetch keeps the indexed nodes until the next attempt.
+func (s *SearchService) refreshWorkflowy(ctx context.Context, embed semantic.Embedder, client workflowy.WorkflowyClient, search config.SearchConfig, sensitive config.SensitiveConfig) {
+	defer s.workflowyRefresh.Done()
+
+	// Fetch without the lock so searches are not held up
+	nodes, err := listWorkflowyNodes(client, search.WorkflowyMaxNodes)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workflowyRefreshing = false
+	if err != nil {
+		fmt.Printf("Failed to fetch Workflowy nodes for search: %v\n", err)
+		return
+	}
+
+	docs := make([]semantic.Document, 0, len(nodes))
+	for _, node := range nodes {
+		text := node.Name
+		if node.Note != nil && *node.Note != "" {
+			text += "\n" + *node.Note
+		}
+		docs = append(docs, semantic.Document{Source: models.SourceWorkflowy, ID: node.ID, Text: text})
+	}
+	docs = screenDocuments(sensitive, docs)
+	if _, err := semantic.Sync(ctx, s.storage, embed, search.Model, models.SourceWorkflowy, docs, search.BatchSize); err != nil {
+		fmt.Printf("Failed to index Workflowy nodes for search: %v\n", describeOpenAIError(err))
+		return
+	}
+	s.workflowySynced = time.Now()
+	s.workflowyModel = search.Model
+}
+
+// screenDocuments screens the text of docs for sensitive data before it is
+// embedded. Text is redacted as the sensitive data action says, and
+// documents that the action blocks are left out of the index.
+func screenDocuments(settings config.SensitiveConfig, docs []semantic.Document) []semantic.Document {
+	screened := make([]semantic.Document, 0, len(docs))
+	for _, doc := range docs {
+		text, _, err := screenText(settings, nil, doc.Source, doc.Text)
+		if err != nil {
+			continue
+		}
+		doc.Text = text
+		screened = append(screened, doc)
+	}
+	return screened
+}
+
+// listWorkflowyNodes returns up to maxNodes Workflowy nodes, level by level
+// from the top, in at most maxWorkflowyRequests requests
+func listWorkflowyNodes(client workflowy.WorkflowyClient, maxNodes int) ([]workflowy.Node, error) {
+	var nodes []workflowy.Node
+	seen := make(map[string]bool)
+	parents := []string{workflowy.TopLevelParent}
+	for requests := 0; len(parents) > 0 && len(nodes) < maxNodes && requests < maxWorkflowyRequests; requests++ {
+		parent := parents[0]
+		parents = parents[1:]
+
+		children, err := client.ListNodes(parent)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			if seen[child.ID] || len(nodes) == maxNodes {
+				continue
+			}
+			seen[child.ID] = true
+			nodes = append(nodes, child)
+			parents = append(parents, child.ID)
+		}
+	}
+	return nodes, nil
+}
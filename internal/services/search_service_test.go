@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/sensitive"
+	"talus_helper_windows/internal/workflowy"
+)
+
+// newEmbeddingServer returns a server answering embedding requests, and a
+// function returning the texts it was sent
+func newEmbeddingServer(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var inputs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.EmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		mu.Lock()
+		inputs = append(inputs, req.Input...)
+		mu.Unlock()
+
+		var resp openai.EmbeddingResponse
+		for i := range req.Input {
+			resp.Data = append(resp.Data, openai.Embedding{Index: i, Embedding: []float32{1, 0}})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	sent := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), inputs...)
+	}
+	return server, sent
+}
+
+func TestSearchService_SensitiveData(t *testing.T) {
+	server, sent := newEmbeddingServer(t)
+
+	ctx := context.Background()
+	store := newTestStorage(t)
+	cfg := config.GetDefault()
+	cfg.OpenAIBaseURL = server.URL
+	cfg.OpenAIAPIKey = "sk-test"
+	cfg.OpenAIMaxRetries = 0
+	cfg.Sensitive.Action = sensitive.ActionRedact
+
+	todos := NewTodoService(ctx, store)
+	for _, text := range []string{"Buy milk", "Router password: hunter22"} {
+		if _, err := todos.AddTodo(text); err != nil {
+			t.Fatalf("Failed to add todo: %v", err)
+		}
+	}
+	service := NewSearchService(ctx, &cfg, store, nil)
+
+	// Redacted text is what gets embedded
+	if _, err := service.SemanticSearch("milk", 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	all := strings.Join(sent(), "|")
+	if strings.Contains(all, "hunter22") || !strings.Contains(all, "Router password: [REDACTED password]") {
+		t.Errorf("Expected only redacted text embedded, got %q", sent())
+	}
+
+	// Blocked documents are left out of the index, and blocked queries are not sent
+	cfg.Sensitive.Action = sensitive.ActionBlock
+	if _, err := service.SemanticSearch("milk", 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	embeddings, err := store.GetEmbeddings(ctx, models.SourceTodo)
+	if err != nil {
+		t.Fatalf("Failed to get embeddings: %v", err)
+	}
+	if len(embeddings) != 1 || embeddings[0].Text != "Buy milk" {
+		t.Errorf("Expected only the plain todo indexed, got %+v", embeddings)
+	}
+
+	before := len(sent())
+	if _, err := service.SemanticSearch("password: hunter22", 0); !errors.Is(err, ErrSensitiveData) {
+		t.Errorf("Expected ErrSensitiveData, got %v", err)
+	}
+	if len(sent()) != before {
+		t.Errorf("Expected no request for a blocked query, got %q", sent()[before:])
+	}
+}
+
+// treeClient is a Workflowy client serving a fixed tree of nodes. Listing
+// waits for release when it is set.
+type treeClient struct {
+	workflowy.WorkflowyClient
+	children map[string][]workflowy.Node
+	release  chan struct{}
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *treeClient) ListNodes(parentID string) ([]workflowy.Node, error) {
+	if c.release != nil {
+		<-c.release
+	}
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+	return c.children[parentID], nil
+}
+
+func TestListWorkflowyNodes_CapsRequests(t *testing.T) {
+	// Many top-level nodes, each with one child
+	client := &treeClient{children: make(map[string][]workflowy.Node)}
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("node-%d", i)
+		client.children[workflowy.TopLevelParent] = append(client.children[workflowy.TopLevelParent], workflowy.Node{ID: id})
+		client.children[id] = []workflowy.Node{{ID: id + "-child"}}
+	}
+
+	nodes, err := listWorkflowyNodes(client, 500)
+	if err != nil {
+		t.Fatalf("Failed to list nodes: %v", err)
+	}
+	if client.calls != maxWorkflowyRequests {
+		t.Errorf("Expected %d requests, got %d", maxWorkflowyRequests, client.calls)
+	}
+	// The top level, then the children of the nodes listed by the other requests
+	if want := 200 + maxWorkflowyRequests - 1; len(nodes) != want {
+		t.Errorf("Expected %d nodes, got %d", want, len(nodes))
+	}
+}
+
+func TestSearchService_WorkflowyRefreshInBackground(t *testing.T) {
+	server, _ := newEmbeddingServer(t)
+
+	ctx := context.Background()
+	store := newTestStorage(t)
+	cfg := config.GetDefault()
+	cfg.OpenAIBaseURL = server.URL
+	cfg.OpenAIAPIKey = "sk-test"
+	cfg.OpenAIMaxRetries = 0
+	cfg.WorkflowyAPIKey = "wf-test"
+
+	client := &treeClient{
+		children: map[string][]workflowy.Node{workflowy.TopLevelParent: {{ID: "node", Name: "Plan the trip"}}},
+		release:  make(chan struct{}),
+	}
+	service := NewSearchService(ctx, &cfg, store, nil)
+	service.workflowy = workflowyConn{client: client, key: cfg.WorkflowyAPIKey, http: cfg.HTTP}
+
+	// The search does not wait for Workflowy
+	done := make(chan error, 1)
+	go func() {
+		_, err := service.SemanticSearch("trip", 0)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the search to finish while Workflowy is being fetched")
+	}
+
+	close(client.release)
+	service.workflowyRefresh.Wait()
+	embeddings, err := store.GetEmbeddings(ctx, models.SourceWorkflowy)
+	if err != nil {
+		t.Fatalf("Failed to get embeddings: %v", err)
+	}
+	if len(embeddings) != 1 || embeddings[0].Text != "Plan the trip" {
+		t.Errorf("Expected the Workflowy node indexed after the refresh, got %+v", embeddings)
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"talus_helper_windows/internal/clipboard"
+	"talus_helper_windows/internal/cliphistory"
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/sensitive"
+	"talus_helper_windows/internal/storage"
+)
+
+// ErrSensitiveData is returned for text holding sensitive data, and for
+// images marked as holding it, when the sensitive data action is "block"
+var ErrSensitiveData = errors.New("sensitive data found")
+
+// EventSensitiveData is emitted with a SensitiveReport payload whenever
+// sensitive data is found, whatever the action
+const EventSensitiveData = "sensitive:detected"
+
+// Sources of the text reported in a SensitiveReport
+const (
+	SensitiveSourceOCR       = "ocr"
+	SensitiveSourceClipboard = "clipboard"
+	SensitiveSourceChat      = "chat"
+	SensitiveSourceTasks     = "tasks"
+)
+
+// SensitiveReport tells the UI which sensitive data was found in OCR,
+// clipboard, chat or task text and what was done about it. The findings carry masked
+// previews, never the data itself.
+type SensitiveReport struct {
+	Source   string              `json:"source"`
+	Action   string              `json:"action"`
+	Findings []sensitive.Finding `json:"findings"`
+}
+
+// screenText looks for sensitive data in text according to settings and
+// returns the text to keep, redacted when the action is "redact", with the
+// kinds of data found. With the action "block" it returns ErrSensitiveData
+// instead. Findings are reported with EventSensitiveData when emit is set.
+func screenText(settings config.SensitiveConfig, emit EventEmitter, source, text string) (string, []string, error) {
+	if !settings.Enabled {
+		return text, nil, nil
+	}
+
+	findings := sensitive.Detect(text, sensitive.Options{
+		Kinds:           settings.Kinds,
+		MinEntropy:      settings.MinEntropy,
+		MinSecretLength: settings.MinSecretLength,
+	})
+	if len(findings) == 0 {
+		return text, nil, nil
+	}
+
+	action := settings.Action
+	if action != sensitive.ActionRedact && action != sensitive.ActionBlock {
+		action = sensitive.ActionWarn
+	}
+	if emit != nil {
+		emit(EventSensitiveData, SensitiveReport{Source: source, Action: action, Findings: findings})
+	}
+
+	kinds := sensitive.Kinds(findings)
+	switch action {
+	case sensitive.ActionBlock:
+		return "", kinds, fmt.Errorf("%w: %s", ErrSensitiveData, strings.Join(kinds, ", "))
+	case sensitive.ActionRedact:
+		return sensitive.Redact(text, findings), kinds, nil
+	default:
+		return text, kinds, nil
+	}
+}
+
+// screenImage returns ErrSensitiveData for an image whose clipboard history
+// entry is marked as holding sensitive data when the action is "block", so
+// that an image whose OCR text was blocked is not sent to a model again.
+// Images that are not in the history, or were never read, are not marked.
+func screenImage(ctx context.Context, settings config.SensitiveConfig, store storage.Storage, image []byte) error {
+	if !settings.Enabled || settings.Action != sensitive.ActionBlock || store == nil {
+		return nil
+	}
+
+	kinds, err := store.GetClipboardSensitiveKinds(ctx, cliphistory.Hash(clipboard.KindImage, image))
+	if err != nil {
+		return fmt.Errorf("failed to check image for sensitive data: %w", err)
+	}
+	if len(kinds) > 0 {
+		return fmt.Errorf("%w: %s", ErrSensitiveData, strings.Join(kinds, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/tasks"
+	"talus_helper_windows/internal/usage"
+)
+
+// TaskService extracts action items from text and adds the accepted ones as todos
+type TaskService struct {
+	ctx    context.Context
+	config *config.Config
+	todos  *TodoService
+	usage  *UsageService
+	emit   EventEmitter
+
+	// mu guards openaiClient, which concurrent calls reconfigure
+	mu           sync.Mutex
+	openaiClient *openai.Client
+}
+
+// NewTaskService creates a new TaskService.
+// usage may be nil, in which case LLM calls are not recorded or budgeted.
+// emit may be nil, in which case sensitive data findings are not reported.
+func NewTaskService(ctx context.Context, cfg *config.Config, todos *TodoService, usage *UsageService, emit EventEmitter) *TaskService {
+	return &TaskService{
+		ctx:    ctx,
+		config: cfg,
+		todos:  todos,
+		usage:  usage,
+		emit:   emit,
+	}
+}
+
+// ExtractTasks asks the chat model for the action items in text, such as
+// meeting notes or an OCR result. The tasks are proposals for the user to
+// review; nothing is added until AddTasks is called.
+// The text is screened for sensitive data before it is sent.
+func (s *TaskService) ExtractTasks(text string) ([]tasks.Task, error) {
+	text, _, err := screenText(s.config.Sensitive, s.emit, SensitiveSourceTasks, text)
+	if err != nil {
+		return nil, fmt.Errorf("text was not sent for task extraction: %w", err)
+	}
+
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	if s.usage != nil {
+		if err := s.usage.CheckBudget(); err != nil {
+			return nil, err
+		}
+	}
+
+	found, err := tasks.Extract(usage.WithFeature(s.ctx, usage.FeatureTasks), client, text, openai.ChatOptions{
+		Model: chatModel(s.config),
+		// The same notes should give the same tasks
+		Temperature: 0,
+		MaxTokens:   s.config.Chat.MaxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract tasks: %w", describeOpenAIError(err))
+	}
+	return found, nil
+}
+
+// AddTasks adds the accepted tasks as todos, in order, and returns them
+func (s *TaskService) AddTasks(accepted []tasks.Task) ([]models.Todo, error) {
+	todos := make([]models.Todo, 0, len(accepted))
+	for _, task := range accepted {
+		if task.Text == "" {
+			continue
+		}
+		todo, err := s.todos.AddTodo(task.TodoText())
+		if err != nil {
+			return todos, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
+
+// client returns the OpenAI client, recreating it when the settings changed
+func (s *TaskService) client() (*openai.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, err := configureClient(s.openaiClient, s.config, s.usage, time.Duration(s.config.Chat.TimeoutSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	s.openaiClient = client
+	return client, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/sensitive"
+)
+
+func TestTaskService_ExtractTasks_SensitiveData(t *testing.T) {
+	server := newChatServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		w.Write([]byte(completion(`{"tasks":[{"text":"Rotate the key"}]}`)))
+	})
+
+	cfg := config.GetDefault()
+	cfg.OpenAIBaseURL = server.URL
+	cfg.OpenAIAPIKey = "sk-test"
+	cfg.OpenAIMaxRetries = 0
+	cfg.Sensitive.Action = sensitive.ActionRedact
+
+	var reports []SensitiveReport
+	service := NewTaskService(context.Background(), &cfg, nil, nil, func(name string, data ...interface{}) {
+		if name == EventSensitiveData {
+			reports = append(reports, data[0].(SensitiveReport))
+		}
+	})
+
+	// Redacted text is what the model gets
+	found, err := service.ExtractTasks("Rotate the key sk-abcdefghijklmnopqrstuvwx today")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(found) != 1 || found[0].Text != "Rotate the key" {
+		t.Errorf("Expected the extracted task, got %+v", found)
+	}
+	got := messageTexts(server.request(0).Messages)
+	if want := "Rotate the key [REDACTED api-key] today"; got[len(got)-1] != want {
+		t.Errorf("Expected %q sent, got %q", want, got)
+	}
+	if len(reports) != 1 || reports[0].Source != SensitiveSourceTasks {
+		t.Errorf("Expected a tasks report, got %+v", reports)
+	}
+
+	// Blocked text is not sent
+	cfg.Sensitive.Action = sensitive.ActionBlock
+	if _, err := service.ExtractTasks("Call back, password: hunter22"); !errors.Is(err, ErrSensitiveData) {
+		t.Errorf("Expected ErrSensitiveData, got %v", err)
+	}
+	if n := server.count(); n != 1 {
+		t.Errorf("Expected no request for blocked text, got %d requests", n)
+	}
+}
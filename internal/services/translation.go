@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"talus_helper_windows/internal/ocr"
+	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/usage"
+)
+
+// languageNames maps the language codes offered in Settings to the names
+// used in translation prompts
+var languageNames = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"zh": "Chinese",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"it": "Italian",
+}
+
+// languageName returns the English name of a language code; other values,
+// such as a language name typed by the user, are returned unchanged
+func languageName(language string) string {
+	if name, ok := languageNames[language]; ok {
+		return name
+	}
+	return language
+}
+
+// translate translates text into language with the OpenAI-compatible endpoint.
+// Translations are cached like OCR results; forceRefresh skips the cache.
+// The text is screened for sensitive data before it is sent.
+func (s *ClipboardService) translate(text, language string, forceRefresh bool) (string, error) {
+	if language == "" {
+		return "", fmt.Errorf("no target language is configured. Please choose one in Settings")
+	}
+
+	// The OCR text was screened and reported already; screening it again
+	// keeps unchecked text from reaching the translation model
+	text, _, err := screenText(s.config.Sensitive, nil, SensitiveSourceOCR, text)
+	if err != nil {
+		return "", fmt.Errorf("text was not sent for translation: %w", err)
+	}
+
+	model := s.config.OCR.TranslationModel
+	if model == "" {
+		model = s.config.OCR.Model
+	}
+
+	cache := s.ocrCache()
+	cacheKey := ocr.CacheKey([]byte(text), ocr.ModeTranslate, language, model)
+	if cache != nil && !forceRefresh {
+		if translation, ok := cache.Get(cacheKey); ok {
+			return translation, nil
+		}
+	}
+
+	client, err := s.client()
+	if err != nil {
+		return "", fmt.Errorf("translation needs an OpenAI-compatible API: %w", err)
+	}
+	if s.usage != nil {
+		if err := s.usage.CheckBudget(); err != nil {
+			return "", err
+		}
+	}
+
+	ctx, done := s.beginRun(usage.FeatureTranslate)
+	defer done()
+
+	name := languageName(language)
+	prompt := fmt.Sprintf("Translate the user's text into %s. Keep line breaks, lists and formatting. "+
+		"If the text is already in %s, return it unchanged. Return only the translation.", name, name)
+	translation, err := client.Complete(ctx, []openai.Message{
+		openai.TextMessage("system", prompt),
+		openai.TextMessage("user", text),
+	}, openai.ChatOptions{
+		Model:       model,
+		Temperature: s.config.OCR.Temperature,
+		MaxTokens:   s.config.OCR.MaxTokens,
+	})
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return "", fmt.Errorf("translation was cancelled")
+		}
+		return "", fmt.Errorf("failed to translate text: %w", describeOpenAIError(err))
+	}
+
+	if cache != nil {
+		if err := cache.Put(cacheKey, translation); err != nil {
+			fmt.Printf("Failed to cache translation: %v\n", err)
+		}
+	}
+	return translation, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/storage"
+	"talus_helper_windows/internal/usage"
+
+	"github.com/google/uuid"
+)
+
+// ErrBudgetExceeded is returned for LLM calls once a budget is used up and
+// the budget action is "block"
+var ErrBudgetExceeded = errors.New("LLM budget exceeded")
+
+// EventUsageBudget is emitted with a BudgetWarning payload when a budget is
+// used up and the budget action is "warn"
+const EventUsageBudget = "usage:budget"
+
+// BudgetWarning reports a used-up budget
+type BudgetWarning struct {
+	Period string  `json:"period"`
+	Spent  float64 `json:"spent"`
+	Budget float64 `json:"budget"`
+}
+
+// UsageService records LLM calls and enforces the spending budgets
+type UsageService struct {
+	ctx     context.Context
+	config  *config.Config
+	storage storage.Storage
+	emit    EventEmitter
+}
+
+// NewUsageService creates a new UsageService
+func NewUsageService(ctx context.Context, cfg *config.Config, storage storage.Storage, emit EventEmitter) *UsageService {
+	return &UsageService{
+		ctx:     ctx,
+		config:  cfg,
+		storage: storage,
+		emit:    emit,
+	}
+}
+
+// Observe records a finished LLM call. It has the signature of openai.Client.Observe;
+// the feature is taken from ctx, see usage.WithFeature.
+func (s *UsageService) Observe(ctx context.Context, call openai.Call) {
+	record := models.UsageRecord{
+		ID:               uuid.New().String(),
+		Feature:          usage.FeatureFromContext(ctx),
+		Model:            call.Model,
+		PromptTokens:     call.Usage.PromptTokens,
+		CompletionTokens: call.Usage.CompletionTokens,
+		LatencyMs:        call.Latency.Milliseconds(),
+		CreatedAt:        time.Now(),
+	}
+	if price, ok := usage.PriceFor(s.config.Usage.Prices, call.Model); ok {
+		record.Cost = price.Cost(record.PromptTokens, record.CompletionTokens)
+	}
+	if call.Err != nil {
+		record.Error = call.Err.Error()
+	}
+
+	// Record with the service context; the call's own context may already be cancelled
+	if err := s.storage.CreateUsageRecord(s.ctx, &record); err != nil {
+		fmt.Printf("Failed to record LLM usage: %v\n", err)
+	}
+}
+
+// CheckBudget reports whether a paid LLM call may be made. When the daily or
+// monthly budget is used up it returns ErrBudgetExceeded if the budget action
+// is "block", or emits EventUsageBudget and returns nil if it is "warn".
+func (s *UsageService) CheckBudget() error {
+	daily, monthly := s.config.Usage.DailyBudget, s.config.Usage.MonthlyBudget
+	if daily <= 0 && monthly <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	records, err := s.storage.GetUsageRecords(s.ctx, usage.StartOfMonth(now))
+	if err != nil {
+		// Do not block work because the accounting database is unavailable
+		fmt.Printf("Failed to check LLM budget: %v\n", err)
+		return nil
+	}
+
+	if spent := usage.SumCost(records, usage.StartOfDay(now)); daily > 0 && spent >= daily {
+		return s.budgetExceeded(BudgetWarning{Period: "daily", Spent: spent, Budget: daily})
+	}
+	if spent := usage.SumCost(records, usage.StartOfMonth(now)); monthly > 0 && spent >= monthly {
+		return s.budgetExceeded(BudgetWarning{Period: "monthly", Spent: spent, Budget: monthly})
+	}
+	return nil
+}
+
+// budgetExceeded blocks or warns according to the budget action
+func (s *UsageService) budgetExceeded(warning BudgetWarning) error {
+	if s.config.Usage.BudgetAction == usage.ActionBlock {
+		return fmt.Errorf("%w: %s spend %.2f of %.2f", ErrBudgetExceeded, warning.Period, warning.Spent, warning.Budget)
+	}
+	if s.emit != nil {
+		s.emit(EventUsageBudget, warning)
+	}
+	return nil
+}
+
+// GetUsageReport summarizes LLM usage over the last days calendar days,
+// including today, with the current daily and monthly spend
+func (s *UsageService) GetUsageReport(days int) (usage.Report, error) {
+	if days <= 0 {
+		days = 30
+	}
+
+	now := time.Now()
+	since := usage.StartOfDay(now).AddDate(0, 0, -(days - 1))
+	from := since
+	if monthStart := usage.StartOfMonth(now); monthStart.Before(from) {
+		from = monthStart
+	}
+
+	records, err := s.storage.GetUsageRecords(s.ctx, from)
+	if err != nil {
+		return usage.Report{}, fmt.Errorf("failed to get usage records: %w", err)
+	}
+
+	var inPeriod []models.UsageRecord
+	for _, record := range records {
+		if !record.CreatedAt.Before(since) {
+			inPeriod = append(inPeriod, record)
+		}
+	}
+
+	report := usage.Summarize(inPeriod, since)
+	report.TodayCost = usage.SumCost(records, usage.StartOfDay(now))
+	report.MonthCost = usage.SumCost(records, usage.StartOfMonth(now))
+	report.DailyBudget = s.config.Usage.DailyBudget
+	report.MonthlyBudget = s.config.Usage.MonthlyBudget
+	return report, nil
+}
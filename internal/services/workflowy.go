@@ -0,0 +1,38 @@
+package services
+
+import (
+	"fmt"
+
+	"talus_helper_windows/internal/config"
+	"talus_helper_windows/internal/workflowy"
+)
+
+// workflowyConn caches the Workflowy client of the configured API key and network settings
+type workflowyConn struct {
+	client workflowy.WorkflowyClient
+	key    string
+	http   config.HTTPConfig
+}
+
+// get returns the Workflowy client, recreating it when the API key or the
+// network settings changed, or nil when no key is set or the network
+// settings are invalid
+func (w *workflowyConn) get(cfg *config.Config) workflowy.WorkflowyClient {
+	key := cfg.WorkflowyAPIKey
+	if key == "" {
+		return nil
+	}
+	if w.client == nil || w.key != key || w.http != cfg.HTTP {
+		httpClient, err := newHTTPClient(cfg, 0)
+		if err != nil {
+			fmt.Printf("Failed to create Workflowy client: %v\n", err)
+			return nil
+		}
+		clientConfig := workflowy.NewClientConfig(key)
+		clientConfig.HTTPClient = httpClient
+		w.client = workflowy.NewClient(clientConfig)
+		w.key = key
+		w.http = cfg.HTTP
+	}
+	return w.client
+}
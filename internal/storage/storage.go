@@ -3,9 +3,14 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/binary"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"talus_helper_windows/internal/config"
 	"talus_helper_windows/internal/models"
@@ -13,6 +18,10 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// SchemaVersion is the database schema created by Migrate.
+// It is stored in SQLite's user_version pragma.
+const SchemaVersion = 8
+
 // Storage interface defines methods for data persistence
 type Storage interface {
 	// Connection management
@@ -26,12 +35,51 @@ type Storage interface {
 	UpdateTodo(ctx context.Context, todo *models.Todo) error
 	DeleteTodo(ctx context.Context, id string) error
 
+	// LLM usage operations
+	CreateUsageRecord(ctx context.Context, record *models.UsageRecord) error
+	GetUsageRecords(ctx context.Context, since time.Time) ([]models.UsageRecord, error)
+
+	// OCR history operations
+	CreateOCRResult(ctx context.Context, result *models.OCRResult) error
+	GetOCRResults(ctx context.Context, limit int) ([]models.OCRResult, error)
+	DeleteOCRResult(ctx context.Context, id string) error
+
+	// Chat operations
+	GetConversations(ctx context.Context) ([]models.Conversation, error)
+	GetConversationByID(ctx context.Context, id string) (*models.Conversation, error)
+	CreateConversation(ctx context.Context, conversation *models.Conversation) error
+	UpdateConversation(ctx context.Context, conversation *models.Conversation) error
+	DeleteConversation(ctx context.Context, id string) error
+	GetChatMessages(ctx context.Context, conversationID string) ([]models.ChatMessage, error)
+	CreateChatMessage(ctx context.Context, message *models.ChatMessage) error
+	CreateToolInvocation(ctx context.Context, invocation *models.ToolInvocation) error
+	GetToolInvocations(ctx context.Context, conversationID string) ([]models.ToolInvocation, error)
+	GetEmbeddings(ctx context.Context, source string) ([]models.Embedding, error)
+	SaveEmbedding(ctx context.Context, embedding *models.Embedding) error
+	DeleteEmbedding(ctx context.Context, source, sourceID string) error
+
+	// Clipboard history operations
+	SaveClipboardEntry(ctx context.Context, entry *models.ClipboardEntry) error
+	GetClipboardEntries(ctx context.Context, query string, limit int) ([]models.ClipboardEntry, error)
+	GetClipboardEntry(ctx context.Context, id string) (*models.ClipboardEntry, error)
+	SetClipboardEntryPinned(ctx context.Context, id string, pinned bool) error
+	SetClipboardOCRText(ctx context.Context, hash, text string, sensitive []string) error
+	GetClipboardSensitiveKinds(ctx context.Context, hash string) ([]string, error)
+	DeleteClipboardEntry(ctx context.Context, id string) error
+	PruneClipboardHistory(ctx context.Context, before time.Time, keep int) (int64, error)
+	ExpireSensitiveClipboardEntries(ctx context.Context, before time.Time) (int64, error)
+
 	// Database management
 	Migrate(ctx context.Context) error
+	Snapshot(ctx context.Context, destPath string) error
+	Restore(ctx context.Context, srcPath string) error
 }
 
 // SQLiteStorage implements Storage interface using SQLite database
 type SQLiteStorage struct {
+	// mu is held for reading while the database is used and for writing
+	// while the connection is opened, closed or replaced by Restore
+	mu      sync.RWMutex
 	db      *sql.DB
 	dataDir string
 }
@@ -43,6 +91,13 @@ func NewSQLiteStorage() *SQLiteStorage {
 
 // Connect establishes connection to the SQLite database
 func (s *SQLiteStorage) Connect(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connect(ctx)
+}
+
+// connect opens the database; the caller holds mu for writing
+func (s *SQLiteStorage) connect(ctx context.Context) error {
 	var err error
 	s.dataDir, err = config.GetDataDir()
 	if err != nil {
@@ -54,8 +109,7 @@ func (s *SQLiteStorage) Connect(ctx context.Context) error {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	dbPath := filepath.Join(s.dataDir, "todos.db")
-	s.db, err = sql.Open("sqlite", dbPath)
+	s.db, err = sql.Open("sqlite", s.dbPath())
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -70,6 +124,13 @@ func (s *SQLiteStorage) Connect(ctx context.Context) error {
 
 // Close closes the database connection
 func (s *SQLiteStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.close()
+}
+
+// close closes the database; the caller holds mu for writing
+func (s *SQLiteStorage) close() error {
 	if s.db != nil {
 		return s.db.Close()
 	}
@@ -78,6 +139,13 @@ func (s *SQLiteStorage) Close() error {
 
 // Migrate creates the necessary database tables
 func (s *SQLiteStorage) Migrate(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.migrate(ctx)
+}
+
+// migrate creates the tables; the caller holds mu
+func (s *SQLiteStorage) migrate(ctx context.Context) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS todos (
 		id TEXT PRIMARY KEY,
@@ -89,14 +157,187 @@ func (s *SQLiteStorage) Migrate(ctx context.Context) error {
 
 	CREATE INDEX IF NOT EXISTS idx_todos_created_at ON todos(created_at);
 	CREATE INDEX IF NOT EXISTS idx_todos_completed ON todos(completed);
+
+	CREATE TABLE IF NOT EXISTS llm_usage (
+		id TEXT PRIMARY KEY,
+		feature TEXT NOT NULL,
+		model TEXT NOT NULL,
+		prompt_tokens INTEGER NOT NULL DEFAULT 0,
+		completion_tokens INTEGER NOT NULL DEFAULT 0,
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		cost REAL NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_llm_usage_created_at ON llm_usage(created_at);
+
+	CREATE TABLE IF NOT EXISTS ocr_history (
+		id TEXT PRIMARY KEY,
+		mode TEXT NOT NULL,
+		text TEXT NOT NULL,
+		translation TEXT NOT NULL DEFAULT '',
+		language TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_ocr_history_created_at ON ocr_history(created_at);
+
+	CREATE TABLE IF NOT EXISTS conversations (
+		id TEXT PRIMARY KEY,
+		title TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_conversations_updated_at ON conversations(updated_at);
+
+	CREATE TABLE IF NOT EXISTS chat_messages (
+		id TEXT PRIMARY KEY,
+		conversation_id TEXT NOT NULL,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		image BLOB,
+		image_format TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_chat_messages_conversation ON chat_messages(conversation_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS tool_invocations (
+		id TEXT PRIMARY KEY,
+		conversation_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		arguments TEXT NOT NULL,
+		result TEXT NOT NULL,
+		status TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tool_invocations_conversation ON tool_invocations(conversation_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS embeddings (
+		source TEXT NOT NULL,
+		source_id TEXT NOT NULL,
+		model TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		text TEXT NOT NULL,
+		vector BLOB NOT NULL,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (source, source_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS clipboard_history (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		text TEXT NOT NULL DEFAULT '',
+		ocr_text TEXT NOT NULL DEFAULT '',
+		image BLOB,
+		format TEXT NOT NULL DEFAULT '',
+		thumbnail BLOB,
+		hash TEXT NOT NULL UNIQUE,
+		size INTEGER NOT NULL DEFAULT 0,
+		pinned BOOLEAN NOT NULL DEFAULT 0,
+		sensitive TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		copied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_clipboard_history_copied_at ON clipboard_history(copied_at);
 	`
 
-	_, err := s.db.ExecContext(ctx, query)
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	// Columns added to tables created by earlier schema versions
+	if err := s.addColumn(ctx, "clipboard_history", "sensitive", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion))
 	return err
 }
 
+// addColumn adds a column to a table unless the table already has it
+func (s *SQLiteStorage) addColumn(ctx context.Context, table, column, definition string) error {
+	var count int
+	row := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column)
+	if err := row.Scan(&count); err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s to %s: %w", column, table, err)
+	}
+	return nil
+}
+
+// Snapshot writes a consistent copy of the database to destPath
+func (s *SQLiteStorage) Snapshot(ctx context.Context, destPath string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", destPath); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the database with the one at srcPath and migrates it
+// to the current schema. Calls made meanwhile wait for the new database.
+func (s *SQLiteStorage) Restore(ctx context.Context, srcPath string) error {
+	// Validate the source before touching the live database
+	src, err := sql.Open("sqlite", srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database to restore: %w", err)
+	}
+	var version int
+	err = src.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	src.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read database to restore: %w", err)
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, SchemaVersion)
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read database to restore: %w", err)
+	}
+
+	// Other users of the storage wait until the new database is connected
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+
+	tmpPath := s.dbPath() + ".restore"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write restored database: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.dbPath()); err != nil {
+		return fmt.Errorf("failed to replace database: %w", err)
+	}
+
+	if err := s.connect(ctx); err != nil {
+		return err
+	}
+	return s.migrate(ctx)
+}
+
+// dbPath returns the path of the SQLite database file
+func (s *SQLiteStorage) dbPath() string {
+	return filepath.Join(s.dataDir, "todos.db")
+}
+
 // GetTodos retrieves all todos from the database
 func (s *SQLiteStorage) GetTodos(ctx context.Context) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	query := `SELECT id, text, completed, created_at FROM todos ORDER BY created_at DESC`
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
@@ -123,6 +364,8 @@ func (s *SQLiteStorage) GetTodos(ctx context.Context) ([]models.Todo, error) {
 
 // GetTodoByID retrieves a specific todo by ID
 func (s *SQLiteStorage) GetTodoByID(ctx context.Context, id string) (*models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	query := `SELECT id, text, completed, created_at FROM todos WHERE id = ?`
 	row := s.db.QueryRowContext(ctx, query, id)
 
@@ -140,6 +383,8 @@ func (s *SQLiteStorage) GetTodoByID(ctx context.Context, id string) (*models.Tod
 
 // CreateTodo creates a new todo in the database
 func (s *SQLiteStorage) CreateTodo(ctx context.Context, todo *models.Todo) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	query := `INSERT INTO todos (id, text, completed, created_at) VALUES (?, ?, ?, ?)`
 	_, err := s.db.ExecContext(ctx, query, todo.ID, todo.Text, todo.Completed, todo.CreatedAt)
 	if err != nil {
@@ -150,6 +395,8 @@ func (s *SQLiteStorage) CreateTodo(ctx context.Context, todo *models.Todo) error
 
 // UpdateTodo updates an existing todo in the database
 func (s *SQLiteStorage) UpdateTodo(ctx context.Context, todo *models.Todo) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	query := `UPDATE todos SET text = ?, completed = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
 	result, err := s.db.ExecContext(ctx, query, todo.Text, todo.Completed, todo.ID)
 	if err != nil {
@@ -170,6 +417,8 @@ func (s *SQLiteStorage) UpdateTodo(ctx context.Context, todo *models.Todo) error
 
 // DeleteTodo deletes a todo from the database
 func (s *SQLiteStorage) DeleteTodo(ctx context.Context, id string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	query := `DELETE FROM todos WHERE id = ?`
 	result, err := s.db.ExecContext(ctx, query, id)
 	if err != nil {
@@ -187,3 +436,606 @@ func (s *SQLiteStorage) DeleteTodo(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// CreateUsageRecord stores a record of an LLM call
+func (s *SQLiteStorage) CreateUsageRecord(ctx context.Context, record *models.UsageRecord) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `INSERT INTO llm_usage (id, feature, model, prompt_tokens, completion_tokens, latency_ms, cost, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, query, record.ID, record.Feature, record.Model, record.PromptTokens,
+		record.CompletionTokens, record.LatencyMs, record.Cost, record.Error, record.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create usage record: %w", err)
+	}
+	return nil
+}
+
+// GetUsageRecords retrieves the LLM calls made at or after since, oldest first
+func (s *SQLiteStorage) GetUsageRecords(ctx context.Context, since time.Time) ([]models.UsageRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `SELECT id, feature, model, prompt_tokens, completion_tokens, latency_ms, cost, error, created_at
+		FROM llm_usage WHERE created_at >= ? ORDER BY created_at`
+	rows, err := s.db.QueryContext(ctx, query, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []models.UsageRecord
+	for rows.Next() {
+		var record models.UsageRecord
+		err := rows.Scan(&record.ID, &record.Feature, &record.Model, &record.PromptTokens,
+			&record.CompletionTokens, &record.LatencyMs, &record.Cost, &record.Error, &record.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan usage record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return records, nil
+}
+
+// CreateOCRResult stores an OCR result in the history
+func (s *SQLiteStorage) CreateOCRResult(ctx context.Context, result *models.OCRResult) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `INSERT INTO ocr_history (id, mode, text, translation, language, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, query, result.ID, result.Mode, result.Text, result.Translation,
+		result.Language, result.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create OCR result: %w", err)
+	}
+	return nil
+}
+
+// GetOCRResults retrieves up to limit OCR results, newest first
+func (s *SQLiteStorage) GetOCRResults(ctx context.Context, limit int) ([]models.OCRResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `SELECT id, mode, text, translation, language, created_at
+		FROM ocr_history ORDER BY created_at DESC LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OCR results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.OCRResult
+	for rows.Next() {
+		var result models.OCRResult
+		err := rows.Scan(&result.ID, &result.Mode, &result.Text, &result.Translation, &result.Language, &result.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan OCR result: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// DeleteOCRResult removes an OCR result from the history
+func (s *SQLiteStorage) DeleteOCRResult(ctx context.Context, id string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `DELETE FROM ocr_history WHERE id = ?`
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete OCR result: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("OCR result with id %s not found", id)
+	}
+	return nil
+}
+
+// GetConversations retrieves all conversations, most recently updated first
+func (s *SQLiteStorage) GetConversations(ctx context.Context) ([]models.Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `SELECT id, title, created_at, updated_at FROM conversations ORDER BY updated_at DESC`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var conversations []models.Conversation
+	for rows.Next() {
+		var conversation models.Conversation
+		err := rows.Scan(&conversation.ID, &conversation.Title, &conversation.CreatedAt, &conversation.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		conversations = append(conversations, conversation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return conversations, nil
+}
+
+// GetConversationByID retrieves a specific conversation by ID
+func (s *SQLiteStorage) GetConversationByID(ctx context.Context, id string) (*models.Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `SELECT id, title, created_at, updated_at FROM conversations WHERE id = ?`
+	row := s.db.QueryRowContext(ctx, query, id)
+
+	var conversation models.Conversation
+	err := row.Scan(&conversation.ID, &conversation.Title, &conversation.CreatedAt, &conversation.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("conversation with id %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to scan conversation: %w", err)
+	}
+
+	return &conversation, nil
+}
+
+// CreateConversation creates a new conversation
+func (s *SQLiteStorage) CreateConversation(ctx context.Context, conversation *models.Conversation) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `INSERT INTO conversations (id, title, created_at, updated_at) VALUES (?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, query, conversation.ID, conversation.Title,
+		conversation.CreatedAt.UTC(), conversation.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create conversation: %w", err)
+	}
+	return nil
+}
+
+// UpdateConversation updates the title and update time of a conversation
+func (s *SQLiteStorage) UpdateConversation(ctx context.Context, conversation *models.Conversation) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `UPDATE conversations SET title = ?, updated_at = ? WHERE id = ?`
+	result, err := s.db.ExecContext(ctx, query, conversation.Title, conversation.UpdatedAt.UTC(), conversation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("conversation with id %s not found", conversation.ID)
+	}
+	return nil
+}
+
+// DeleteConversation deletes a conversation together with its messages and tool invocations
+func (s *SQLiteStorage) DeleteConversation(ctx context.Context, id string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM chat_messages WHERE conversation_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete chat messages: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tool_invocations WHERE conversation_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete tool invocations: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM conversations WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("conversation with id %s not found", id)
+	}
+	return tx.Commit()
+}
+
+// GetChatMessages retrieves the messages of a conversation, oldest first
+func (s *SQLiteStorage) GetChatMessages(ctx context.Context, conversationID string) ([]models.ChatMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `SELECT id, conversation_id, role, content, image, image_format, created_at
+		FROM chat_messages WHERE conversation_id = ? ORDER BY created_at, rowid`
+	rows, err := s.db.QueryContext(ctx, query, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []models.ChatMessage
+	for rows.Next() {
+		var message models.ChatMessage
+		err := rows.Scan(&message.ID, &message.ConversationID, &message.Role, &message.Content,
+			&message.Image, &message.ImageFormat, &message.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chat message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return messages, nil
+}
+
+// CreateChatMessage stores a message of a conversation
+func (s *SQLiteStorage) CreateChatMessage(ctx context.Context, message *models.ChatMessage) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `INSERT INTO chat_messages (id, conversation_id, role, content, image, image_format, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, query, message.ID, message.ConversationID, message.Role, message.Content,
+		message.Image, message.ImageFormat, message.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create chat message: %w", err)
+	}
+	return nil
+}
+
+// CreateToolInvocation records a tool called in a conversation
+func (s *SQLiteStorage) CreateToolInvocation(ctx context.Context, invocation *models.ToolInvocation) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `INSERT INTO tool_invocations (id, conversation_id, name, arguments, result, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, query, invocation.ID, invocation.ConversationID, invocation.Name,
+		invocation.Arguments, invocation.Result, invocation.Status, invocation.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create tool invocation: %w", err)
+	}
+	return nil
+}
+
+// GetToolInvocations retrieves the tools called in a conversation, oldest first
+func (s *SQLiteStorage) GetToolInvocations(ctx context.Context, conversationID string) ([]models.ToolInvocation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `SELECT id, conversation_id, name, arguments, result, status, created_at
+		FROM tool_invocations WHERE conversation_id = ? ORDER BY created_at, rowid`
+	rows, err := s.db.QueryContext(ctx, query, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tool invocations: %w", err)
+	}
+	defer rows.Close()
+
+	var invocations []models.ToolInvocation
+	for rows.Next() {
+		var invocation models.ToolInvocation
+		err := rows.Scan(&invocation.ID, &invocation.ConversationID, &invocation.Name, &invocation.Arguments,
+			&invocation.Result, &invocation.Status, &invocation.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tool invocation: %w", err)
+		}
+		invocations = append(invocations, invocation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return invocations, nil
+}
+
+// GetEmbeddings retrieves the stored vectors of a source, or of all sources if source is empty
+func (s *SQLiteStorage) GetEmbeddings(ctx context.Context, source string) ([]models.Embedding, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `SELECT source, source_id, model, hash, text, vector, updated_at
+		FROM embeddings WHERE ? = '' OR source = ? ORDER BY source, source_id`
+	rows, err := s.db.QueryContext(ctx, query, source, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	var embeddings []models.Embedding
+	for rows.Next() {
+		var embedding models.Embedding
+		var vector []byte
+		err := rows.Scan(&embedding.Source, &embedding.SourceID, &embedding.Model, &embedding.Hash,
+			&embedding.Text, &vector, &embedding.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan embedding: %w", err)
+		}
+		if embedding.Vector, err = decodeVector(vector); err != nil {
+			return nil, fmt.Errorf("invalid embedding for %s %s: %w", embedding.Source, embedding.SourceID, err)
+		}
+		embeddings = append(embeddings, embedding)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return embeddings, nil
+}
+
+// SaveEmbedding stores the vector of a document, replacing an earlier one
+func (s *SQLiteStorage) SaveEmbedding(ctx context.Context, embedding *models.Embedding) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `INSERT INTO embeddings (source, source_id, model, hash, text, vector, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source, source_id) DO UPDATE SET
+			model = excluded.model, hash = excluded.hash, text = excluded.text,
+			vector = excluded.vector, updated_at = excluded.updated_at`
+	_, err := s.db.ExecContext(ctx, query, embedding.Source, embedding.SourceID, embedding.Model, embedding.Hash,
+		embedding.Text, encodeVector(embedding.Vector), embedding.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save embedding: %w", err)
+	}
+	return nil
+}
+
+// DeleteEmbedding removes the vector of a document; a missing vector is not an error
+func (s *SQLiteStorage) DeleteEmbedding(ctx context.Context, source, sourceID string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `DELETE FROM embeddings WHERE source = ? AND source_id = ?`
+	if _, err := s.db.ExecContext(ctx, query, source, sourceID); err != nil {
+		return fmt.Errorf("failed to delete embedding: %w", err)
+	}
+	return nil
+}
+
+// encodeVector encodes a vector as little-endian float32 values
+func encodeVector(vector []float32) []byte {
+	data := make([]byte, 4*len(vector))
+	for i, value := range vector {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(value))
+	}
+	return data
+}
+
+// decodeVector decodes a vector encoded by encodeVector
+func decodeVector(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("vector length %d is not a multiple of 4", len(data))
+	}
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vector, nil
+}
+
+// clipboardColumns are the columns of a clipboard entry listed by
+// GetClipboardEntries; the full image is left out
+const clipboardColumns = `id, kind, text, ocr_text, format, thumbnail, hash, size, pinned, sensitive, created_at, copied_at`
+
+// SaveClipboardEntry stores an entry in the clipboard history. If an entry
+// with the same hash exists, only its copy time is updated and entry is
+// filled with the stored ID, creation time, pin, OCR text and sensitive
+// data kinds.
+func (s *SQLiteStorage) SaveClipboardEntry(ctx context.Context, entry *models.ClipboardEntry) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `INSERT INTO clipboard_history (id, kind, text, ocr_text, image, format, thumbnail, hash, size, pinned, sensitive, created_at, copied_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (hash) DO UPDATE SET copied_at = excluded.copied_at`
+	_, err := s.db.ExecContext(ctx, query, entry.ID, entry.Kind, entry.Text, entry.OCRText, entry.Image, entry.Format,
+		entry.Thumbnail, entry.Hash, entry.Size, entry.Pinned, strings.Join(entry.Sensitive, ","),
+		entry.CreatedAt.UTC(), entry.CopiedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save clipboard entry: %w", err)
+	}
+
+	var sensitive string
+	row := s.db.QueryRowContext(ctx, `SELECT id, ocr_text, pinned, sensitive, created_at FROM clipboard_history WHERE hash = ?`, entry.Hash)
+	if err := row.Scan(&entry.ID, &entry.OCRText, &entry.Pinned, &sensitive, &entry.CreatedAt); err != nil {
+		return fmt.Errorf("failed to scan clipboard entry: %w", err)
+	}
+	entry.Sensitive = splitKinds(sensitive)
+	return nil
+}
+
+// GetClipboardEntries retrieves up to limit clipboard entries whose text or
+// OCR text contains query, pinned entries first and then the most recently
+// copied. An empty query matches every entry. Images are not loaded.
+func (s *SQLiteStorage) GetClipboardEntries(ctx context.Context, query string, limit int) ([]models.ClipboardEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	pattern := "%" + escapeLike(query) + "%"
+	rows, err := s.db.QueryContext(ctx, `SELECT `+clipboardColumns+` FROM clipboard_history
+		WHERE text LIKE ? ESCAPE '\' OR ocr_text LIKE ? ESCAPE '\'
+		ORDER BY pinned DESC, copied_at DESC LIMIT ?`, pattern, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clipboard history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.ClipboardEntry
+	for rows.Next() {
+		var entry models.ClipboardEntry
+		var sensitive string
+		err := rows.Scan(&entry.ID, &entry.Kind, &entry.Text, &entry.OCRText, &entry.Format, &entry.Thumbnail,
+			&entry.Hash, &entry.Size, &entry.Pinned, &sensitive, &entry.CreatedAt, &entry.CopiedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan clipboard entry: %w", err)
+		}
+		entry.Sensitive = splitKinds(sensitive)
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetClipboardEntry retrieves a clipboard entry by ID, including its image
+func (s *SQLiteStorage) GetClipboardEntry(ctx context.Context, id string) (*models.ClipboardEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	row := s.db.QueryRowContext(ctx, `SELECT `+clipboardColumns+`, image FROM clipboard_history WHERE id = ?`, id)
+
+	var entry models.ClipboardEntry
+	var sensitive string
+	err := row.Scan(&entry.ID, &entry.Kind, &entry.Text, &entry.OCRText, &entry.Format, &entry.Thumbnail,
+		&entry.Hash, &entry.Size, &entry.Pinned, &sensitive, &entry.CreatedAt, &entry.CopiedAt, &entry.Image)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("clipboard entry with id %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to scan clipboard entry: %w", err)
+	}
+	entry.Sensitive = splitKinds(sensitive)
+
+	return &entry, nil
+}
+
+// SetClipboardEntryPinned pins or unpins a clipboard entry; pinned entries
+// are never pruned
+func (s *SQLiteStorage) SetClipboardEntryPinned(ctx context.Context, id string, pinned bool) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result, err := s.db.ExecContext(ctx, `UPDATE clipboard_history SET pinned = ? WHERE id = ?`, pinned, id)
+	if err != nil {
+		return fmt.Errorf("failed to pin clipboard entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("clipboard entry with id %s not found", id)
+	}
+	return nil
+}
+
+// SetClipboardOCRText stores the text read from the image with the given
+// hash, and the kinds of sensitive data found in it; kinds found earlier
+// are kept when sensitive is empty. An image that is not in the history is
+// not an error.
+func (s *SQLiteStorage) SetClipboardOCRText(ctx context.Context, hash, text string, sensitive []string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query := `UPDATE clipboard_history SET ocr_text = ?, sensitive = CASE WHEN ? = '' THEN sensitive ELSE ? END WHERE hash = ?`
+	kinds := strings.Join(sensitive, ",")
+	if _, err := s.db.ExecContext(ctx, query, text, kinds, kinds, hash); err != nil {
+		return fmt.Errorf("failed to store clipboard OCR text: %w", err)
+	}
+	return nil
+}
+
+// GetClipboardSensitiveKinds returns the kinds of sensitive data the entry
+// with the given hash is marked with. An entry that is not in the history
+// has none.
+func (s *SQLiteStorage) GetClipboardSensitiveKinds(ctx context.Context, hash string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var sensitive string
+	err := s.db.QueryRowContext(ctx, `SELECT sensitive FROM clipboard_history WHERE hash = ?`, hash).Scan(&sensitive)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clipboard entry: %w", err)
+	}
+	return splitKinds(sensitive), nil
+}
+
+// DeleteClipboardEntry removes an entry from the clipboard history
+func (s *SQLiteStorage) DeleteClipboardEntry(ctx context.Context, id string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result, err := s.db.ExecContext(ctx, `DELETE FROM clipboard_history WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete clipboard entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("clipboard entry with id %s not found", id)
+	}
+	return nil
+}
+
+// PruneClipboardHistory removes unpinned entries last copied before before,
+// unless before is zero, and all but the keep most recently copied unpinned
+// entries, unless keep is 0. It returns the number of entries removed.
+func (s *SQLiteStorage) PruneClipboardHistory(ctx context.Context, before time.Time, keep int) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var removed int64
+	if !before.IsZero() {
+		result, err := s.db.ExecContext(ctx, `DELETE FROM clipboard_history WHERE NOT pinned AND copied_at < ?`, before.UTC())
+		if err != nil {
+			return 0, fmt.Errorf("failed to prune clipboard history: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		removed += n
+	}
+	if keep > 0 {
+		result, err := s.db.ExecContext(ctx, `DELETE FROM clipboard_history WHERE id IN (
+			SELECT id FROM clipboard_history WHERE NOT pinned ORDER BY copied_at DESC LIMIT -1 OFFSET ?)`, keep)
+		if err != nil {
+			return removed, fmt.Errorf("failed to prune clipboard history: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		removed += n
+	}
+	return removed, nil
+}
+
+// ExpireSensitiveClipboardEntries removes unpinned entries holding
+// sensitive data that were last copied before before. It returns the
+// number of entries removed.
+func (s *SQLiteStorage) ExpireSensitiveClipboardEntries(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result, err := s.db.ExecContext(ctx, `DELETE FROM clipboard_history WHERE NOT pinned AND sensitive != '' AND copied_at < ?`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire sensitive clipboard entries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// splitKinds splits the comma-separated kinds of sensitive data stored with an entry
+func splitKinds(kinds string) []string {
+	if kinds == "" {
+		return nil
+	}
+	return strings.Split(kinds, ",")
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern with a backslash
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
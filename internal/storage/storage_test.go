@@ -0,0 +1,454 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"talus_helper_windows/internal/models"
+)
+
+// newTestStorage connects a migrated SQLiteStorage in a temporary home directory
+func newTestStorage(t *testing.T) *SQLiteStorage {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	s := NewSQLiteStorage()
+	ctx := context.Background()
+	if err := s.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return s
+}
+
+func TestSQLiteStorage_SnapshotAndRestore(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	todo := models.Todo{ID: "1", Text: "keep me", CreatedAt: time.Now()}
+	if err := s.CreateTodo(ctx, &todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	snapshot := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := s.Snapshot(ctx, snapshot); err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+
+	if err := s.DeleteTodo(ctx, "1"); err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+
+	if err := s.Restore(ctx, snapshot); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+
+	todos, err := s.GetTodos(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get todos: %v", err)
+	}
+	if len(todos) != 1 || todos[0].Text != "keep me" {
+		t.Errorf("Expected restored todo, got %+v", todos)
+	}
+}
+
+func TestSQLiteStorage_RestoreWhileInUse(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	todo := models.Todo{ID: "1", Text: "keep me", CreatedAt: time.Now()}
+	if err := s.CreateTodo(ctx, &todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	snapshot := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := s.Snapshot(ctx, snapshot); err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+
+	// Run with -race: readers must neither see a closed database nor race on it
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if todos, err := s.GetTodos(ctx); err != nil || len(todos) != 1 {
+					t.Errorf("Expected the todo during a restore, got %v and %v", todos, err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		if err := s.Restore(ctx, snapshot); err != nil {
+			t.Errorf("Failed to restore: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestSQLiteStorage_UsageRecords(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	records := []models.UsageRecord{
+		{ID: "old", Feature: "ocr", Model: "m", Cost: 1, CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "new", Feature: "ocr", Model: "m", PromptTokens: 100, CompletionTokens: 5, LatencyMs: 250, Cost: 0.5, CreatedAt: now.Add(-time.Minute)},
+		{ID: "failed", Feature: "ocr", Model: "m", Error: "API error: bad model", CreatedAt: now},
+	}
+	for i := range records {
+		if err := s.CreateUsageRecord(ctx, &records[i]); err != nil {
+			t.Fatalf("Failed to create usage record: %v", err)
+		}
+	}
+
+	got, err := s.GetUsageRecords(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get usage records: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "new" || got[1].ID != "failed" {
+		t.Fatalf("Expected the two recent records oldest first, got %+v", got)
+	}
+	if got[0].PromptTokens != 100 || got[0].LatencyMs != 250 || got[0].Cost != 0.5 {
+		t.Errorf("Unexpected record: %+v", got[0])
+	}
+	if got[1].Error != "API error: bad model" {
+		t.Errorf("Expected error to round-trip, got %q", got[1].Error)
+	}
+	if !got[0].CreatedAt.Equal(records[1].CreatedAt) {
+		t.Errorf("Expected created_at %v, got %v", records[1].CreatedAt, got[0].CreatedAt)
+	}
+}
+
+func TestSQLiteStorage_OCRResults(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	results := []models.OCRResult{
+		{ID: "first", Mode: "text", Text: "Hello", CreatedAt: now.Add(-time.Hour)},
+		{ID: "second", Mode: "translate", Text: "Bonjour", Translation: "Hello", Language: "en", CreatedAt: now},
+	}
+	for i := range results {
+		if err := s.CreateOCRResult(ctx, &results[i]); err != nil {
+			t.Fatalf("Failed to create OCR result: %v", err)
+		}
+	}
+
+	got, err := s.GetOCRResults(ctx, 10)
+	if err != nil {
+		t.Fatalf("Failed to get OCR results: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "second" || got[0].Translation != "Hello" || got[0].Language != "en" {
+		t.Fatalf("Expected newest first with translation, got %+v", got)
+	}
+
+	if limited, _ := s.GetOCRResults(ctx, 1); len(limited) != 1 {
+		t.Errorf("Expected limit to apply, got %d results", len(limited))
+	}
+
+	if err := s.DeleteOCRResult(ctx, "first"); err != nil {
+		t.Fatalf("Failed to delete OCR result: %v", err)
+	}
+	if err := s.DeleteOCRResult(ctx, "first"); err == nil {
+		t.Error("Expected error deleting a missing result")
+	}
+}
+
+func TestSQLiteStorage_Conversations(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	older := models.Conversation{ID: "older", Title: "Older", CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour)}
+	newer := models.Conversation{ID: "newer", CreatedAt: now, UpdatedAt: now}
+	for _, conversation := range []*models.Conversation{&older, &newer} {
+		if err := s.CreateConversation(ctx, conversation); err != nil {
+			t.Fatalf("Failed to create conversation: %v", err)
+		}
+	}
+
+	messages := []models.ChatMessage{
+		{ID: "m1", ConversationID: "older", Role: "user", Content: "What is this?", Image: []byte{0x89, 'P', 'N', 'G'}, ImageFormat: "png", CreatedAt: now},
+		{ID: "m2", ConversationID: "older", Role: "assistant", Content: "A logo.", CreatedAt: now},
+	}
+	for i := range messages {
+		if err := s.CreateChatMessage(ctx, &messages[i]); err != nil {
+			t.Fatalf("Failed to create chat message: %v", err)
+		}
+	}
+
+	// Continuing the older conversation moves it to the top
+	older.Title = "Logo"
+	older.UpdatedAt = now.Add(time.Minute)
+	if err := s.UpdateConversation(ctx, &older); err != nil {
+		t.Fatalf("Failed to update conversation: %v", err)
+	}
+	conversations, err := s.GetConversations(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get conversations: %v", err)
+	}
+	if len(conversations) != 2 || conversations[0].ID != "older" || conversations[0].Title != "Logo" {
+		t.Fatalf("Expected most recently updated first, got %+v", conversations)
+	}
+
+	got, err := s.GetChatMessages(ctx, "older")
+	if err != nil {
+		t.Fatalf("Failed to get chat messages: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "m1" || got[1].ID != "m2" {
+		t.Fatalf("Expected messages in order, got %+v", got)
+	}
+	if string(got[0].Image) != "\x89PNG" || got[0].ImageFormat != "png" || got[1].Image != nil {
+		t.Errorf("Expected image kept on the first message only, got %+v", got)
+	}
+
+	invocation := models.ToolInvocation{ID: "t1", ConversationID: "older", Name: "delete_todo",
+		Arguments: `{"id":"1"}`, Result: "declined", Status: models.ToolStatusDeclined, CreatedAt: now}
+	if err := s.CreateToolInvocation(ctx, &invocation); err != nil {
+		t.Fatalf("Failed to create tool invocation: %v", err)
+	}
+	if invocations, _ := s.GetToolInvocations(ctx, "older"); len(invocations) != 1 || invocations[0].Status != models.ToolStatusDeclined {
+		t.Errorf("Expected the tool invocation, got %+v", invocations)
+	}
+
+	if err := s.DeleteConversation(ctx, "older"); err != nil {
+		t.Fatalf("Failed to delete conversation: %v", err)
+	}
+	if invocations, _ := s.GetToolInvocations(ctx, "older"); len(invocations) != 0 {
+		t.Errorf("Expected tool invocations deleted with the conversation, got %+v", invocations)
+	}
+	if _, err := s.GetConversationByID(ctx, "older"); err == nil {
+		t.Error("Expected deleted conversation to be gone")
+	}
+	if left, _ := s.GetChatMessages(ctx, "older"); len(left) != 0 {
+		t.Errorf("Expected messages deleted with the conversation, got %+v", left)
+	}
+	if err := s.DeleteConversation(ctx, "older"); err == nil {
+		t.Error("Expected error deleting a missing conversation")
+	}
+}
+
+func TestSQLiteStorage_Embeddings(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	embeddings := []models.Embedding{
+		{Source: models.SourceTodo, SourceID: "1", Model: "m", Hash: "h1", Text: "Pay the bill", Vector: []float32{0.5, -1, 3.25}, UpdatedAt: time.Now()},
+		{Source: models.SourceOCR, SourceID: "2", Model: "m", Hash: "h2", Text: "Invoice", Vector: []float32{1, 0}, UpdatedAt: time.Now()},
+	}
+	for i := range embeddings {
+		if err := s.SaveEmbedding(ctx, &embeddings[i]); err != nil {
+			t.Fatalf("Failed to save embedding: %v", err)
+		}
+	}
+
+	// Saving again replaces the vector
+	embeddings[0].Hash, embeddings[0].Vector = "h3", []float32{2, 2}
+	if err := s.SaveEmbedding(ctx, &embeddings[0]); err != nil {
+		t.Fatalf("Failed to replace embedding: %v", err)
+	}
+
+	todos, err := s.GetEmbeddings(ctx, models.SourceTodo)
+	if err != nil {
+		t.Fatalf("Failed to get embeddings: %v", err)
+	}
+	if len(todos) != 1 || todos[0].Hash != "h3" || len(todos[0].Vector) != 2 || todos[0].Vector[1] != 2 {
+		t.Errorf("Expected the replaced todo vector, got %+v", todos)
+	}
+
+	all, _ := s.GetEmbeddings(ctx, "")
+	if len(all) != 2 {
+		t.Errorf("Expected 2 embeddings, got %d", len(all))
+	}
+
+	if err := s.DeleteEmbedding(ctx, models.SourceOCR, "2"); err != nil {
+		t.Fatalf("Failed to delete embedding: %v", err)
+	}
+	if all, _ := s.GetEmbeddings(ctx, ""); len(all) != 1 {
+		t.Errorf("Expected 1 embedding after delete, got %d", len(all))
+	}
+}
+
+func TestSQLiteStorage_ClipboardHistory(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []models.ClipboardEntry{
+		{ID: "a", Kind: "text", Text: "100% done", Hash: "ha", CreatedAt: start, CopiedAt: start},
+		{ID: "b", Kind: "image", Image: []byte("\x89PNG"), Format: "png", Thumbnail: []byte("thumb"), Hash: "hb",
+			CreatedAt: start.Add(time.Minute), CopiedAt: start.Add(time.Minute)},
+		{ID: "c", Kind: "text", Text: "meeting notes", Hash: "hc", CreatedAt: start.Add(2 * time.Minute), CopiedAt: start.Add(2 * time.Minute)},
+	}
+	for i := range entries {
+		if err := s.SaveClipboardEntry(ctx, &entries[i]); err != nil {
+			t.Fatalf("Failed to save clipboard entry: %v", err)
+		}
+	}
+
+	// Copying the same content again moves the existing entry to the top
+	again := models.ClipboardEntry{ID: "a2", Kind: "text", Text: "100% done", Hash: "ha",
+		CreatedAt: start.Add(time.Hour), CopiedAt: start.Add(time.Hour)}
+	if err := s.SaveClipboardEntry(ctx, &again); err != nil {
+		t.Fatalf("Failed to save duplicate clipboard entry: %v", err)
+	}
+	if again.ID != "a" || !again.CreatedAt.Equal(start) {
+		t.Errorf("Expected the duplicate merged into entry a, got %+v", again)
+	}
+
+	if err := s.SetClipboardOCRText(ctx, "hb", "Invoice 42", nil); err != nil {
+		t.Fatalf("Failed to set OCR text: %v", err)
+	}
+	if err := s.SetClipboardEntryPinned(ctx, "c", true); err != nil {
+		t.Fatalf("Failed to pin clipboard entry: %v", err)
+	}
+
+	all, err := s.GetClipboardEntries(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("Failed to get clipboard entries: %v", err)
+	}
+	if len(all) != 3 || all[0].ID != "c" || all[1].ID != "a" || all[2].ID != "b" {
+		t.Fatalf("Expected pinned first, then most recently copied, got %+v", all)
+	}
+	if all[2].Image != nil || string(all[2].Thumbnail) != "thumb" {
+		t.Errorf("Expected the thumbnail without the image in lists, got %+v", all[2])
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"invoice", []string{"b"}},
+		{"100%", []string{"a"}},
+		{"_", nil},
+		{"NOTES", []string{"c"}},
+	}
+	for _, tt := range tests {
+		found, err := s.GetClipboardEntries(ctx, tt.query, 10)
+		if err != nil {
+			t.Fatalf("Failed to search clipboard entries: %v", err)
+		}
+		var ids []string
+		for _, entry := range found {
+			ids = append(ids, entry.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+			t.Errorf("Search %q: expected %v, got %v", tt.query, tt.want, ids)
+		}
+	}
+
+	image, err := s.GetClipboardEntry(ctx, "b")
+	if err != nil {
+		t.Fatalf("Failed to get clipboard entry: %v", err)
+	}
+	if string(image.Image) != "\x89PNG" || image.OCRText != "Invoice 42" {
+		t.Errorf("Expected the full image with its OCR text, got %+v", image)
+	}
+
+	// b is older than the cutoff; of the unpinned entries left only a is kept
+	removed, err := s.PruneClipboardHistory(ctx, start.Add(30*time.Second), 1)
+	if err != nil {
+		t.Fatalf("Failed to prune clipboard history: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 entry removed, got %d", removed)
+	}
+	removed, _ = s.PruneClipboardHistory(ctx, time.Time{}, 0)
+	if left, _ := s.GetClipboardEntries(ctx, "", 10); removed != 0 || len(left) != 2 {
+		t.Errorf("Expected nothing pruned without limits, got %d removed and %d left", removed, len(left))
+	}
+	if _, err := s.PruneClipboardHistory(ctx, start.Add(2*time.Hour), 0); err != nil {
+		t.Fatalf("Failed to prune clipboard history: %v", err)
+	}
+	if left, _ := s.GetClipboardEntries(ctx, "", 10); len(left) != 1 || left[0].ID != "c" {
+		t.Errorf("Expected only the pinned entry left, got %+v", left)
+	}
+
+	if err := s.DeleteClipboardEntry(ctx, "c"); err != nil {
+		t.Fatalf("Failed to delete clipboard entry: %v", err)
+	}
+	if err := s.DeleteClipboardEntry(ctx, "c"); err == nil {
+		t.Error("Expected error deleting a missing clipboard entry")
+	}
+}
+
+func TestSQLiteStorage_SensitiveClipboardEntries(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []models.ClipboardEntry{
+		{ID: "key", Kind: "text", Text: "[REDACTED api-key]", Hash: "h1", Sensitive: []string{"api-key", "email"},
+			CreatedAt: start, CopiedAt: start},
+		{ID: "image", Kind: "image", Image: []byte("\x89PNG"), Format: "png", Hash: "h2", CreatedAt: start, CopiedAt: start},
+		{ID: "pinned", Kind: "text", Text: "pwd: x", Hash: "h3", Sensitive: []string{"password"}, Pinned: true,
+			CreatedAt: start, CopiedAt: start},
+		{ID: "plain", Kind: "text", Text: "notes", Hash: "h4", CreatedAt: start, CopiedAt: start},
+	}
+	for i := range entries {
+		if err := s.SaveClipboardEntry(ctx, &entries[i]); err != nil {
+			t.Fatalf("Failed to save clipboard entry: %v", err)
+		}
+	}
+	if err := s.SetClipboardEntryPinned(ctx, "pinned", true); err != nil {
+		t.Fatalf("Failed to pin clipboard entry: %v", err)
+	}
+
+	// Kinds found earlier are kept when the OCR text is set again without any
+	if err := s.SetClipboardOCRText(ctx, "h2", "", []string{"card-number"}); err != nil {
+		t.Fatalf("Failed to set OCR text: %v", err)
+	}
+	if err := s.SetClipboardOCRText(ctx, "h2", "cached", nil); err != nil {
+		t.Fatalf("Failed to set OCR text: %v", err)
+	}
+
+	key, err := s.GetClipboardEntry(ctx, "key")
+	if err != nil {
+		t.Fatalf("Failed to get clipboard entry: %v", err)
+	}
+	if fmt.Sprint(key.Sensitive) != "[api-key email]" {
+		t.Errorf("Expected the sensitive kinds, got %v", key.Sensitive)
+	}
+	image, _ := s.GetClipboardEntry(ctx, "image")
+	if fmt.Sprint(image.Sensitive) != "[card-number]" || image.OCRText != "cached" {
+		t.Errorf("Expected the image marked as sensitive, got %+v", image)
+	}
+	if kinds, err := s.GetClipboardSensitiveKinds(ctx, "h2"); err != nil || fmt.Sprint(kinds) != "[card-number]" {
+		t.Errorf("Expected the kinds looked up by hash, got %v and %v", kinds, err)
+	}
+	if kinds, err := s.GetClipboardSensitiveKinds(ctx, "missing"); err != nil || kinds != nil {
+		t.Errorf("Expected no kinds for a missing entry, got %v and %v", kinds, err)
+	}
+
+	removed, err := s.ExpireSensitiveClipboardEntries(ctx, start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to expire sensitive clipboard entries: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 entries expired, got %d", removed)
+	}
+	left, _ := s.GetClipboardEntries(ctx, "", 10)
+	var ids []string
+	for _, entry := range left {
+		ids = append(ids, entry.ID)
+	}
+	if fmt.Sprint(ids) != "[pinned plain]" {
+		t.Errorf("Expected the pinned and plain entries left, got %v", ids)
+	}
+}
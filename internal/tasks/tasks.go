@@ -0,0 +1,166 @@
+// Package tasks extracts action items from free text, such as meeting notes,
+// with an LLM and turns them into todo texts.
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"talus_helper_windows/internal/openai"
+)
+
+// Task priorities
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// MaxTasks is the largest number of tasks returned from one text
+const MaxTasks = 50
+
+// Task is an action item found in a text. Assignee and Due are empty when the
+// text does not name them; Due is kept as written, such as "Friday".
+type Task struct {
+	Text     string `json:"text"`
+	Assignee string `json:"assignee"`
+	Due      string `json:"due"`
+	Priority string `json:"priority"`
+}
+
+// schemaName is the name of the JSON schema sent with extraction requests
+const schemaName = "action_items"
+
+// Schema is the strict JSON schema the LLM response must match: every
+// property is required and no other properties are allowed
+var Schema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"tasks": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"text": {"type": "string"},
+					"assignee": {"type": "string"},
+					"due": {"type": "string"},
+					"priority": {"type": "string", "enum": ["low", "normal", "high"]}
+				},
+				"required": ["text", "assignee", "due", "priority"],
+				"additionalProperties": false
+			}
+		}
+	},
+	"required": ["tasks"],
+	"additionalProperties": false
+}`)
+
+// SystemPrompt asks the LLM for the action items of the user's text
+const SystemPrompt = "Find the action items in the user's text, such as tasks, follow-ups and decisions " +
+	"that need someone to act. For each, set text to a short imperative sentence in the language of the text, " +
+	"assignee to the person responsible and due to the deadline exactly as written, or an empty string if " +
+	"not stated, and priority to low, normal or high. Do not invent tasks; return an empty list if there are none."
+
+// Extract sends text to the chat completions endpoint and returns the action
+// items found in it. opts sets the model and sampling parameters; its
+// ResponseFormat is replaced by Schema.
+func Extract(ctx context.Context, client *openai.Client, text string, opts openai.ChatOptions) ([]Task, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("no text to extract tasks from")
+	}
+
+	opts.ResponseFormat = openai.JSONSchemaFormat(schemaName, Schema)
+	raw, err := client.Complete(ctx, []openai.Message{
+		openai.TextMessage("system", SystemPrompt),
+		openai.TextMessage("user", text),
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// Parse validates an extraction response and normalizes its tasks: fields are
+// trimmed, tasks without text and repeated tasks are dropped, and a missing
+// priority becomes PriorityNormal
+func Parse(raw string) ([]Task, error) {
+	var response struct {
+		Tasks []Task `json:"tasks"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(stripCodeFence(raw))))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid task response: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("invalid task response: unexpected data after JSON value")
+	}
+
+	tasks := make([]Task, 0, len(response.Tasks))
+	seen := make(map[string]bool)
+	for _, task := range response.Tasks {
+		task.Text = strings.TrimSpace(task.Text)
+		task.Assignee = strings.TrimSpace(task.Assignee)
+		task.Due = strings.TrimSpace(task.Due)
+		if task.Text == "" {
+			continue
+		}
+		key := strings.ToLower(task.Text)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		switch priority := strings.ToLower(strings.TrimSpace(task.Priority)); priority {
+		case PriorityLow, PriorityHigh:
+			task.Priority = priority
+		case PriorityNormal, "":
+			task.Priority = PriorityNormal
+		default:
+			return nil, fmt.Errorf("invalid task response: unknown priority %q", task.Priority)
+		}
+
+		tasks = append(tasks, task)
+		if len(tasks) == MaxTasks {
+			break
+		}
+	}
+	return tasks, nil
+}
+
+// TodoText returns the text of the todo created for the task, with the
+// assignee, deadline and a high priority noted after it
+func (t Task) TodoText() string {
+	var notes []string
+	if t.Assignee != "" {
+		notes = append(notes, "@"+t.Assignee)
+	}
+	if t.Due != "" {
+		notes = append(notes, "due "+t.Due)
+	}
+	if t.Priority == PriorityHigh {
+		notes = append(notes, "high priority")
+	}
+	if len(notes) == 0 {
+		return t.Text
+	}
+	return fmt.Sprintf("%s (%s)", t.Text, strings.Join(notes, ", "))
+}
+
+// codeFence matches a response wrapped in a Markdown code block
+var codeFence = regexp.MustCompile("(?s)^```[a-zA-Z]*\\s*\\n(.*)\\n```$")
+
+// stripCodeFence removes a Markdown code block around a JSON response, which
+// some models add even when asked for JSON
+func stripCodeFence(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if match := codeFence.FindStringSubmatch(raw); match != nil {
+		return match[1]
+	}
+	return raw
+}
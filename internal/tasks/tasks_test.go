@@ -0,0 +1,170 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"talus_helper_windows/internal/openai"
+)
+
+// newFakeServer returns a chat completions server that answers every request
+// with content and records the last request
+func newFakeServer(t *testing.T, content string, got *openai.ChatRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Expected path /chat/completions, got %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		response := openai.ChatResponse{Choices: []openai.Choice{{
+			Message:      openai.Message{Role: "assistant", Content: content},
+			FinishReason: "stop",
+		}}}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestExtract(t *testing.T) {
+	var got openai.ChatRequest
+	server := newFakeServer(t, `{"tasks":[
+		{"text":"Send the slides","assignee":"Ann","due":"Friday","priority":"high"},
+		{"text":"Book a room","assignee":"","due":"","priority":"normal"}
+	]}`, &got)
+
+	client := openai.NewClient(server.URL, "test-key")
+	tasks, err := Extract(context.Background(), client, "  Ann sends the slides by Friday. Someone books a room. ", openai.ChatOptions{Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []Task{
+		{Text: "Send the slides", Assignee: "Ann", Due: "Friday", Priority: PriorityHigh},
+		{Text: "Book a room", Priority: PriorityNormal},
+	}
+	if len(tasks) != len(want) {
+		t.Fatalf("Expected %d tasks, got %+v", len(want), tasks)
+	}
+	for i := range want {
+		if tasks[i] != want[i] {
+			t.Errorf("Task %d: expected %+v, got %+v", i, want[i], tasks[i])
+		}
+	}
+
+	if got.Model != "gpt-4o-mini" || len(got.Messages) != 2 {
+		t.Fatalf("Unexpected request: %+v", got)
+	}
+	if got.Messages[1].Content != "Ann sends the slides by Friday. Someone books a room." {
+		t.Errorf("Expected trimmed text as user message, got %v", got.Messages[1].Content)
+	}
+	if got.ResponseFormat == nil || got.ResponseFormat.JSONSchema == nil ||
+		got.ResponseFormat.JSONSchema.Name != schemaName || !got.ResponseFormat.JSONSchema.Strict {
+		t.Errorf("Expected strict task schema, got %+v", got.ResponseFormat)
+	}
+}
+
+func TestExtract_InvalidResponse(t *testing.T) {
+	var got openai.ChatRequest
+	server := newFakeServer(t, "1. Send the slides\n2. Book a room", &got)
+
+	_, err := Extract(context.Background(), openai.NewClient(server.URL, "test-key"), "notes", openai.ChatOptions{Model: "m"})
+	if err == nil || !strings.Contains(err.Error(), "invalid task response") {
+		t.Errorf("Expected invalid task response error, got %v", err)
+	}
+}
+
+func TestExtract_EmptyText(t *testing.T) {
+	_, err := Extract(context.Background(), openai.NewClient("http://unused", "key"), " \n ", openai.ChatOptions{Model: "m"})
+	if err == nil {
+		t.Error("Expected an error for empty text")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []Task
+		wantErr string
+	}{
+		{
+			name: "normalizes fields",
+			raw:  `{"tasks":[{"text":" Call Bob ","assignee":" Ann ","due":"","priority":"HIGH"}]}`,
+			want: []Task{{Text: "Call Bob", Assignee: "Ann", Priority: PriorityHigh}},
+		},
+		{
+			name: "drops empty and repeated tasks",
+			raw:  `{"tasks":[{"text":"","assignee":"","due":"","priority":"low"},{"text":"Call Bob","assignee":"","due":"","priority":""},{"text":"call bob","assignee":"","due":"","priority":"high"}]}`,
+			want: []Task{{Text: "Call Bob", Priority: PriorityNormal}},
+		},
+		{
+			name: "strips code fence",
+			raw:  "```json\n{\"tasks\":[]}\n```",
+			want: []Task{},
+		},
+		{name: "unknown field", raw: `{"tasks":[],"summary":"x"}`, wantErr: "unknown field"},
+		{name: "unknown priority", raw: `{"tasks":[{"text":"x","assignee":"","due":"","priority":"urgent"}]}`, wantErr: "unknown priority"},
+		{name: "trailing data", raw: `{"tasks":[]} {}`, wantErr: "unexpected data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := Parse(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(tasks) != len(tt.want) {
+				t.Fatalf("Expected %+v, got %+v", tt.want, tasks)
+			}
+			for i := range tt.want {
+				if tasks[i] != tt.want[i] {
+					t.Errorf("Expected %+v, got %+v", tt.want[i], tasks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParse_MaxTasks(t *testing.T) {
+	items := make([]string, MaxTasks+5)
+	for i := range items {
+		items[i] = `{"text":"Task ` + strings.Repeat("x", i+1) + `","assignee":"","due":"","priority":"low"}`
+	}
+	tasks, err := Parse(`{"tasks":[` + strings.Join(items, ",") + `]}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tasks) != MaxTasks {
+		t.Errorf("Expected %d tasks, got %d", MaxTasks, len(tasks))
+	}
+}
+
+func TestTask_TodoText(t *testing.T) {
+	tests := []struct {
+		task Task
+		want string
+	}{
+		{Task{Text: "Book a room", Priority: PriorityNormal}, "Book a room"},
+		{Task{Text: "Send the slides", Assignee: "Ann", Due: "Friday", Priority: PriorityHigh}, "Send the slides (@Ann, due Friday, high priority)"},
+		{Task{Text: "Review budget", Due: "next week", Priority: PriorityLow}, "Review budget (due next week)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.task.TodoText(); got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+}
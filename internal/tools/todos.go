@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"talus_helper_windows/internal/models"
+)
+
+// TodoStore is the todo list the todo tools operate on
+type TodoStore interface {
+	GetTodos() ([]models.Todo, error)
+	AddTodo(text string) (models.Todo, error)
+	UpdateTodo(id, text string, completed bool) (models.Todo, error)
+	DeleteTodo(id string) error
+}
+
+// todoResult is a todo as returned to the model
+type todoResult struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	Completed bool   `json:"completed"`
+}
+
+func newTodoResult(todo models.Todo) todoResult {
+	return todoResult{ID: todo.ID, Text: todo.Text, Completed: todo.Completed}
+}
+
+// TodoTools returns the tools listing, adding, completing, editing and
+// deleting todos in store. Editing and deleting are destructive.
+func TodoTools(store TodoStore) []Tool {
+	return []Tool{
+		{
+			Name:        "list_todos",
+			Description: "List the user's todos, optionally only those containing query or with the given completed state.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"query": {"type": "string", "description": "Text the todo must contain, ignoring case"},
+					"completed": {"type": "boolean", "description": "Only list completed or open todos"}
+				}
+			}`),
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					Query     string `json:"query"`
+					Completed *bool  `json:"completed"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				todos, err := store.GetTodos()
+				if err != nil {
+					return "", err
+				}
+				query := strings.ToLower(strings.TrimSpace(params.Query))
+				results := []todoResult{}
+				for _, todo := range todos {
+					if params.Completed != nil && todo.Completed != *params.Completed {
+						continue
+					}
+					if query != "" && !strings.Contains(strings.ToLower(todo.Text), query) {
+						continue
+					}
+					results = append(results, newTodoResult(todo))
+				}
+				return jsonResult(results)
+			},
+		},
+		{
+			Name:        "add_todo",
+			Description: "Add a todo to the user's list.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"text": {"type": "string", "description": "The todo text"}
+				},
+				"required": ["text"]
+			}`),
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					Text string `json:"text"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				text := strings.TrimSpace(params.Text)
+				if text == "" {
+					return "", fmt.Errorf("text is required")
+				}
+				todo, err := store.AddTodo(text)
+				if err != nil {
+					return "", err
+				}
+				return jsonResult(newTodoResult(todo))
+			},
+		},
+		{
+			Name:        "set_todo_completed",
+			Description: "Mark a todo as completed or as open again.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "description": "The todo ID from list_todos"},
+					"completed": {"type": "boolean"}
+				},
+				"required": ["id", "completed"]
+			}`),
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					ID        string `json:"id"`
+					Completed bool   `json:"completed"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				todo, err := findTodo(store, params.ID)
+				if err != nil {
+					return "", err
+				}
+				updated, err := store.UpdateTodo(todo.ID, todo.Text, params.Completed)
+				if err != nil {
+					return "", err
+				}
+				return jsonResult(newTodoResult(updated))
+			},
+		},
+		{
+			Name:        "update_todo",
+			Description: "Replace the text of a todo. The user must confirm this.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "description": "The todo ID from list_todos"},
+					"text": {"type": "string", "description": "The new todo text"}
+				},
+				"required": ["id", "text"]
+			}`),
+			Destructive: true,
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					ID   string `json:"id"`
+					Text string `json:"text"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				text := strings.TrimSpace(params.Text)
+				if text == "" {
+					return "", fmt.Errorf("text is required")
+				}
+				todo, err := findTodo(store, params.ID)
+				if err != nil {
+					return "", err
+				}
+				updated, err := store.UpdateTodo(todo.ID, text, todo.Completed)
+				if err != nil {
+					return "", err
+				}
+				return jsonResult(newTodoResult(updated))
+			},
+		},
+		{
+			Name:        "delete_todo",
+			Description: "Delete a todo permanently. The user must confirm this.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "description": "The todo ID from list_todos"}
+				},
+				"required": ["id"]
+			}`),
+			Destructive: true,
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					ID string `json:"id"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				todo, err := findTodo(store, params.ID)
+				if err != nil {
+					return "", err
+				}
+				if err := store.DeleteTodo(todo.ID); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Deleted todo %q", todo.Text), nil
+			},
+		},
+	}
+}
+
+// findTodo returns the todo with the given ID
+func findTodo(store TodoStore, id string) (models.Todo, error) {
+	todos, err := store.GetTodos()
+	if err != nil {
+		return models.Todo{}, err
+	}
+	for _, todo := range todos {
+		if todo.ID == id {
+			return todo, nil
+		}
+	}
+	return models.Todo{}, fmt.Errorf("todo with id %s not found", id)
+}
@@ -0,0 +1,158 @@
+// Package tools defines the operations the chat assistant may call, such as
+// managing todos and Workflowy nodes, and runs the calls made by the model.
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/openai"
+)
+
+// DeclinedResult is returned to the model when the user declines a call
+const DeclinedResult = "The user declined this action."
+
+// Tool is an operation the model can call. Parameters is the JSON schema of
+// its arguments. Destructive tools change or delete existing data and only
+// run after the user confirms them.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+	Destructive bool
+	Run         func(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// ConfirmFunc asks the user whether a destructive tool call may run
+type ConfirmFunc func(ctx context.Context, tool Tool, args json.RawMessage) (bool, error)
+
+// LogFunc receives every tool invocation with its result and status
+type LogFunc func(invocation models.ToolInvocation)
+
+// Registry holds the tools offered to the model by name
+type Registry struct {
+	tools map[string]Tool
+}
+
+// NewRegistry returns a registry with the given tools
+func NewRegistry(tools ...Tool) *Registry {
+	r := &Registry{tools: make(map[string]Tool)}
+	r.Add(tools...)
+	return r
+}
+
+// Add registers tools, replacing any with the same name
+func (r *Registry) Add(tools ...Tool) {
+	for _, tool := range tools {
+		r.tools[tool.Name] = tool
+	}
+}
+
+// Lookup returns the tool with the given name
+func (r *Registry) Lookup(name string) (Tool, bool) {
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// Definitions returns the tool definitions for a chat request, sorted by name
+func (r *Registry) Definitions() []openai.Tool {
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	definitions := make([]openai.Tool, 0, len(names))
+	for _, name := range names {
+		tool := r.tools[name]
+		definitions = append(definitions, openai.FunctionTool(tool.Name, tool.Description, tool.Parameters))
+	}
+	return definitions
+}
+
+// Handler returns an openai.ToolHandler running calls with the registry.
+// Destructive calls run only when confirm approves them; with a nil confirm
+// they are always declined. Unknown tools, invalid arguments and failed runs
+// are reported to the model so it can recover, while a failed confirmation
+// or a cancelled context ends the completion. Every call is passed to log
+// when it is not nil.
+func (r *Registry) Handler(confirm ConfirmFunc, log LogFunc) openai.ToolHandler {
+	return func(ctx context.Context, call openai.ToolCall) (string, error) {
+		result, status, err := r.run(ctx, call, confirm)
+		if err != nil {
+			return "", err
+		}
+		if log != nil {
+			log(models.ToolInvocation{
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+				Result:    result,
+				Status:    status,
+				CreatedAt: time.Now(),
+			})
+		}
+		return result, nil
+	}
+}
+
+// run runs a call and returns its result and status
+func (r *Registry) run(ctx context.Context, call openai.ToolCall, confirm ConfirmFunc) (string, string, error) {
+	tool, ok := r.tools[call.Function.Name]
+	if !ok {
+		return fmt.Sprintf("Error: unknown tool %q", call.Function.Name), models.ToolStatusFailed, nil
+	}
+
+	args := json.RawMessage(call.Function.Arguments)
+	if len(bytes.TrimSpace(args)) == 0 {
+		args = json.RawMessage("{}")
+	}
+	if !json.Valid(args) {
+		return "Error: arguments are not valid JSON", models.ToolStatusFailed, nil
+	}
+
+	if tool.Destructive {
+		approved := false
+		if confirm != nil {
+			var err error
+			if approved, err = confirm(ctx, tool, args); err != nil {
+				return "", "", err
+			}
+		}
+		if !approved {
+			return DeclinedResult, models.ToolStatusDeclined, nil
+		}
+	}
+
+	result, err := tool.Run(ctx, args)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", "", ctxErr
+	}
+	if err != nil {
+		return "Error: " + err.Error(), models.ToolStatusFailed, nil
+	}
+	return result, models.ToolStatusOK, nil
+}
+
+// decodeArgs decodes tool arguments into v, rejecting unknown fields
+func decodeArgs(args json.RawMessage, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(args))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// jsonResult encodes v as the result of a tool call
+func jsonResult(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(data), nil
+}
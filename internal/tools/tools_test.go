@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"talus_helper_windows/internal/models"
+	"talus_helper_windows/internal/openai"
+	"talus_helper_windows/internal/workflowy"
+)
+
+// fakeTodoStore is an in-memory TodoStore
+type fakeTodoStore struct {
+	todos  []models.Todo
+	nextID int
+}
+
+func (s *fakeTodoStore) GetTodos() ([]models.Todo, error) {
+	return append([]models.Todo(nil), s.todos...), nil
+}
+
+func (s *fakeTodoStore) AddTodo(text string) (models.Todo, error) {
+	s.nextID++
+	todo := models.Todo{ID: fmt.Sprintf("t%d", s.nextID), Text: text, CreatedAt: time.Now()}
+	s.todos = append(s.todos, todo)
+	return todo, nil
+}
+
+func (s *fakeTodoStore) UpdateTodo(id, text string, completed bool) (models.Todo, error) {
+	for i := range s.todos {
+		if s.todos[i].ID == id {
+			s.todos[i].Text = text
+			s.todos[i].Completed = completed
+			return s.todos[i], nil
+		}
+	}
+	return models.Todo{}, fmt.Errorf("todo with id %s not found", id)
+}
+
+func (s *fakeTodoStore) DeleteTodo(id string) error {
+	for i := range s.todos {
+		if s.todos[i].ID == id {
+			s.todos = append(s.todos[:i], s.todos[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with id %s not found", id)
+}
+
+func call(name, args string) openai.ToolCall {
+	return openai.ToolCall{ID: "call_1", Type: "function", Function: openai.FunctionCall{Name: name, Arguments: args}}
+}
+
+func TestRegistry_Definitions(t *testing.T) {
+	registry := NewRegistry(TodoTools(&fakeTodoStore{})...)
+	registry.Add(WorkflowyTools(workflowy.NewMockClient())...)
+
+	definitions := registry.Definitions()
+	if len(definitions) != 10 {
+		t.Fatalf("Expected 10 definitions, got %d", len(definitions))
+	}
+	for i, definition := range definitions {
+		if definition.Type != "function" {
+			t.Errorf("Expected function tool, got %q", definition.Type)
+		}
+		if !json.Valid(definition.Function.Parameters) {
+			t.Errorf("Tool %s has invalid parameters schema", definition.Function.Name)
+		}
+		if i > 0 && definitions[i-1].Function.Name >= definition.Function.Name {
+			t.Errorf("Expected definitions sorted by name, got %s before %s", definitions[i-1].Function.Name, definition.Function.Name)
+		}
+	}
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	store := &fakeTodoStore{todos: []models.Todo{
+		{ID: "a", Text: "Buy milk"},
+		{ID: "b", Text: "Call Bob", Completed: true},
+	}}
+	registry := NewRegistry(TodoTools(store)...)
+
+	tests := []struct {
+		name       string
+		call       openai.ToolCall
+		approve    bool
+		wantStatus string
+		wantResult string
+	}{
+		{"list filtered", call("list_todos", `{"completed":false}`), false, models.ToolStatusOK, `[{"id":"a","text":"Buy milk","completed":false}]`},
+		{"list by query", call("list_todos", `{"query":"BOB"}`), false, models.ToolStatusOK, `"id":"b"`},
+		{"empty arguments", call("list_todos", ``), false, models.ToolStatusOK, `"id":"a"`},
+		{"add", call("add_todo", `{"text":" Pay rent "}`), false, models.ToolStatusOK, `"text":"Pay rent"`},
+		{"complete", call("set_todo_completed", `{"id":"a","completed":true}`), false, models.ToolStatusOK, `"completed":true`},
+		{"delete declined", call("delete_todo", `{"id":"a"}`), false, models.ToolStatusDeclined, DeclinedResult},
+		{"delete approved", call("delete_todo", `{"id":"b"}`), true, models.ToolStatusOK, `Deleted todo "Call Bob"`},
+		{"unknown todo", call("set_todo_completed", `{"id":"zzz","completed":true}`), false, models.ToolStatusFailed, "not found"},
+		{"unknown tool", call("format_disk", `{}`), false, models.ToolStatusFailed, "unknown tool"},
+		{"invalid json", call("add_todo", `{"text":`), false, models.ToolStatusFailed, "not valid JSON"},
+		{"unknown field", call("add_todo", `{"text":"x","when":"now"}`), false, models.ToolStatusFailed, "unknown field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []models.ToolInvocation
+			var confirmed []string
+			confirm := func(ctx context.Context, tool Tool, args json.RawMessage) (bool, error) {
+				confirmed = append(confirmed, tool.Name)
+				return tt.approve, nil
+			}
+			handle := registry.Handler(confirm, func(invocation models.ToolInvocation) {
+				logged = append(logged, invocation)
+			})
+
+			result, err := handle(context.Background(), tt.call)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !strings.Contains(result, tt.wantResult) {
+				t.Errorf("Expected result containing %q, got %q", tt.wantResult, result)
+			}
+			if len(logged) != 1 || logged[0].Status != tt.wantStatus || logged[0].Name != tt.call.Function.Name || logged[0].Result != result {
+				t.Errorf("Expected one %s invocation logged, got %+v", tt.wantStatus, logged)
+			}
+
+			tool, ok := registry.Lookup(tt.call.Function.Name)
+			if wantConfirm := ok && tool.Destructive; wantConfirm != (len(confirmed) == 1) {
+				t.Errorf("Expected confirmation %v, got %v", wantConfirm, confirmed)
+			}
+		})
+	}
+
+	if len(store.todos) != 2 {
+		t.Errorf("Expected the approved delete only, got %+v", store.todos)
+	}
+}
+
+func TestRegistry_Handler_NoConfirm(t *testing.T) {
+	store := &fakeTodoStore{todos: []models.Todo{{ID: "a", Text: "Buy milk"}}}
+	handle := NewRegistry(TodoTools(store)...).Handler(nil, nil)
+
+	result, err := handle(context.Background(), call("update_todo", `{"id":"a","text":"Buy oat milk"}`))
+	if err != nil || result != DeclinedResult {
+		t.Errorf("Expected declined result, got %q, %v", result, err)
+	}
+	if store.todos[0].Text != "Buy milk" {
+		t.Errorf("Expected todo unchanged, got %q", store.todos[0].Text)
+	}
+}
+
+func TestRegistry_Handler_ConfirmError(t *testing.T) {
+	store := &fakeTodoStore{todos: []models.Todo{{ID: "a", Text: "Buy milk"}}}
+	confirmErr := errors.New("confirmation cancelled")
+	var logged int
+	handle := NewRegistry(TodoTools(store)...).Handler(
+		func(ctx context.Context, tool Tool, args json.RawMessage) (bool, error) { return false, confirmErr },
+		func(models.ToolInvocation) { logged++ },
+	)
+
+	if _, err := handle(context.Background(), call("delete_todo", `{"id":"a"}`)); !errors.Is(err, confirmErr) {
+		t.Errorf("Expected confirmation error, got %v", err)
+	}
+	if logged != 0 || len(store.todos) != 1 {
+		t.Errorf("Expected nothing run or logged, got %d logged and %+v", logged, store.todos)
+	}
+}
+
+func TestWorkflowyTools(t *testing.T) {
+	client := workflowy.NewMockClient()
+	approve := func(ctx context.Context, tool Tool, args json.RawMessage) (bool, error) { return true, nil }
+	handle := NewRegistry(WorkflowyTools(client)...).Handler(approve, nil)
+	ctx := context.Background()
+
+	result, err := handle(ctx, call("create_workflowy_node", `{"name":"Groceries","note":"weekly"}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(result), &created); err != nil || created.ID == "" {
+		t.Fatalf("Expected created node ID, got %q", result)
+	}
+	if len(client.CreateNodeCalls) != 1 || client.CreateNodeCalls[0].ParentID != workflowy.TopLevelParent {
+		t.Errorf("Expected top-level create, got %+v", client.CreateNodeCalls)
+	}
+
+	if _, err := handle(ctx, call("set_workflowy_node_completed", fmt.Sprintf(`{"id":%q,"completed":true}`, created.ID))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result, _ = handle(ctx, call("list_workflowy_nodes", `{}`))
+	if !strings.Contains(result, `"name":"Groceries","note":"weekly","completed":true`) {
+		t.Errorf("Expected completed node listed, got %s", result)
+	}
+	if client.ListNodesCalls[0] != workflowy.TopLevelParent {
+		t.Errorf("Expected top-level list, got %q", client.ListNodesCalls[0])
+	}
+
+	if _, err := handle(ctx, call("update_workflowy_node", fmt.Sprintf(`{"id":%q,"name":"Shopping"}`, created.ID))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.UpdateNodeCalls[created.ID] == nil || client.UpdateNodeCalls[created.ID].Name != "Shopping" {
+		t.Errorf("Expected node renamed, got %+v", client.UpdateNodeCalls)
+	}
+
+	if _, err := handle(ctx, call("delete_workflowy_node", fmt.Sprintf(`{"id":%q}`, created.ID))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.GetNodeCount() != 0 {
+		t.Errorf("Expected node deleted, got %d nodes", client.GetNodeCount())
+	}
+
+	result, _ = handle(ctx, call("update_workflowy_node", `{"id":"x"}`))
+	if !strings.Contains(result, "name or note is required") {
+		t.Errorf("Expected validation error, got %q", result)
+	}
+}
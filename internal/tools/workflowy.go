@@ -0,0 +1,204 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"talus_helper_windows/internal/workflowy"
+)
+
+// nodeResult is a Workflowy node as returned to the model
+type nodeResult struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Note      string `json:"note,omitempty"`
+	Completed bool   `json:"completed"`
+}
+
+func newNodeResult(node workflowy.Node) nodeResult {
+	result := nodeResult{ID: node.ID, Name: node.Name, Completed: node.CompletedAt != nil}
+	if node.Note != nil {
+		result.Note = *node.Note
+	}
+	return result
+}
+
+// WorkflowyTools returns the tools listing, creating, completing, editing
+// and deleting Workflowy nodes with client. Editing and deleting are
+// destructive.
+func WorkflowyTools(client workflowy.WorkflowyClient) []Tool {
+	return []Tool{
+		{
+			Name:        "list_workflowy_nodes",
+			Description: "List the Workflowy nodes under a parent node, or the top-level nodes without parent_id.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"parent_id": {"type": "string", "description": "The parent node ID"}
+				}
+			}`),
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					ParentID string `json:"parent_id"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				nodes, err := client.ListNodes(parentOrTop(params.ParentID))
+				if err != nil {
+					return "", err
+				}
+				results := make([]nodeResult, 0, len(nodes))
+				for _, node := range nodes {
+					results = append(results, newNodeResult(node))
+				}
+				return jsonResult(results)
+			},
+		},
+		{
+			Name:        "create_workflowy_node",
+			Description: "Create a Workflowy node under a parent node, or at the top level without parent_id.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"parent_id": {"type": "string", "description": "The parent node ID"},
+					"name": {"type": "string", "description": "The node text"},
+					"note": {"type": "string", "description": "A note shown under the node"}
+				},
+				"required": ["name"]
+			}`),
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					ParentID string `json:"parent_id"`
+					Name     string `json:"name"`
+					Note     string `json:"note"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				name := strings.TrimSpace(params.Name)
+				if name == "" {
+					return "", fmt.Errorf("name is required")
+				}
+				resp, err := client.CreateNode(&workflowy.CreateNodeRequest{
+					ParentID: parentOrTop(params.ParentID),
+					Name:     name,
+					Note:     params.Note,
+				})
+				if err != nil {
+					return "", err
+				}
+				return jsonResult(map[string]string{"id": resp.ItemID})
+			},
+		},
+		{
+			Name:        "set_workflowy_node_completed",
+			Description: "Mark a Workflowy node as completed or as open again.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "description": "The node ID"},
+					"completed": {"type": "boolean"}
+				},
+				"required": ["id", "completed"]
+			}`),
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					ID        string `json:"id"`
+					Completed bool   `json:"completed"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				if params.ID == "" {
+					return "", fmt.Errorf("id is required")
+				}
+				var err error
+				if params.Completed {
+					_, err = client.CompleteNode(params.ID)
+				} else {
+					_, err = client.UncompleteNode(params.ID)
+				}
+				if err != nil {
+					return "", err
+				}
+				return "OK", nil
+			},
+		},
+		{
+			Name:        "update_workflowy_node",
+			Description: "Change the text or note of a Workflowy node. The user must confirm this.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "description": "The node ID"},
+					"name": {"type": "string", "description": "The new node text"},
+					"note": {"type": "string", "description": "The new note"}
+				},
+				"required": ["id"]
+			}`),
+			Destructive: true,
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+					Note string `json:"note"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				if params.ID == "" {
+					return "", fmt.Errorf("id is required")
+				}
+				if strings.TrimSpace(params.Name) == "" && params.Note == "" {
+					return "", fmt.Errorf("name or note is required")
+				}
+				_, err := client.UpdateNode(params.ID, &workflowy.UpdateNodeRequest{
+					Name: strings.TrimSpace(params.Name),
+					Note: params.Note,
+				})
+				if err != nil {
+					return "", err
+				}
+				return "OK", nil
+			},
+		},
+		{
+			Name:        "delete_workflowy_node",
+			Description: "Delete a Workflowy node and its children permanently. The user must confirm this.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "description": "The node ID"}
+				},
+				"required": ["id"]
+			}`),
+			Destructive: true,
+			Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var params struct {
+					ID string `json:"id"`
+				}
+				if err := decodeArgs(args, &params); err != nil {
+					return "", err
+				}
+				if params.ID == "" {
+					return "", fmt.Errorf("id is required")
+				}
+				if _, err := client.DeleteNode(params.ID); err != nil {
+					return "", err
+				}
+				return "OK", nil
+			},
+		},
+	}
+}
+
+// parentOrTop returns parentID, or the top-level parent when it is empty
+func parentOrTop(parentID string) string {
+	if parentID = strings.TrimSpace(parentID); parentID != "" {
+		return parentID
+	}
+	return workflowy.TopLevelParent
+}
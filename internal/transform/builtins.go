@@ -0,0 +1,267 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Builtins returns the built-in transformers
+func Builtins() []Transformer {
+	return []Transformer{
+		{Name: "trim", Description: "Removes leading and trailing whitespace", Apply: trim},
+		{Name: "strip-formatting", Description: "Removes terminal colours, invisible characters, typographic quotes and trailing spaces", Apply: stripFormatting},
+		{Name: "upper", Description: "Converts to UPPER CASE", Apply: upper},
+		{Name: "lower", Description: "Converts to lower case", Apply: lower},
+		{Name: "title", Description: "Capitalizes Each Word", Apply: title},
+		{Name: "camel", Description: "Converts each line to camelCase", Apply: camel},
+		{Name: "snake", Description: "Converts each line to snake_case", Apply: snake},
+		{Name: "kebab", Description: "Converts each line to kebab-case", Apply: kebab},
+		{Name: "json-pretty", Description: "Indents JSON", Apply: jsonPretty},
+		{Name: "json-minify", Description: "Removes the whitespace from JSON", Apply: jsonMinify},
+		{Name: "base64-encode", Description: "Encodes as base64", Apply: base64Encode},
+		{Name: "base64-decode", Description: "Decodes standard or URL-safe base64, with or without padding", Apply: base64Decode},
+		{Name: "jwt-decode", Description: "Shows the header and payload of a JWT as JSON, without verifying it", Apply: jwtDecode},
+		{Name: "url-encode", Description: "Escapes text for a URL query", Apply: urlEncode},
+		{Name: "url-decode", Description: "Unescapes a URL-encoded text", Apply: urlDecode},
+	}
+}
+
+// trim removes leading and trailing whitespace
+func trim(text string) (string, error) {
+	return strings.TrimSpace(text), nil
+}
+
+// ansiEscape matches terminal colour and cursor sequences
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// formattingReplacer replaces characters that rich text and word processors
+// add with their plain equivalents, and drops invisible ones
+var formattingReplacer = strings.NewReplacer(
+	"\u00a0", " ", "\u2007", " ", "\u202f", " ",
+	"\u2018", "'", "\u2019", "'", "\u201c", `"`, "\u201d", `"`,
+	"\u2013", "-", "\u2014", "-", "\u2026", "...",
+	"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "", "\u00ad", "",
+)
+
+// stripFormatting turns formatted text into plain text. Line endings are
+// kept, but spaces at the end of each line are removed.
+func stripFormatting(text string) (string, error) {
+	text = formattingReplacer.Replace(ansiEscape.ReplaceAllString(text, ""))
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		cr := strings.HasSuffix(line, "\r")
+		line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " \t")
+		if cr {
+			line += "\r"
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// upper converts text to upper case
+func upper(text string) (string, error) {
+	return strings.ToUpper(text), nil
+}
+
+// lower converts text to lower case
+func lower(text string) (string, error) {
+	return strings.ToLower(text), nil
+}
+
+// title capitalizes the first letter of every word and lowers the others
+func title(text string) (string, error) {
+	var b strings.Builder
+	start := true
+	for _, r := range text {
+		if start {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+		start = unicode.IsSpace(r)
+	}
+	return b.String(), nil
+}
+
+// camel converts each line to camelCase
+func camel(text string) (string, error) {
+	return eachLine(text, func(words []string) string {
+		for i, word := range words {
+			word = strings.ToLower(word)
+			if i > 0 {
+				r, size := utf8.DecodeRuneInString(word)
+				word = string(unicode.ToUpper(r)) + word[size:]
+			}
+			words[i] = word
+		}
+		return strings.Join(words, "")
+	}), nil
+}
+
+// snake converts each line to snake_case
+func snake(text string) (string, error) {
+	return eachLine(text, func(words []string) string {
+		return strings.ToLower(strings.Join(words, "_"))
+	}), nil
+}
+
+// kebab converts each line to kebab-case
+func kebab(text string) (string, error) {
+	return eachLine(text, func(words []string) string {
+		return strings.ToLower(strings.Join(words, "-"))
+	}), nil
+}
+
+// eachLine splits every line of text into words and joins them with join.
+// Blank lines are kept.
+func eachLine(text string, join func(words []string) string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if words := splitWords(line); len(words) > 0 {
+			lines[i] = join(words)
+		} else {
+			lines[i] = strings.TrimSpace(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitWords splits an identifier or phrase into words at spaces and
+// punctuation, and where the case changes, so that "parseHTTPRequest" and
+// "parse_http request" both give parse, HTTP or http, and Request or request
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		lowerToUpper := unicode.IsUpper(r) && !unicode.IsUpper(prev)
+		acronymEnd := unicode.IsUpper(r) && unicode.IsUpper(prev) &&
+			i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// jsonPretty indents JSON by two spaces
+func jsonPretty(text string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(strings.TrimSpace(text)), "", "  "); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// jsonMinify removes insignificant whitespace from JSON
+func jsonMinify(text string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(text)); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// base64Encode encodes text as standard, padded base64
+func base64Encode(text string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(text)), nil
+}
+
+// base64Decode decodes standard or URL-safe base64, ignoring whitespace and
+// missing padding. The result must be text.
+func base64Decode(text string) (string, error) {
+	data, err := decodeBase64(strings.Join(strings.Fields(text), ""))
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("decoded data is binary, not text")
+	}
+	return string(data), nil
+}
+
+// decodeBase64 decodes standard or URL-safe base64 with or without padding
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	data, err := encoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	return data, nil
+}
+
+// jwtDecode returns the header and payload of a JWT as indented JSON. The
+// signature is not checked. A leading "Bearer " is ignored.
+func jwtDecode(text string) (string, error) {
+	token := strings.TrimSpace(text)
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("a JWT has 3 parts separated by dots, got %d", len(parts))
+	}
+
+	var decoded struct {
+		Header  json.RawMessage `json:"header"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	for i, part := range []*json.RawMessage{&decoded.Header, &decoded.Payload} {
+		data, err := decodeBase64(parts[i])
+		if err != nil {
+			return "", err
+		}
+		if !json.Valid(data) {
+			return "", fmt.Errorf("JWT %s is not JSON", []string{"header", "payload"}[i])
+		}
+		*part = data
+	}
+
+	result, err := json.MarshalIndent(decoded, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// urlEncode escapes text for use in a URL query
+func urlEncode(text string) (string, error) {
+	return url.QueryEscape(text), nil
+}
+
+// urlDecode unescapes URL-encoded text, turning + into a space
+func urlDecode(text string) (string, error) {
+	decoded, err := url.QueryUnescape(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL encoding: %w", err)
+	}
+	return decoded, nil
+}
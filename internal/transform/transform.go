@@ -0,0 +1,144 @@
+// Package transform holds composable text transformers, such as pretty
+// printing JSON or decoding base64, and the named chains of them that users
+// define, and runs them on clipboard text.
+package transform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Transformer turns one text into another. Chains built from other
+// transformers list them in Steps.
+type Transformer struct {
+	Name        string                            `json:"name"`
+	Description string                            `json:"description"`
+	Steps       []string                          `json:"steps,omitempty"`
+	Apply       func(text string) (string, error) `json:"-"`
+}
+
+// Registry holds transformers by name
+type Registry struct {
+	transformers map[string]Transformer
+}
+
+// NewRegistry returns a registry with the given transformers
+func NewRegistry(transformers ...Transformer) *Registry {
+	r := &Registry{transformers: make(map[string]Transformer)}
+	r.Add(transformers...)
+	return r
+}
+
+// Add registers transformers, replacing any with the same name
+func (r *Registry) Add(transformers ...Transformer) {
+	for _, transformer := range transformers {
+		r.transformers[transformer.Name] = transformer
+	}
+}
+
+// Lookup returns the transformer with the given name
+func (r *Registry) Lookup(name string) (Transformer, bool) {
+	transformer, ok := r.transformers[name]
+	return transformer, ok
+}
+
+// List returns the registered transformers sorted by name
+func (r *Registry) List() []Transformer {
+	names := make([]string, 0, len(r.transformers))
+	for name := range r.transformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	transformers := make([]Transformer, 0, len(names))
+	for _, name := range names {
+		transformers = append(transformers, r.transformers[name])
+	}
+	return transformers
+}
+
+// Apply runs text through the named transformers in order
+func (r *Registry) Apply(text string, names ...string) (string, error) {
+	chain, err := r.Chain("", names...)
+	if err != nil {
+		return "", err
+	}
+	return chain.Apply(text)
+}
+
+// Chain composes the named transformers into one that runs them in order.
+// The error of a failing step names it.
+func (r *Registry) Chain(name string, steps ...string) (Transformer, error) {
+	if len(steps) == 0 {
+		return Transformer{}, fmt.Errorf("no transformers given")
+	}
+	transformers := make([]Transformer, len(steps))
+	for i, step := range steps {
+		transformer, ok := r.transformers[step]
+		if !ok {
+			return Transformer{}, fmt.Errorf("unknown transformer %q", step)
+		}
+		transformers[i] = transformer
+	}
+
+	return Transformer{
+		Name:        name,
+		Description: "Runs " + strings.Join(steps, ", then "),
+		Steps:       append([]string(nil), steps...),
+		Apply: func(text string) (string, error) {
+			for _, transformer := range transformers {
+				var err error
+				if text, err = transformer.Apply(text); err != nil {
+					return "", fmt.Errorf("%s: %w", transformer.Name, err)
+				}
+			}
+			return text, nil
+		},
+	}, nil
+}
+
+// AddChains registers user-defined chains, keyed by name, each listing the
+// transformers it runs. Chains may use each other, in any order, but not
+// themselves; a chain replaces a transformer with the same name.
+func (r *Registry) AddChains(chains map[string][]string) error {
+	names := make([]string, 0, len(chains))
+	for name := range chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	added := make(map[string]bool)
+	var add func(name string, visiting map[string]bool) error
+	add = func(name string, visiting map[string]bool) error {
+		if added[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("chain %q uses itself", name)
+		}
+		visiting[name] = true
+		for _, step := range chains[name] {
+			if _, ok := chains[step]; ok {
+				if err := add(step, visiting); err != nil {
+					return err
+				}
+			}
+		}
+
+		chain, err := r.Chain(name, chains[name]...)
+		if err != nil {
+			return fmt.Errorf("chain %q: %w", name, err)
+		}
+		r.Add(chain)
+		added[name] = true
+		return nil
+	}
+
+	for _, name := range names {
+		if err := add(name, make(map[string]bool)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
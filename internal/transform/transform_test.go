@@ -0,0 +1,143 @@
+package transform
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Apply(t *testing.T) {
+	registry := NewRegistry(Builtins()...)
+
+	tests := []struct {
+		name    string
+		input   string
+		steps   []string
+		want    string
+		wantErr string
+	}{
+		{"single", " hi ", []string{"trim"}, "hi", ""},
+		{"in order", "eyJhIjogMX0=", []string{"base64-decode", "json-minify", "upper"}, `{"A":1}`, ""},
+		{"unknown", "x", []string{"trim", "reverse"}, "", `unknown transformer "reverse"`},
+		{"none", "x", nil, "", "no transformers given"},
+		{"failing step", "{", []string{"trim", "json-pretty"}, "", "json-pretty: invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.Apply(tt.input, tt.steps...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRegistry_List(t *testing.T) {
+	registry := NewRegistry(
+		Transformer{Name: "b", Apply: trim},
+		Transformer{Name: "a", Apply: trim},
+	)
+	registry.Add(Transformer{Name: "b", Description: "replaced", Apply: upper})
+
+	list := registry.List()
+	if len(list) != 2 || list[0].Name != "a" || list[1].Description != "replaced" {
+		t.Errorf("Expected a and the replaced b, got %+v", list)
+	}
+}
+
+func TestRegistry_AddChains(t *testing.T) {
+	tests := []struct {
+		name    string
+		chains  map[string][]string
+		run     string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "chain",
+			chains: map[string][]string{"tidy": {"strip-formatting", "trim"}},
+			run:    "tidy",
+			input:  " \u201cx\u201d  ",
+			want:   `"x"`,
+		},
+		{
+			name: "chain of chains",
+			chains: map[string][]string{
+				"a-first":  {"b-second", "upper"},
+				"b-second": {"jwt-decode", "json-minify"},
+			},
+			run:   "a-first",
+			input: jwt,
+			want:  `{"HEADER":{"ALG":"HS256","TYP":"JWT"},"PAYLOAD":{"SUB":"42","NAME":"ADA"}}`,
+		},
+		{
+			name:    "unknown step",
+			chains:  map[string][]string{"tidy": {"trim", "prettify"}},
+			wantErr: `chain "tidy": unknown transformer "prettify"`,
+		},
+		{
+			name:    "empty",
+			chains:  map[string][]string{"nothing": {}},
+			wantErr: "no transformers given",
+		},
+		{
+			name:    "cycle",
+			chains:  map[string][]string{"a": {"b"}, "b": {"trim", "a"}},
+			wantErr: "uses itself",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(Builtins()...)
+			err := registry.AddChains(tt.chains)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			chain, ok := registry.Lookup(tt.run)
+			if !ok || len(chain.Steps) != len(tt.chains[tt.run]) {
+				t.Fatalf("Expected chain %s with its steps, got %+v", tt.run, chain)
+			}
+			got, err := chain.Apply(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestChain_WrapsStepErrors(t *testing.T) {
+	failure := errors.New("boom")
+	registry := NewRegistry(Transformer{Name: "fail", Apply: func(string) (string, error) {
+		return "", failure
+	}})
+
+	chain, err := registry.Chain("mine", "fail")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := chain.Apply("x"); !errors.Is(err, failure) || !strings.HasPrefix(err.Error(), "fail: ") {
+		t.Errorf("Expected the step error with its name, got %v", err)
+	}
+}
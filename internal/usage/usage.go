@@ -0,0 +1,172 @@
+package usage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"talus_helper_windows/internal/models"
+)
+
+// Features that make LLM calls, recorded with each call
+const (
+	FeatureOCR       = "ocr"
+	FeatureTranslate = "translate"
+	FeatureChat      = "chat"
+	FeatureTasks     = "tasks"
+	FeatureSearch    = "search"
+	FeatureOther     = "other"
+)
+
+// Budget actions
+const (
+	ActionWarn  = "warn"
+	ActionBlock = "block"
+)
+
+type featureKey struct{}
+
+// WithFeature returns a context that attributes LLM calls made with it to feature
+func WithFeature(ctx context.Context, feature string) context.Context {
+	return context.WithValue(ctx, featureKey{}, feature)
+}
+
+// FeatureFromContext returns the feature set by WithFeature, or FeatureOther
+func FeatureFromContext(ctx context.Context) string {
+	if feature, ok := ctx.Value(featureKey{}).(string); ok && feature != "" {
+		return feature
+	}
+	return FeatureOther
+}
+
+// Price is the cost of a model per million prompt and completion tokens
+type Price struct {
+	Prompt     float64 `toml:"prompt" json:"prompt"`
+	Completion float64 `toml:"completion" json:"completion"`
+}
+
+// Cost returns the cost of a call with the given token counts
+func (p Price) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Prompt + float64(completionTokens)*p.Completion) / 1_000_000
+}
+
+// PriceFor returns the price of model. An exact match wins; otherwise the
+// longest key that is a prefix of model is used, so "gpt-4o" also prices
+// dated variants such as "gpt-4o-2024-08-06".
+func PriceFor(prices map[string]Price, model string) (Price, bool) {
+	if price, ok := prices[model]; ok {
+		return price, true
+	}
+
+	best := ""
+	for name := range prices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return prices[best], true
+}
+
+// Total aggregates the calls sharing a key such as a model, feature or day
+type Total struct {
+	Key              string  `json:"key"`
+	Requests         int     `json:"requests"`
+	Failures         int     `json:"failures"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	Cost             float64 `json:"cost"`
+	AvgLatencyMs     int64   `json:"avgLatencyMs"`
+
+	latencyMs int64
+}
+
+// add accumulates a record into the total
+func (t *Total) add(record models.UsageRecord) {
+	t.Requests++
+	if record.Error != "" {
+		t.Failures++
+	}
+	t.PromptTokens += record.PromptTokens
+	t.CompletionTokens += record.CompletionTokens
+	t.Cost += record.Cost
+	t.latencyMs += record.LatencyMs
+	t.AvgLatencyMs = t.latencyMs / int64(t.Requests)
+}
+
+// Report summarizes LLM usage over a period and the spend against the budgets
+type Report struct {
+	Since         time.Time `json:"since"`
+	Total         Total     `json:"total"`
+	ByModel       []Total   `json:"byModel"`
+	ByFeature     []Total   `json:"byFeature"`
+	ByDay         []Total   `json:"byDay"`
+	TodayCost     float64   `json:"todayCost"`
+	MonthCost     float64   `json:"monthCost"`
+	DailyBudget   float64   `json:"dailyBudget"`
+	MonthlyBudget float64   `json:"monthlyBudget"`
+}
+
+// Summarize aggregates records by model, feature and local calendar day
+func Summarize(records []models.UsageRecord, since time.Time) Report {
+	report := Report{Since: since, Total: Total{Key: "total"}}
+
+	byModel := map[string]*Total{}
+	byFeature := map[string]*Total{}
+	byDay := map[string]*Total{}
+	for _, record := range records {
+		report.Total.add(record)
+		group(byModel, record.Model).add(record)
+		group(byFeature, record.Feature).add(record)
+		group(byDay, record.CreatedAt.Local().Format("2006-01-02")).add(record)
+	}
+
+	report.ByModel = sorted(byModel, func(a, b Total) bool { return a.Cost > b.Cost || (a.Cost == b.Cost && a.Key < b.Key) })
+	report.ByFeature = sorted(byFeature, func(a, b Total) bool { return a.Cost > b.Cost || (a.Cost == b.Cost && a.Key < b.Key) })
+	report.ByDay = sorted(byDay, func(a, b Total) bool { return a.Key < b.Key })
+	return report
+}
+
+// group returns the total for key, creating it if needed
+func group(totals map[string]*Total, key string) *Total {
+	if totals[key] == nil {
+		totals[key] = &Total{Key: key}
+	}
+	return totals[key]
+}
+
+// sorted returns the totals ordered by less
+func sorted(totals map[string]*Total, less func(a, b Total) bool) []Total {
+	result := make([]Total, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool { return less(result[i], result[j]) })
+	return result
+}
+
+// StartOfDay returns local midnight of the day containing t
+func StartOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// StartOfMonth returns local midnight of the first day of the month containing t
+func StartOfMonth(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+}
+
+// SumCost returns the total cost of the records created at or after since
+func SumCost(records []models.UsageRecord, since time.Time) float64 {
+	var cost float64
+	for _, record := range records {
+		if !record.CreatedAt.Before(since) {
+			cost += record.Cost
+		}
+	}
+	return cost
+}
@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"talus_helper_windows/internal/models"
+)
+
+func TestFeatureFromContext(t *testing.T) {
+	if got := FeatureFromContext(context.Background()); got != FeatureOther {
+		t.Errorf("Expected %q, got %q", FeatureOther, got)
+	}
+	if got := FeatureFromContext(WithFeature(context.Background(), FeatureOCR)); got != FeatureOCR {
+		t.Errorf("Expected %q, got %q", FeatureOCR, got)
+	}
+}
+
+func TestPriceFor(t *testing.T) {
+	prices := map[string]Price{
+		"gpt-4o":      {Prompt: 2.5, Completion: 10},
+		"gpt-4o-mini": {Prompt: 0.15, Completion: 0.6},
+	}
+
+	tests := []struct {
+		model string
+		want  float64
+		found bool
+	}{
+		{"gpt-4o", 2.5, true},
+		{"gpt-4o-2024-08-06", 2.5, true},
+		{"gpt-4o-mini-2024-07-18", 0.15, true},
+		{"moonshot-v1-8k", 0, false},
+	}
+	for _, tt := range tests {
+		price, found := PriceFor(prices, tt.model)
+		if found != tt.found || price.Prompt != tt.want {
+			t.Errorf("PriceFor(%q) = %v, %v; want prompt %v, %v", tt.model, price, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestPrice_Cost(t *testing.T) {
+	price := Price{Prompt: 2.5, Completion: 10}
+	if got := price.Cost(1000, 500); math.Abs(got-0.0075) > 1e-12 {
+		t.Errorf("Expected 0.0075, got %v", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	day1 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	day2 := day1.Add(24 * time.Hour)
+	records := []models.UsageRecord{
+		{Feature: FeatureOCR, Model: "a", PromptTokens: 10, CompletionTokens: 1, LatencyMs: 100, Cost: 1, CreatedAt: day1},
+		{Feature: FeatureOCR, Model: "b", PromptTokens: 20, CompletionTokens: 2, LatencyMs: 300, Cost: 3, CreatedAt: day1},
+		{Feature: FeatureOther, Model: "a", Error: "failed", LatencyMs: 200, CreatedAt: day2},
+	}
+
+	report := Summarize(records, day1)
+
+	if report.Total.Requests != 3 || report.Total.Failures != 1 || report.Total.Cost != 4 || report.Total.PromptTokens != 30 {
+		t.Errorf("Unexpected total: %+v", report.Total)
+	}
+	if report.Total.AvgLatencyMs != 200 {
+		t.Errorf("Expected average latency 200, got %d", report.Total.AvgLatencyMs)
+	}
+	if len(report.ByModel) != 2 || report.ByModel[0].Key != "b" || report.ByModel[1].Requests != 2 {
+		t.Errorf("Expected models ordered by cost, got %+v", report.ByModel)
+	}
+	if len(report.ByFeature) != 2 || report.ByFeature[0].Key != FeatureOCR {
+		t.Errorf("Unexpected features: %+v", report.ByFeature)
+	}
+	if len(report.ByDay) != 2 || report.ByDay[0].Key != "2024-03-01" || report.ByDay[1].Key != "2024-03-02" {
+		t.Errorf("Expected days in order, got %+v", report.ByDay)
+	}
+	if got := SumCost(records, day1.Add(time.Hour)); got != 0 {
+		t.Errorf("Expected no cost after day 1 calls, got %v", got)
+	}
+}
+
+func TestStartOfPeriods(t *testing.T) {
+	now := time.Date(2024, 3, 15, 13, 45, 0, 0, time.Local)
+	if got := StartOfDay(now); !got.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Unexpected start of day: %v", got)
+	}
+	if got := StartOfMonth(now); !got.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Unexpected start of month: %v", got)
+	}
+}
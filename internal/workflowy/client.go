@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"talus_helper_windows/internal/httpx"
 )
 
 // Client represents a WorkFlowy API client
@@ -20,11 +22,13 @@ type Client struct {
 
 // NewClient creates a new WorkFlowy API client
 func NewClient(config *ClientConfig) *Client {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = httpx.DefaultClient(30 * time.Second)
+	}
 	return &Client{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:     config,
+		httpClient: httpClient,
 	}
 }
 
@@ -207,7 +211,7 @@ func (c *Client) makeRequest(method, path string, body []byte) (*http.Response,
 
 // GetTopLevelNodes retrieves all top-level nodes
 func (c *Client) GetTopLevelNodes() ([]Node, error) {
-	return c.ListNodes("None")
+	return c.ListNodes(TopLevelParent)
 }
 
 // GetChildNodes retrieves child nodes for a given parent
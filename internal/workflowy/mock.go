@@ -244,7 +244,7 @@ func (m *MockClient) UncompleteNode(nodeID string) (*StatusResponse, error) {
 
 // GetTopLevelNodes retrieves all top-level nodes
 func (m *MockClient) GetTopLevelNodes() ([]Node, error) {
-	return m.ListNodes("None")
+	return m.ListNodes(TopLevelParent)
 }
 
 // GetChildNodes retrieves child nodes for a given parent
@@ -1,5 +1,10 @@
 package workflowy
 
+import "net/http"
+
+// TopLevelParent is the parent ID of the top-level nodes
+const TopLevelParent = "None"
+
 // Node represents a WorkFlowy node (bullet point)
 type Node struct {
 	ID          string   `json:"id"`
@@ -57,6 +62,8 @@ type StatusResponse struct {
 type ClientConfig struct {
 	APIKey  string
 	BaseURL string
+	// HTTPClient sends the requests; nil uses a default client with a 30s timeout
+	HTTPClient *http.Client
 }
 
 // NewClientConfig creates a new client configuration with default values
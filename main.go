@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -11,9 +12,13 @@ import (
 //go:embed all:frontend/dist
 var assets embed.FS
 
+// Version is the application version, set at build time with
+// -ldflags "-X main.Version=..."
+var Version = "1.0.0"
+
 func main() {
 	// Create an instance of the app structure
-	app := NewApp()
+	app := NewApp(os.Args[1:])
 
 	// Create application with options
 	err := wails.Run(&options.App{